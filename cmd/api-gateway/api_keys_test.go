@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"server/internal/models"
+	"server/internal/rbac"
+)
+
+// createAPIKey выпускает API-ключ и возвращает его токен
+func (e *testEnv) createAPIKey(token string, scopes ...string) string {
+	e.t.Helper()
+	var resp struct {
+		Token string `json:"token"`
+	}
+	e.doJSON(http.MethodPost, "/api/api-keys", token, map[string]interface{}{"name": "ci", "scopes": scopes}, &resp, http.StatusCreated)
+	return resp.Token
+}
+
+func TestReadOnlyAPIKeyCannotWrite(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser("alice@example.com", "password123", rbac.RoleUser)
+	session := env.login("alice@example.com", "password123")
+	key := env.createAPIKey(session, string(rbac.TodosRead))
+
+	env.doJSON(http.MethodPost, "/api/tags", session, map[string]string{"name": "home"}, nil, http.StatusCreated)
+
+	var tags []struct {
+		Name string `json:"name"`
+	}
+	env.doJSON(http.MethodGet, "/api/tags", key, nil, &tags, http.StatusOK)
+	if len(tags) != 1 || tags[0].Name != "home" {
+		t.Fatalf("tags = %+v, want [home]", tags)
+	}
+	env.doJSON(http.MethodPost, "/api/tags", key, map[string]string{"name": "work"}, nil, http.StatusForbidden)
+	env.doJSON(http.MethodDelete, "/api/todos/1", key, nil, nil, http.StatusForbidden)
+
+	// Ключ с правом записи проходит тот же маршрут
+	writer := env.createAPIKey(session, string(rbac.TodosRead), string(rbac.TodosWrite))
+	env.doJSON(http.MethodPost, "/api/tags", writer, map[string]string{"name": "work"}, nil, http.StatusCreated)
+}
+
+func TestAPIKeyCannotManageAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser("alice@example.com", "password123", rbac.RoleUser)
+	session := env.login("alice@example.com", "password123")
+	key := env.createAPIKey(session, string(rbac.TodosRead), string(rbac.TodosWrite))
+
+	for _, route := range []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodGet, "/api/api-keys", nil},
+		{http.MethodPost, "/api/api-keys", map[string]interface{}{"name": "more", "scopes": []string{string(rbac.TodosRead)}}},
+		{http.MethodPost, "/api/password/change", map[string]string{"current_password": "password123", "new_password": "password456"}},
+		{http.MethodPost, "/api/2fa/totp/enroll", nil},
+		{http.MethodGet, "/api/me/sessions", nil},
+		{http.MethodPost, "/api/logout/all", nil},
+		{http.MethodGet, "/api/identities", nil},
+	} {
+		env.doJSON(route.method, route.path, key, route.body, nil, http.StatusForbidden)
+	}
+
+	// После входа те же маршруты доступны
+	env.doJSON(http.MethodGet, "/api/api-keys", session, nil, nil, http.StatusOK)
+	env.doJSON(http.MethodGet, "/api/me/sessions", session, nil, nil, http.StatusOK)
+}
+
+func TestAPIKeyLimitIgnoresExpiredKeys(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("alice@example.com", "password123", rbac.RoleUser)
+	session := env.login("alice@example.com", "password123")
+
+	expired := time.Now().Add(-time.Hour)
+	for i := 0; i < 50; i++ {
+		err := env.apiKeys.CreateAPIKey(context.Background(), &models.APIKey{
+			UserID:    user.ID,
+			Name:      "old",
+			TokenHash: "expired-" + strconv.Itoa(i),
+			Scopes:    string(rbac.TodosRead),
+			ExpiresAt: &expired,
+			CreatedAt: expired.Add(-time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("CreateAPIKey: %v", err)
+		}
+	}
+	// Истекшие ключи место не занимают, действующие — занимают
+	for i := 0; i < 50; i++ {
+		env.createAPIKey(session, string(rbac.TodosRead))
+	}
+	env.doJSON(http.MethodPost, "/api/api-keys", session, map[string]interface{}{"name": "ci", "scopes": []string{string(rbac.TodosRead)}}, nil, http.StatusConflict)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"server/internal/config"
+	"server/internal/keyring"
+	"server/internal/loginguard"
+	"server/internal/mailer"
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/rbac"
+	"server/internal/repository/repotest"
+	"server/internal/revocation"
+	"server/internal/service"
+)
+
+// Интеграционные тесты поднимают настоящий шлюз (newRouter), UserService
+// и TodoService в одном процессе. gRPC идет через bufconn, вместо PostgreSQL —
+// хранилища в памяти из repotest.
+
+type testEnv struct {
+	t          *testing.T
+	URL        string // адрес шлюза, он же issuer OAuth
+	users      *repotest.Users
+	identities *repotest.Identities
+	sessions   *repotest.Sessions
+	apiKeys    *repotest.APIKeys
+	browser    *http.Client // не следует за перенаправлениями
+}
+
+// newTestEnv поднимает шлюз; options меняют его настройки
+func newTestEnv(t *testing.T, options ...func(*config.Config)) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	gateway := httptest.NewUnstartedServer(nil)
+	issuer := "http://" + gateway.Listener.Addr().String()
+
+	keys, err := keyring.New(repotest.NewSigningKeys(), keyring.AlgEdDSA, time.Hour)
+	if err != nil {
+		t.Fatalf("keyring.New: %v", err)
+	}
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatalf("keys.Load: %v", err)
+	}
+
+	users := repotest.NewUsers()
+	identities := repotest.NewIdentities(users)
+	refreshTokens := repotest.NewRefreshTokens()
+	sessions := repotest.NewSessions(refreshTokens)
+	apiKeys := repotest.NewAPIKeys()
+	guard := loginguard.New(loginguard.NewMemoryStore(),
+		loginguard.Policy{FreeAttempts: 100, BaseDelay: time.Second, MaxDelay: time.Minute, ResetAfter: time.Hour},
+		loginguard.Policy{FreeAttempts: 100, BaseDelay: time.Second, MaxDelay: time.Minute, ResetAfter: time.Hour},
+	)
+	userService := service.NewUserServiceServer(users, refreshTokens, repotest.NewUserTokens(), repotest.NewTOTP(), apiKeys, repotest.NewOAuth(), identities, sessions,
+		revocation.NewMemoryStore(), guard, keys, memMailer{}, service.UserServiceConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: time.Hour,
+			OAuthIssuer:     issuer,
+		})
+
+	userServer := grpc.NewServer(grpc.UnaryInterceptor(rbac.UnaryServerInterceptor(service.UserMethodPermissions, userService.UserRole)))
+	proto.RegisterUserServiceServer(userServer, userService)
+	userClient := proto.NewUserServiceClient(serveBufconn(t, userServer))
+
+	// Задачи и списки в памяти реализованы не полностью; метки — полностью
+	tags := repotest.NewTags()
+	todoService := service.NewTodoServiceServer(repotest.NewTodos(tags), repotest.NewLists(), tags, userClient, 5)
+	todoServer := grpc.NewServer(grpc.UnaryInterceptor(rbac.UnaryServerInterceptor(service.TodoMethodPermissions, rbac.RoleFromIncomingContext)))
+	proto.RegisterTodoServiceServer(todoServer, todoService)
+	todoClient := proto.NewTodoServiceClient(serveBufconn(t, todoServer))
+
+	cfg := &config.Config{
+		AuthMode:            config.AuthModeLocalRevocation,
+		RevocationCacheTTL:  time.Second,
+		JWTSigningAlgorithm: keyring.AlgEdDSA,
+		OAuthIssuer:         issuer,
+		OAuthLoginURL:       issuer + "/login",
+		OIDCLoginResultURL:  issuer + "/login/sso",
+	}
+	for _, option := range options {
+		option(cfg)
+	}
+	router, err := newRouter(cfg, userClient, todoClient)
+	if err != nil {
+		t.Fatalf("newRouter: %v", err)
+	}
+	gateway.Config.Handler = router
+	gateway.Start()
+	t.Cleanup(gateway.Close)
+
+	return &testEnv{
+		t:          t,
+		URL:        issuer,
+		users:      users,
+		identities: identities,
+		sessions:   sessions,
+		apiKeys:    apiKeys,
+		browser: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}},
+	}
+}
+
+// serveBufconn запускает gRPC-сервер в памяти и подключается к нему
+func serveBufconn(t *testing.T, server *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// addUser создает пользователя с подтвержденным email
+func (e *testEnv) addUser(email, password, role string) *models.User {
+	e.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		e.t.Fatalf("bcrypt: %v", err)
+	}
+	user := &models.User{Email: email, Password: string(hash), Role: role, EmailVerified: true}
+	if err := e.users.CreateUser(context.Background(), user); err != nil {
+		e.t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+// login входит через /api/login и возвращает access-токен
+func (e *testEnv) login(email, password string) string {
+	e.t.Helper()
+	var resp struct {
+		Token string `json:"token"`
+	}
+	e.doJSON(http.MethodPost, "/api/login", "", map[string]string{"email": email, "password": password}, &resp, http.StatusOK)
+	return resp.Token
+}
+
+// doJSON выполняет запрос к шлюзу и проверяет код ответа
+func (e *testEnv) doJSON(method, path, token string, body, out interface{}, wantStatus ...int) *http.Response {
+	e.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			e.t.Fatalf("marshal: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, e.URL+path, reader)
+	if err != nil {
+		e.t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := e.browser.Do(req)
+	if err != nil {
+		e.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var raw bytes.Buffer
+	raw.ReadFrom(resp.Body)
+	if len(wantStatus) > 0 && resp.StatusCode != wantStatus[0] {
+		e.t.Fatalf("%s %s: status %d, want %d; body: %s", method, path, resp.StatusCode, wantStatus[0], raw.String())
+	}
+	if out != nil && raw.Len() > 0 {
+		if err := json.Unmarshal(raw.Bytes(), out); err != nil {
+			e.t.Fatalf("%s %s: decode %q: %v", method, path, raw.String(), err)
+		}
+	}
+	return resp
+}
+
+// memMailer молча принимает письма
+type memMailer struct{}
+
+func (memMailer) Send(ctx context.Context, msg mailer.Message) error {
+	return nil
+}
@@ -5,13 +5,10 @@ import (
 	"log"
 	"net/http"
 	
-	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
 	"server/internal/config"
-	"server/internal/handler"
-	"server/internal/middleware"
 	"server/internal/proto"
 )
 
@@ -37,26 +34,9 @@ func main() {
 	defer todoConn.Close()
 	todoClient := proto.NewTodoServiceClient(todoConn)
 
-	// Инициализация Gin-роутера
-	router := gin.Default()
-
-	// Инициализация хэндлеров
-	userHandler := handler.NewUserHandler(userClient)
-	todoHandler := handler.NewTodoHandler(todoClient)
-
-	// Маршруты без аутентификации
-	router.POST("/api/register", userHandler.Register)
-	router.POST("/api/login", userHandler.Login)
-
-	// Маршруты, требующие аутентификации
-	authGroup := router.Group("/api")
-	authGroup.Use(middleware.AuthMiddleware(userClient))
-	{
-		// Маршруты для TodoService
-		authGroup.POST("/todos", todoHandler.CreateTodo)
-		authGroup.GET("/todos", todoHandler.GetTodos)
-		authGroup.PUT("/todos/:id", todoHandler.UpdateTodo)
-		authGroup.DELETE("/todos/:id", todoHandler.DeleteTodo)
+	router, err := newRouter(cfg, userClient, todoClient)
+	if err != nil {
+		log.Fatalf("failed to set up router: %v", err)
 	}
 
 	// Запуск REST-сервера
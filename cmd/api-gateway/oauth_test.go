@@ -0,0 +1,494 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"server/internal/keyring"
+	"server/internal/oidc"
+)
+
+// fakeClientApp — приложение, которое входит через наш сервер авторизации
+// так же, как это сделал бы настоящий клиент: по документу discovery,
+// с PKCE и проверкой ID-токена по JWKS
+type fakeClientApp struct {
+	t      *testing.T
+	server *httptest.Server
+
+	discovery    oidc.Discovery
+	clientID     string
+	clientSecret string
+
+	mu sync.Mutex
+	// Параметры текущего входа
+	verifier, state, nonce string
+	// Результат последнего возврата на /callback
+	code     string
+	tokens   oauthTokens
+	idClaims jwt.MapClaims
+	userInfo map[string]interface{}
+	err      string
+}
+
+type oauthTokens struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+	Error       string `json:"error"`
+}
+
+func newFakeClientApp(t *testing.T, env *testEnv) *fakeClientApp {
+	t.Helper()
+	app := &fakeClientApp{t: t}
+	app.server = httptest.NewServer(http.HandlerFunc(app.callback))
+	t.Cleanup(app.server.Close)
+
+	resp, err := http.Get(env.URL + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatalf("discovery: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&app.discovery); err != nil {
+		t.Fatalf("decode discovery: %v", err)
+	}
+	return app
+}
+
+func (a *fakeClientApp) redirectURI() string {
+	return a.server.URL + "/callback"
+}
+
+// authorizeURL начинает новый вход и возвращает адрес для браузера
+func (a *fakeClientApp) authorizeURL(scope string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.verifier, a.state, a.nonce = randomString(a.t), randomString(a.t), randomString(a.t)
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {a.clientID},
+		"redirect_uri":          {a.redirectURI()},
+		"scope":                 {scope},
+		"state":                 {a.state},
+		"nonce":                 {a.nonce},
+		"code_challenge":        {oidc.S256Challenge(a.verifier)},
+		"code_challenge_method": {oidc.CodeChallengeMethodS256},
+	}
+	return a.discovery.AuthorizationEndpoint + "?" + params.Encode()
+}
+
+func (a *fakeClientApp) callback(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	query := r.URL.Query()
+	a.code, a.tokens, a.idClaims, a.userInfo, a.err = "", oauthTokens{}, nil, nil, ""
+
+	if query.Get("state") != a.state {
+		a.err = "state mismatch"
+		http.Error(w, a.err, http.StatusBadRequest)
+		return
+	}
+	if e := query.Get("error"); e != "" {
+		a.err = e
+		http.Error(w, e, http.StatusBadRequest)
+		return
+	}
+	if query.Get("iss") != a.discovery.Issuer {
+		a.err = "issuer mismatch"
+		http.Error(w, a.err, http.StatusBadRequest)
+		return
+	}
+
+	a.code = query.Get("code")
+	a.tokens = a.exchange(a.code, a.verifier)
+	if a.tokens.Error != "" {
+		a.err = a.tokens.Error
+		http.Error(w, a.err, http.StatusBadRequest)
+		return
+	}
+	if claims, err := a.verifyIDToken(a.tokens.IDToken); err != nil {
+		a.err = "invalid id_token: " + err.Error()
+		http.Error(w, a.err, http.StatusBadRequest)
+		return
+	} else {
+		a.idClaims = claims
+	}
+	status, info := a.fetchUserInfo(a.tokens.AccessToken)
+	if status != http.StatusOK {
+		a.err = fmt.Sprintf("userinfo status %d", status)
+		http.Error(w, a.err, http.StatusBadGateway)
+		return
+	}
+	a.userInfo = info
+	fmt.Fprintf(w, "signed in as %v", info["email"])
+}
+
+// exchange обменивает код на токены; секрет передается через HTTP Basic
+func (a *fakeClientApp) exchange(code, verifier string) oauthTokens {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.redirectURI()},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, a.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		a.t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatalf("token request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var tokens oauthTokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		a.t.Fatalf("decode token response: %v", err)
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" {
+		a.t.Errorf("token response Cache-Control = %q, want no-store", resp.Header.Get("Cache-Control"))
+	}
+	return tokens
+}
+
+func (a *fakeClientApp) fetchUserInfo(accessToken string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(http.MethodGet, a.discovery.UserinfoEndpoint, nil)
+	if err != nil {
+		a.t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatalf("userinfo request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var info map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&info)
+	return resp.StatusCode, info
+}
+
+// verifyIDToken проверяет подпись по JWKS и обязательные claims
+func (a *fakeClientApp) verifyIDToken(idToken string) (jwt.MapClaims, error) {
+	resp, err := http.Get(a.discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var jwks struct {
+		Keys []keyring.JWK `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		for _, key := range jwks.Keys {
+			if key.Kid == t.Header["kid"] && key.Kty == "OKP" && t.Method.Alg() == "EdDSA" {
+				x, err := base64.RawURLEncoding.DecodeString(key.X)
+				if err != nil {
+					return nil, err
+				}
+				return ed25519.PublicKey(x), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key %v", t.Header["kid"])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims["iss"] != a.discovery.Issuer {
+		return nil, fmt.Errorf("iss = %v", claims["iss"])
+	}
+	if !claims.VerifyAudience(a.clientID, true) {
+		return nil, fmt.Errorf("aud = %v", claims["aud"])
+	}
+	if claims["nonce"] != a.nonce {
+		return nil, fmt.Errorf("nonce = %v", claims["nonce"])
+	}
+	return claims, nil
+}
+
+func randomString(t *testing.T) string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// oauthEnv — шлюз, администратор, пользователь и зарегистрированное приложение
+type oauthEnv struct {
+	*testEnv
+	app       *fakeClientApp
+	userToken string
+}
+
+func newOAuthEnv(t *testing.T) *oauthEnv {
+	env := newTestEnv(t)
+	env.addUser("admin@example.com", "admin-password", "admin")
+	env.addUser("alice@example.com", "alice-password", "user")
+	adminToken := env.login("admin@example.com", "admin-password")
+
+	app := newFakeClientApp(t, env)
+	var created struct {
+		Client struct {
+			ClientID     string `json:"client_id"`
+			Confidential bool   `json:"confidential"`
+		} `json:"client"`
+		ClientSecret string `json:"client_secret"`
+	}
+	env.doJSON(http.MethodPost, "/api/admin/oauth/clients", adminToken, map[string]interface{}{
+		"name":          "Fake app",
+		"redirect_uris": []string{app.redirectURI()},
+		"confidential":  true,
+	}, &created, http.StatusCreated)
+	if created.Client.ClientID == "" || created.ClientSecret == "" || !created.Client.Confidential {
+		t.Fatalf("unexpected client registration response: %+v", created)
+	}
+	app.clientID, app.clientSecret = created.Client.ClientID, created.ClientSecret
+
+	return &oauthEnv{testEnv: env, app: app, userToken: env.login("alice@example.com", "alice-password")}
+}
+
+// signIn проходит путь браузера: точка авторизации, страница входа
+// (согласие через /api/oauth/authorize) и возврат в приложение
+func (e *oauthEnv) signIn(scope string) *http.Response {
+	e.t.Helper()
+	resp, err := e.browser.Get(e.app.authorizeURL(scope))
+	if err != nil {
+		e.t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		e.t.Fatalf("authorize: status %d, want 302", resp.StatusCode)
+	}
+	loginPage, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(loginPage.String(), e.URL+"/login?") {
+		e.t.Fatalf("authorize redirected to %q, want the login page", resp.Header.Get("Location"))
+	}
+
+	// Страница входа передает исходные параметры от имени вошедшего пользователя
+	query := loginPage.Query()
+	payload := map[string]string{}
+	for _, key := range []string{"response_type", "client_id", "redirect_uri", "scope", "state", "nonce", "code_challenge", "code_challenge_method"} {
+		payload[key] = query.Get(key)
+	}
+	var approved struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	e.doJSON(http.MethodPost, "/api/oauth/authorize", e.userToken, payload, &approved, http.StatusOK)
+	if !strings.HasPrefix(approved.RedirectTo, e.app.redirectURI()+"?") {
+		e.t.Fatalf("redirect_to = %q, want the app callback", approved.RedirectTo)
+	}
+
+	resp, err = e.browser.Get(approved.RedirectTo)
+	if err != nil {
+		e.t.Fatalf("callback: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestOAuthDiscovery(t *testing.T) {
+	env := newOAuthEnv(t)
+	d := env.app.discovery
+
+	if d.Issuer != env.URL {
+		t.Errorf("issuer = %q, want %q", d.Issuer, env.URL)
+	}
+	for name, endpoint := range map[string]string{
+		"authorization_endpoint": d.AuthorizationEndpoint,
+		"token_endpoint":         d.TokenEndpoint,
+		"userinfo_endpoint":      d.UserinfoEndpoint,
+		"jwks_uri":               d.JWKSURI,
+	} {
+		if !strings.HasPrefix(endpoint, env.URL+"/") {
+			t.Errorf("%s = %q, want an endpoint of %s", name, endpoint, env.URL)
+		}
+	}
+	if len(d.CodeChallengeMethodsSupported) != 1 || d.CodeChallengeMethodsSupported[0] != "S256" {
+		t.Errorf("code_challenge_methods_supported = %v, want [S256]", d.CodeChallengeMethodsSupported)
+	}
+}
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	env := newOAuthEnv(t)
+
+	resp := env.signIn("openid email")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback status %d, app error %q", resp.StatusCode, env.app.err)
+	}
+
+	app := env.app
+	alice, _ := env.users.GetUserByEmail(context.Background(), "alice@example.com")
+	wantSub := fmt.Sprintf("%d", alice.ID)
+	if app.tokens.TokenType != "Bearer" || app.tokens.Scope != "openid email" {
+		t.Errorf("token response = %+v", app.tokens)
+	}
+	if app.idClaims["sub"] != wantSub || app.idClaims["email"] != "alice@example.com" {
+		t.Errorf("id_token claims = %v", app.idClaims)
+	}
+	if app.userInfo["sub"] != wantSub || app.userInfo["email"] != "alice@example.com" || app.userInfo["email_verified"] != true {
+		t.Errorf("userinfo = %v", app.userInfo)
+	}
+}
+
+func TestOAuthUserInfoWithoutEmailScope(t *testing.T) {
+	env := newOAuthEnv(t)
+
+	if resp := env.signIn("openid"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback status %d, app error %q", resp.StatusCode, env.app.err)
+	}
+	if _, ok := env.app.userInfo["email"]; ok {
+		t.Errorf("userinfo without email scope = %v, want no email", env.app.userInfo)
+	}
+	if _, ok := env.app.idClaims["email"]; ok {
+		t.Errorf("id_token without email scope = %v, want no email", env.app.idClaims)
+	}
+}
+
+func TestOAuthCodeReuseRevokesTokens(t *testing.T) {
+	env := newOAuthEnv(t)
+	if resp := env.signIn("openid email"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback status %d, app error %q", resp.StatusCode, env.app.err)
+	}
+	app := env.app
+
+	replayed := app.exchange(app.code, app.verifier)
+	if replayed.Error != "invalid_grant" {
+		t.Fatalf("second exchange error = %q, want invalid_grant", replayed.Error)
+	}
+	if status, _ := app.fetchUserInfo(app.tokens.AccessToken); status != http.StatusUnauthorized {
+		t.Errorf("userinfo with token from a replayed code: status %d, want 401", status)
+	}
+}
+
+func TestOAuthRejectsWrongVerifierAndSecret(t *testing.T) {
+	env := newOAuthEnv(t)
+	app := env.app
+
+	// Приложение, которое не обменивает код само: нужен "сырой" код
+	resp, _ := env.browser.Get(app.authorizeURL("openid"))
+	resp.Body.Close()
+	loginPage, _ := url.Parse(resp.Header.Get("Location"))
+	query := loginPage.Query()
+	payload := map[string]string{}
+	for key := range query {
+		payload[key] = query.Get(key)
+	}
+	var approved struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	env.doJSON(http.MethodPost, "/api/oauth/authorize", env.userToken, payload, &approved, http.StatusOK)
+	redirect, _ := url.Parse(approved.RedirectTo)
+	code := redirect.Query().Get("code")
+
+	if got := app.exchange(code, randomString(t)); got.Error != "invalid_grant" {
+		t.Errorf("exchange with a wrong verifier: error %q, want invalid_grant", got.Error)
+	}
+	secret := app.clientSecret
+	app.clientSecret = "wrong"
+	if got := app.exchange(code, app.verifier); got.Error != "invalid_client" {
+		t.Errorf("exchange with a wrong secret: error %q, want invalid_client", got.Error)
+	}
+	// Неудачные попытки не сжигают код
+	app.clientSecret = secret
+	if got := app.exchange(code, app.verifier); got.Error != "" || got.AccessToken == "" {
+		t.Errorf("exchange with the right verifier: %+v", got)
+	}
+}
+
+func TestOAuthAuthorizeRequestErrors(t *testing.T) {
+	env := newOAuthEnv(t)
+	app := env.app
+
+	t.Run("unregistered redirect_uri is not followed", func(t *testing.T) {
+		target, _ := url.Parse(app.authorizeURL("openid"))
+		query := target.Query()
+		query.Set("redirect_uri", "https://evil.example/callback")
+		target.RawQuery = query.Encode()
+
+		resp, err := env.browser.Get(target.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("status %d, want 400", resp.StatusCode)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		param, value, wantError string
+	}{
+		"PKCE is required":      {"code_challenge", "", "invalid_request"},
+		"plain PKCE is refused": {"code_challenge_method", "plain", "invalid_request"},
+		"unknown scope":         {"scope", "openid todos", "invalid_scope"},
+		"implicit flow":         {"response_type", "token", "unsupported_response_type"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			target, _ := url.Parse(app.authorizeURL("openid"))
+			query := target.Query()
+			query.Set(tc.param, tc.value)
+			target.RawQuery = query.Encode()
+
+			resp, err := env.browser.Get(target.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			location, _ := url.Parse(resp.Header.Get("Location"))
+			if resp.StatusCode != http.StatusFound || !strings.HasPrefix(location.String(), app.redirectURI()) {
+				t.Fatalf("status %d, location %q; want a redirect to the app", resp.StatusCode, location)
+			}
+			if got := location.Query().Get("error"); got != tc.wantError {
+				t.Errorf("error = %q, want %q", got, tc.wantError)
+			}
+			if location.Query().Get("state") != query.Get("state") {
+				t.Errorf("state was not returned")
+			}
+		})
+	}
+}
+
+func TestOAuthTokensAreNotAPICredentials(t *testing.T) {
+	env := newOAuthEnv(t)
+	if resp := env.signIn("openid email"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback status %d, app error %q", resp.StatusCode, env.app.err)
+	}
+
+	for name, token := range map[string]string{
+		"access_token": env.app.tokens.AccessToken,
+		"id_token":     env.app.tokens.IDToken,
+	} {
+		resp := env.doJSON(http.MethodGet, "/api/todos", token, nil, nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s accepted by the API: status %d", name, resp.StatusCode)
+		}
+	}
+}
+
+func TestOAuthClientRegistrationRequiresAdmin(t *testing.T) {
+	env := newOAuthEnv(t)
+	env.doJSON(http.MethodPost, "/api/admin/oauth/clients", env.userToken, map[string]interface{}{
+		"name":          "Sneaky app",
+		"redirect_uris": []string{"https://app.example/callback"},
+	}, nil, http.StatusForbidden)
+}
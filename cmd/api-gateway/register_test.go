@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"server/internal/rbac"
+)
+
+func TestRegisterIgnoresRequestedRole(t *testing.T) {
+	env := newTestEnv(t)
+	env.doJSON(http.MethodPost, "/api/register", "", map[string]string{
+		"email":    "mallory@example.com",
+		"password": "password123",
+		"role":     rbac.RoleAdmin,
+	}, nil, http.StatusOK)
+
+	user, err := env.users.GetUserByEmail(context.Background(), "mallory@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if user.Role != rbac.DefaultRole {
+		t.Fatalf("role = %q, want %q", user.Role, rbac.DefaultRole)
+	}
+
+	token := env.login("mallory@example.com", "password123")
+	env.doJSON(http.MethodGet, "/api/admin/users", token, nil, nil, http.StatusForbidden)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"server/internal/config"
+	"server/internal/handler"
+	"server/internal/middleware"
+	"server/internal/oidc"
+	"server/internal/proto"
+	"server/internal/rbac"
+)
+
+// newRouter собирает маршруты шлюза
+func newRouter(cfg *config.Config, userClient proto.UserServiceClient, todoClient proto.TodoServiceClient) (*gin.Engine, error) {
+	// Инициализация Gin-роутера
+	router := gin.Default()
+	// X-Forwarded-For принимается только от известных прокси, иначе клиент
+	// мог бы подставить чужой IP и обойти ограничение попыток входа
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	// Инициализация хэндлеров
+	userHandler := handler.NewUserHandler(userClient)
+	todoHandler := handler.NewTodoHandler(todoClient)
+	listHandler := handler.NewListHandler(todoClient)
+	tagHandler := handler.NewTagHandler(todoClient)
+	trashHandler := handler.NewTrashHandler(todoClient)
+	adminHandler := handler.NewAdminHandler(userClient)
+	oauthHandler := handler.NewOAuthHandler(userClient, cfg.OAuthIssuer, cfg.OAuthLoginURL, cfg.JWTSigningAlgorithm)
+
+	// Внешние провайдеры для входа через SSO
+	var providers []*oidc.Provider
+	for _, p := range cfg.OIDCProviders {
+		providers = append(providers, oidc.NewProvider(oidc.ProviderConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  cfg.OAuthIssuer + "/auth/oidc/" + p.Name + "/callback",
+			Scopes:       p.Scopes,
+		}))
+	}
+	ssoHandler := handler.NewSSOHandler(userClient, providers, cfg.OIDCLoginResultURL, strings.HasPrefix(cfg.OAuthIssuer, "https://"))
+
+	// Маршруты без аутентификации
+	router.POST("/api/register", userHandler.Register)
+	router.POST("/api/login", userHandler.Login)
+	router.POST("/api/login/2fa", userHandler.LoginSecondFactor)
+	router.POST("/api/token/refresh", userHandler.RefreshToken)
+	router.POST("/api/password/reset", userHandler.RequestPasswordReset)
+	router.POST("/api/password/reset/confirm", userHandler.ConfirmPasswordReset)
+	router.POST("/api/email/verify", userHandler.VerifyEmail)
+	router.POST("/api/email/verify/resend", userHandler.ResendVerificationEmail)
+	router.GET("/.well-known/jwks.json", userHandler.JWKS)
+
+	// Сервер авторизации OAuth 2.0 / OpenID Connect
+	router.GET("/.well-known/openid-configuration", oauthHandler.Discovery)
+	router.GET("/oauth/authorize", oauthHandler.Authorize)
+	router.POST("/oauth/token", oauthHandler.Token)
+	router.GET("/oauth/userinfo", oauthHandler.UserInfo)
+	router.POST("/oauth/userinfo", oauthHandler.UserInfo)
+
+	// Вход через внешнего провайдера OpenID Connect
+	router.GET("/auth/oidc/:provider/login", ssoHandler.Login)
+	router.GET("/auth/oidc/:provider/callback", ssoHandler.Callback)
+
+	// Маршруты, требующие аутентификации
+	authGroup := router.Group("/api")
+	switch cfg.AuthMode {
+	case config.AuthModeRemote:
+		authGroup.Use(middleware.AuthMiddleware(userClient))
+	case config.AuthModeLocal:
+		authGroup.Use(middleware.LocalAuthMiddleware(middleware.NewTokenVerifier(userClient, 0)))
+	default:
+		authGroup.Use(middleware.LocalAuthMiddleware(middleware.NewTokenVerifier(userClient, cfg.RevocationCacheTTL)))
+	}
+	{
+		// Учетная запись: доступна только после входа, не по API-ключу
+		account := authGroup.Group("", middleware.RequireSession())
+
+		// Выход: отзыв текущего токена или всех токенов пользователя
+		account.POST("/logout", userHandler.Logout)
+		account.POST("/logout/all", userHandler.LogoutAll)
+		account.POST("/password/change", userHandler.ChangePassword)
+
+		// Второй фактор
+		account.POST("/2fa/totp/enroll", userHandler.EnrollTOTP)
+		account.POST("/2fa/totp/confirm", userHandler.ConfirmTOTP)
+		account.POST("/2fa/totp/disable", userHandler.DisableTOTP)
+
+		// Персональные токены доступа (API-ключи)
+		account.POST("/api-keys", userHandler.CreateAPIKey)
+		account.GET("/api-keys", userHandler.ListAPIKeys)
+		account.DELETE("/api-keys/:id", userHandler.RevokeAPIKey)
+
+		// Сессии (устройства, на которых выполнен вход)
+		account.GET("/me/sessions", userHandler.ListSessions)
+		account.DELETE("/me/sessions/:id", userHandler.RevokeSession)
+
+		// Учетные записи внешних провайдеров
+		account.GET("/identities", ssoHandler.ListIdentities)
+		account.POST("/identities/:provider/link", ssoHandler.Link)
+		account.DELETE("/identities/:provider", ssoHandler.UnlinkIdentity)
+
+		// Согласие на вход в приложение через OAuth
+		account.POST("/oauth/authorize", oauthHandler.Approve)
+
+		// Администрирование
+		authGroup.POST("/admin/keys/rotate", middleware.RequirePermission(rbac.KeysRotate), userHandler.RotateSigningKey)
+
+		// Управление пользователями
+		adminUsers := authGroup.Group("/admin/users", middleware.RequirePermission(rbac.UsersManage))
+		adminUsers.GET("", adminHandler.ListUsers)
+		adminUsers.GET("/:id", adminHandler.GetUser)
+		adminUsers.DELETE("/:id", adminHandler.DeleteUser)
+		adminUsers.PUT("/:id/role", adminHandler.ChangeUserRole)
+		adminUsers.POST("/:id/disable", adminHandler.DisableUser)
+		adminUsers.POST("/:id/enable", adminHandler.EnableUser)
+		adminUsers.POST("/:id/password/reset", adminHandler.ResetUserPassword)
+		adminUsers.POST("/:id/unlock", adminHandler.UnlockUser)
+
+		// Приложения OAuth
+		oauthClients := authGroup.Group("/admin/oauth/clients", middleware.RequirePermission(rbac.OAuthClientsManage))
+		oauthClients.POST("", oauthHandler.CreateClient)
+		oauthClients.GET("", oauthHandler.ListClients)
+		oauthClients.DELETE("/:client_id", oauthHandler.DeleteClient)
+
+		// Маршруты для TodoService. Чтение и изменение задач, списков и меток
+		// требуют разных прав: без подтвержденного email доступно только чтение.
+		todosRead := authGroup.Group("", middleware.RequirePermission(rbac.TodosRead))
+		todosWrite := authGroup.Group("", middleware.RequirePermission(rbac.TodosWrite))
+
+		todosWrite.POST("/todos", todoHandler.CreateTodo)
+		todosRead.GET("/todos", todoHandler.GetTodos)
+		todosRead.GET("/todos/search", todoHandler.SearchTodos)
+		todosRead.GET("/todos/:id", todoHandler.GetTodo)
+		todosWrite.PUT("/todos/:id", todoHandler.UpdateTodo)
+		todosWrite.PATCH("/todos/:id", todoHandler.PatchTodo)
+		todosWrite.DELETE("/todos/:id", todoHandler.DeleteTodo)
+		todosWrite.PUT("/todos/:id/list", todoHandler.MoveTodo)
+		todosWrite.POST("/todos/:id/move", todoHandler.ReorderTodo)
+
+		// Списки задач
+		todosRead.GET("/lists", listHandler.GetLists)
+		todosWrite.POST("/lists", listHandler.CreateList)
+		todosWrite.PUT("/lists/:id", listHandler.UpdateList)
+		todosWrite.DELETE("/lists/:id", listHandler.DeleteList)
+		todosRead.GET("/lists/:id/todos", todoHandler.GetTodos)
+		todosWrite.POST("/lists/:id/todos", todoHandler.CreateTodo)
+
+		// Метки
+		todosRead.GET("/tags", tagHandler.GetTags)
+		todosWrite.POST("/tags", tagHandler.CreateTag)
+		todosWrite.PUT("/tags/:id", tagHandler.UpdateTag)
+		todosWrite.DELETE("/tags/:id", tagHandler.DeleteTag)
+
+		// Корзина
+		todosRead.GET("/trash", trashHandler.ListTrash)
+		todosWrite.POST("/trash/:id/restore", trashHandler.RestoreTodo)
+		todosWrite.DELETE("/trash/:id", trashHandler.PurgeTodo)
+	}
+
+	return router, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"server/internal/config"
+	"server/internal/rbac"
+)
+
+type sessionsResponse struct {
+	Sessions []struct {
+		ID        string `json:"id"`
+		UserAgent string `json:"user_agent"`
+		IP        string `json:"ip"`
+		Current   bool   `json:"current"`
+	} `json:"sessions"`
+}
+
+type loginTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// noRevocationCache — шлюз спрашивает UserService об отзыве на каждый запрос,
+// так что тесты видят отзыв сессии сразу
+func noRevocationCache(cfg *config.Config) {
+	cfg.RevocationCacheTTL = time.Nanosecond
+}
+
+// loginFrom входит с заданным User-Agent
+func (e *testEnv) loginFrom(userAgent, email, password string) loginTokens {
+	e.t.Helper()
+	body, _ := json.Marshal(map[string]string{"email": email, "password": password})
+	req, err := http.NewRequest(http.MethodPost, e.URL+"/api/login", bytes.NewReader(body))
+	if err != nil {
+		e.t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := e.browser.Do(req)
+	if err != nil {
+		e.t.Fatalf("login: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		e.t.Fatalf("login: status %d", resp.StatusCode)
+	}
+	var tokens loginTokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		e.t.Fatalf("login: decode: %v", err)
+	}
+	return tokens
+}
+
+func (e *testEnv) listSessions(token string) sessionsResponse {
+	e.t.Helper()
+	var resp sessionsResponse
+	e.doJSON(http.MethodGet, "/api/me/sessions", token, nil, &resp, http.StatusOK)
+	return resp
+}
+
+func TestSessionsListAndRevoke(t *testing.T) {
+	env := newTestEnv(t, noRevocationCache)
+	env.addUser("alice@example.com", "password123", rbac.RoleUser)
+	laptop := env.loginFrom("Laptop/1.0", "alice@example.com", "password123")
+	phone := env.loginFrom("Phone/2.0", "alice@example.com", "password123")
+
+	list := env.listSessions(laptop.Token)
+	if len(list.Sessions) != 2 {
+		t.Fatalf("sessions = %+v, want 2", list.Sessions)
+	}
+	var phoneSession string
+	for _, session := range list.Sessions {
+		if session.IP != "127.0.0.1" {
+			t.Errorf("session %s: ip = %q", session.ID, session.IP)
+		}
+		switch session.UserAgent {
+		case "Laptop/1.0":
+			if !session.Current {
+				t.Errorf("laptop session is not marked current")
+			}
+		case "Phone/2.0":
+			if session.Current {
+				t.Errorf("phone session is marked current")
+			}
+			phoneSession = session.ID
+		default:
+			t.Errorf("unexpected user agent %q", session.UserAgent)
+		}
+	}
+
+	// Чужую сессию завершить нельзя
+	env.addUser("bob@example.com", "password123", rbac.RoleUser)
+	bob := env.login("bob@example.com", "password123")
+	env.doJSON(http.MethodDelete, "/api/me/sessions/"+phoneSession, bob, nil, nil, http.StatusNotFound)
+
+	env.doJSON(http.MethodDelete, "/api/me/sessions/"+phoneSession, laptop.Token, nil, nil, http.StatusOK)
+	env.doJSON(http.MethodGet, "/api/me/sessions", phone.Token, nil, nil, http.StatusUnauthorized)
+	env.doJSON(http.MethodPost, "/api/token/refresh", "", map[string]string{"refresh_token": phone.RefreshToken}, nil, http.StatusUnauthorized)
+	env.doJSON(http.MethodDelete, "/api/me/sessions/"+phoneSession, laptop.Token, nil, nil, http.StatusNotFound)
+
+	list = env.listSessions(laptop.Token)
+	if len(list.Sessions) != 1 || !list.Sessions[0].Current {
+		t.Fatalf("sessions after revoke = %+v, want only the current one", list.Sessions)
+	}
+}
+
+func TestSessionSurvivesRefreshAndEndsOnLogout(t *testing.T) {
+	env := newTestEnv(t, noRevocationCache)
+	env.addUser("alice@example.com", "password123", rbac.RoleUser)
+	first := env.loginFrom("Laptop/1.0", "alice@example.com", "password123")
+	other := env.loginFrom("Phone/2.0", "alice@example.com", "password123")
+
+	var refreshed loginTokens
+	env.doJSON(http.MethodPost, "/api/token/refresh", "", map[string]string{"refresh_token": first.RefreshToken}, &refreshed, http.StatusOK)
+	list := env.listSessions(refreshed.Token)
+	if len(list.Sessions) != 2 {
+		t.Fatalf("refresh created a new session: %+v", list.Sessions)
+	}
+	for _, session := range list.Sessions {
+		if session.Current != (session.UserAgent == "Laptop/1.0") {
+			t.Errorf("session %q: current = %v", session.UserAgent, session.Current)
+		}
+	}
+
+	// Выход завершает сессию, и старый access-токен той же сессии перестает действовать
+	env.doJSON(http.MethodPost, "/api/logout", refreshed.Token, nil, nil, http.StatusOK)
+	env.doJSON(http.MethodGet, "/api/me/sessions", first.Token, nil, nil, http.StatusUnauthorized)
+	list = env.listSessions(other.Token)
+	if len(list.Sessions) != 1 || list.Sessions[0].UserAgent != "Phone/2.0" {
+		t.Fatalf("sessions after logout = %+v", list.Sessions)
+	}
+}
+
+func TestSessionLastSeenIsThrottled(t *testing.T) {
+	env := newTestEnv(t, noRevocationCache)
+	env.addUser("alice@example.com", "password123", rbac.RoleUser)
+	tokens := env.loginFrom("Laptop/1.0", "alice@example.com", "password123")
+
+	var id string
+	for i := 0; i < 5; i++ {
+		list := env.listSessions(tokens.Token)
+		if len(list.Sessions) != 1 {
+			t.Fatalf("sessions = %+v", list.Sessions)
+		}
+		id = list.Sessions[0].ID
+	}
+	if n := env.sessions.TouchCount(id); n != 1 {
+		t.Fatalf("last-seen written %d times for 5 requests, want 1", n)
+	}
+}
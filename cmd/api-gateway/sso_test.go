@@ -0,0 +1,570 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"server/internal/config"
+	"server/internal/models"
+	"server/internal/oidc"
+)
+
+// stubIssuer — провайдер OpenID Connect в том же процессе: сразу
+// "входит" пользователем user и выдает коды, токены и JWKS
+type stubIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	clientID, clientSecret string
+	redirectURI            string // задается, когда известен адрес шлюза
+
+	mu   sync.Mutex
+	user stubUser
+	// Ответ на следующий запрос авторизации — ошибка, например access_denied
+	authorizeError string
+	// Email только в userinfo, не в ID-токене
+	emailInUserInfoOnly bool
+	// Портит claims ID-токена перед подписью
+	tamper func(jwt.MapClaims)
+	// Подписывает ID-токен этим ключом вместо опубликованного
+	signingKey *rsa.PrivateKey
+	codes      map[string]stubCode
+	tokens     map[string]stubUser // access-токены для userinfo
+}
+
+type stubUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type stubCode struct {
+	user          stubUser
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+var (
+	stubKeyOnce sync.Once
+	stubKeys    [2]*rsa.PrivateKey
+)
+
+// stubRSAKeys — ключи создаются один раз на все тесты: генерация RSA небыстрая
+func stubRSAKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	stubKeyOnce.Do(func() {
+		for i := range stubKeys {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				t.Fatalf("rsa.GenerateKey: %v", err)
+			}
+			stubKeys[i] = key
+		}
+	})
+	return stubKeys[0], stubKeys[1]
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	key, _ := stubRSAKeys(t)
+	s := &stubIssuer{
+		t:            t,
+		key:          key,
+		clientID:     "todo-gateway",
+		clientSecret: "stub secret/with+symbols",
+		codes:        make(map[string]stubCode),
+		tokens:       make(map[string]stubUser),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.userInfo)
+	mux.HandleFunc("/jwks", s.jwks)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// provider — настройки шлюза для входа через этот провайдер
+func (s *stubIssuer) provider(name string) func(*config.Config) {
+	return func(cfg *config.Config) {
+		s.redirectURI = cfg.OAuthIssuer + "/auth/oidc/" + name + "/callback"
+		cfg.OIDCProviders = append(cfg.OIDCProviders, config.OIDCProvider{
+			Name:         name,
+			Issuer:       s.server.URL,
+			ClientID:     s.clientID,
+			ClientSecret: s.clientSecret,
+			Scopes:       []string{"openid", "email"},
+		})
+	}
+}
+
+func (s *stubIssuer) signIn(user stubUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+func (s *stubIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(oidc.Discovery{
+		Issuer:                           s.server.URL,
+		AuthorizationEndpoint:            s.server.URL + "/authorize",
+		TokenEndpoint:                    s.server.URL + "/token",
+		UserinfoEndpoint:                 s.server.URL + "/userinfo",
+		JWKSURI:                          s.server.URL + "/jwks",
+		ResponseTypesSupported:           []string{"code"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"RS256"},
+	})
+}
+
+func (s *stubIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != s.clientID || query.Get("redirect_uri") != s.redirectURI {
+		http.Error(w, "unknown client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+	redirect, _ := url.Parse(s.redirectURI)
+	params := url.Values{"state": {query.Get("state")}}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.authorizeError != "":
+		params.Set("error", s.authorizeError)
+		s.authorizeError = ""
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" ||
+		query.Get("nonce") == "" || query.Get("scope") != "openid email":
+		params.Set("error", "invalid_request")
+	default:
+		code := randomString(s.t)
+		s.codes[code] = stubCode{
+			user:          s.user,
+			nonce:         query.Get("nonce"),
+			codeChallenge: query.Get("code_challenge"),
+			redirectURI:   query.Get("redirect_uri"),
+		}
+		params.Set("code", code)
+	}
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *stubIssuer) token(w http.ResponseWriter, r *http.Request) {
+	oauthError := func(status int, code string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	clientID, secret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	secret, _ = url.QueryUnescape(secret)
+	if clientID != s.clientID || secret != s.clientSecret {
+		oauthError(http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != code.redirectURI ||
+		oidc.S256Challenge(r.PostFormValue("code_verifier")) != code.codeChallenge {
+		oauthError(http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   s.server.URL,
+		"sub":   code.user.Subject,
+		"aud":   s.clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": code.nonce,
+	}
+	if !s.emailInUserInfoOnly {
+		claims["email"] = code.user.Email
+		claims["email_verified"] = code.user.EmailVerified
+	}
+	if s.tamper != nil {
+		s.tamper(claims)
+	}
+	signingKey := s.key
+	if s.signingKey != nil {
+		signingKey = s.signingKey
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "stub-key"
+	signed, err := idToken.SignedString(signingKey)
+	if err != nil {
+		s.t.Errorf("sign id_token: %v", err)
+		oauthError(http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	accessToken := randomString(s.t)
+	s.tokens[accessToken] = code.user
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (s *stubIssuer) userInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	user, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_token"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sub":            user.Subject,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+	})
+}
+
+func (s *stubIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "stub-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+// ssoBrowser — браузер с cookie, который проходит перенаправления сам
+// и останавливается на странице результата входа
+type ssoBrowser struct {
+	env    *testEnv
+	client *http.Client
+}
+
+func newSSOBrowser(env *testEnv) *ssoBrowser {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		env.t.Fatalf("cookiejar: %v", err)
+	}
+	return &ssoBrowser{env: env, client: &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// visit переходит по адресу и возвращает параметры из фрагмента страницы
+// результата
+func (b *ssoBrowser) visit(target string) url.Values {
+	b.env.t.Helper()
+	resultPage := b.env.URL + "/login/sso"
+	for hops := 0; hops < 10; hops++ {
+		resp, err := b.client.Get(target)
+		if err != nil {
+			b.env.t.Fatalf("GET %s: %v", target, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			b.env.t.Fatalf("GET %s: status %d, want a redirect", target, resp.StatusCode)
+		}
+		location, err := resp.Location()
+		if err != nil {
+			b.env.t.Fatalf("GET %s: %v", target, err)
+		}
+		if strings.HasPrefix(location.String(), resultPage+"#") {
+			result, err := url.ParseQuery(location.Fragment)
+			if err != nil {
+				b.env.t.Fatalf("result fragment %q: %v", location.Fragment, err)
+			}
+			return result
+		}
+		target = location.String()
+	}
+	b.env.t.Fatalf("too many redirects")
+	return nil
+}
+
+func (b *ssoBrowser) login(provider string) url.Values {
+	b.env.t.Helper()
+	return b.visit(b.env.URL + "/auth/oidc/" + provider + "/login")
+}
+
+// link начинает привязку от имени вошедшего пользователя и проходит вход
+// у провайдера в том же браузере
+func (b *ssoBrowser) link(token, provider string) url.Values {
+	b.env.t.Helper()
+	req, err := http.NewRequest(http.MethodPost, b.env.URL+"/api/identities/"+provider+"/link", nil)
+	if err != nil {
+		b.env.t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		b.env.t.Fatalf("link: %v", err)
+	}
+	defer resp.Body.Close()
+	var started struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&started); err != nil || resp.StatusCode != http.StatusOK {
+		b.env.t.Fatalf("link: status %d, err %v", resp.StatusCode, err)
+	}
+	return b.visit(started.RedirectTo)
+}
+
+type identityList struct {
+	Identities []struct {
+		Provider string `json:"provider"`
+		Email    string `json:"email"`
+	} `json:"identities"`
+}
+
+func (e *testEnv) listIdentities(token string) identityList {
+	e.t.Helper()
+	var list identityList
+	e.doJSON(http.MethodGet, "/api/identities", token, nil, &list, http.StatusOK)
+	return list
+}
+
+func wantSignedIn(t *testing.T, result url.Values) string {
+	t.Helper()
+	if result.Get("error") != "" || result.Get("token") == "" || result.Get("refresh_token") == "" {
+		t.Fatalf("sign-in result = %v, want tokens", result)
+	}
+	return result.Get("token")
+}
+
+func wantError(t *testing.T, result url.Values, code string) {
+	t.Helper()
+	if result.Get("error") != code || result.Get("token") != "" {
+		t.Fatalf("sign-in result = %v, want error %q", result, code)
+	}
+}
+
+func TestSSOLoginCreatesUser(t *testing.T) {
+	corp := newStubIssuer(t)
+	env := newTestEnv(t, corp.provider("corp"))
+	corp.signIn(stubUser{Subject: "corp-1", Email: "Bob@Corp.example", EmailVerified: true})
+
+	token := wantSignedIn(t, newSSOBrowser(env).login("corp"))
+
+	list := env.listIdentities(token)
+	if len(list.Identities) != 1 || list.Identities[0].Provider != "corp" {
+		t.Fatalf("identities = %+v, want one corp identity", list.Identities)
+	}
+	bob, err := env.users.GetUserByEmail(context.Background(), "bob@corp.example")
+	if err != nil {
+		t.Fatalf("user was not created: %v", err)
+	}
+	if !bob.EmailVerified || bob.Password != "" {
+		t.Errorf("created user = %+v, want a verified user without password", bob)
+	}
+	// Пароля нет, войти по паролю нельзя
+	env.doJSON(http.MethodPost, "/api/login", "", map[string]string{"email": "bob@corp.example", "password": ""}, nil, http.StatusUnauthorized)
+
+	// Повторный вход находит пользователя по sub, даже если email у
+	// провайдера изменился
+	corp.signIn(stubUser{Subject: "corp-1", Email: "robert@corp.example", EmailVerified: true})
+	token = wantSignedIn(t, newSSOBrowser(env).login("corp"))
+	list = env.listIdentities(token)
+	if len(list.Identities) != 1 || list.Identities[0].Email != "robert@corp.example" {
+		t.Errorf("identities after email change = %+v", list.Identities)
+	}
+	if _, err := env.users.GetUserByEmail(context.Background(), "robert@corp.example"); err == nil {
+		t.Errorf("a second user was created for the same subject")
+	}
+}
+
+func TestSSOEmailOnlyInUserInfo(t *testing.T) {
+	corp := newStubIssuer(t)
+	corp.emailInUserInfoOnly = true
+	env := newTestEnv(t, corp.provider("corp"))
+	corp.signIn(stubUser{Subject: "corp-2", Email: "carol@corp.example", EmailVerified: true})
+
+	wantSignedIn(t, newSSOBrowser(env).login("corp"))
+	if _, err := env.users.GetUserByEmail(context.Background(), "carol@corp.example"); err != nil {
+		t.Errorf("user from userinfo email was not created: %v", err)
+	}
+}
+
+func TestSSOExistingUserByEmail(t *testing.T) {
+	corp := newStubIssuer(t)
+	env := newTestEnv(t, corp.provider("corp"))
+	env.addUser("alice@example.com", "alice-password", "user")
+
+	// Провайдер не подтвердил адрес: привязывать нельзя
+	corp.signIn(stubUser{Subject: "corp-alice", Email: "alice@example.com"})
+	wantError(t, newSSOBrowser(env).login("corp"), "login_failed")
+
+	corp.signIn(stubUser{Subject: "corp-alice", Email: "alice@example.com", EmailVerified: true})
+	token := wantSignedIn(t, newSSOBrowser(env).login("corp"))
+	if list := env.listIdentities(token); len(list.Identities) != 1 {
+		t.Errorf("identities = %+v, want the corp identity", list.Identities)
+	}
+	// Пароль по-прежнему работает
+	env.login("alice@example.com", "alice-password")
+}
+
+func TestSSOUnverifiedLocalAccountIsNotTakenOver(t *testing.T) {
+	corp := newStubIssuer(t)
+	env := newTestEnv(t, corp.provider("corp"))
+	// Кто-то заранее зарегистрировался на чужой адрес и не подтвердил его
+	squatter := env.addUser("dave@corp.example", "squatter-password", "user")
+	env.users.Update(squatter.ID, func(user *models.User) { user.EmailVerified = false })
+
+	corp.signIn(stubUser{Subject: "corp-dave", Email: "dave@corp.example", EmailVerified: true})
+	wantError(t, newSSOBrowser(env).login("corp"), "login_failed")
+	if list, _ := env.identities.ListIdentities(context.Background(), squatter.ID); len(list) != 0 {
+		t.Errorf("identity was linked to an unverified account: %+v", list)
+	}
+}
+
+func TestSSORejectsForgedResponses(t *testing.T) {
+	_, otherKey := stubRSAKeys(t)
+	for name, tc := range map[string]struct {
+		setup     func(*stubIssuer)
+		wantError string
+	}{
+		"wrong audience":  {func(s *stubIssuer) { s.tamper = func(c jwt.MapClaims) { c["aud"] = "another-client" } }, "server_error"},
+		"wrong nonce":     {func(s *stubIssuer) { s.tamper = func(c jwt.MapClaims) { c["nonce"] = "replayed" } }, "server_error"},
+		"wrong issuer":    {func(s *stubIssuer) { s.tamper = func(c jwt.MapClaims) { c["iss"] = "https://evil.example" } }, "server_error"},
+		"expired token":   {func(s *stubIssuer) { s.tamper = func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() } }, "server_error"},
+		"foreign key":     {func(s *stubIssuer) { s.signingKey = otherKey }, "server_error"},
+		"denied by user":  {func(s *stubIssuer) { s.authorizeError = "access_denied" }, "access_denied"},
+		"wrong secret":    {func(s *stubIssuer) { s.clientSecret = "rotated" }, "invalid_client"},
+		"no email at all": {func(s *stubIssuer) { s.signIn(stubUser{Subject: "corp-3"}) }, "login_failed"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			corp := newStubIssuer(t)
+			env := newTestEnv(t, corp.provider("corp"))
+			corp.signIn(stubUser{Subject: "corp-3", Email: "erin@corp.example", EmailVerified: true})
+			tc.setup(corp)
+
+			wantError(t, newSSOBrowser(env).login("corp"), tc.wantError)
+			if _, err := env.users.GetUserByEmail(context.Background(), "erin@corp.example"); err == nil {
+				t.Errorf("user was created from a rejected response")
+			}
+		})
+	}
+}
+
+func TestSSOStateIsBoundToBrowser(t *testing.T) {
+	corp := newStubIssuer(t)
+	env := newTestEnv(t, corp.provider("corp"))
+	corp.signIn(stubUser{Subject: "corp-4", Email: "frank@corp.example", EmailVerified: true})
+
+	// Атакующий получает свой код у провайдера...
+	attacker := newSSOBrowser(env)
+	resp, err := attacker.client.Get(env.URL + "/auth/oidc/corp/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp, err = attacker.client.Get(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback := resp.Header.Get("Location")
+
+	// ...и подсовывает ссылку на возврат жертве, у которой нет его cookie
+	wantError(t, newSSOBrowser(env).visit(callback), "invalid_request")
+
+	// Подмененный state не принимается и в браузере атакующего
+	forged, _ := url.Parse(callback)
+	query := forged.Query()
+	query.Set("state", "forged")
+	forged.RawQuery = query.Encode()
+	wantError(t, attacker.visit(forged.String()), "invalid_request")
+
+	// Cookie одноразовая: после неудачи исходный ответ тоже не принимается
+	wantError(t, attacker.visit(callback), "invalid_request")
+}
+
+func TestSSOLinkSecondProvider(t *testing.T) {
+	corp, partner := newStubIssuer(t), newStubIssuer(t)
+	env := newTestEnv(t, corp.provider("corp"), partner.provider("partner"))
+	env.addUser("alice@example.com", "alice-password", "user")
+	env.addUser("bob@example.com", "bob-password", "user")
+	aliceToken := env.login("alice@example.com", "alice-password")
+
+	// Адрес у партнера другой и не подтвержден, но привязку подтверждает вход
+	partner.signIn(stubUser{Subject: "partner-alice", Email: "a.smith@partner.example"})
+	browser := newSSOBrowser(env)
+	if result := browser.link(aliceToken, "partner"); result.Get("linked") != "partner" {
+		t.Fatalf("link result = %v", result)
+	}
+	corp.signIn(stubUser{Subject: "corp-alice", Email: "alice@corp.example"})
+	if result := browser.link(aliceToken, "corp"); result.Get("linked") != "corp" {
+		t.Fatalf("link result = %v", result)
+	}
+	if list := env.listIdentities(aliceToken); len(list.Identities) != 2 {
+		t.Fatalf("identities = %+v, want corp and partner", list.Identities)
+	}
+
+	// Теперь Алиса входит через партнера
+	token := wantSignedIn(t, newSSOBrowser(env).login("partner"))
+	if list := env.listIdentities(token); len(list.Identities) != 2 {
+		t.Errorf("partner login signed in another user: %+v", list.Identities)
+	}
+
+	// Чужую учетную запись к себе не привязать
+	bobToken := env.login("bob@example.com", "bob-password")
+	wantError(t, newSSOBrowser(env).link(bobToken, "partner"), "already_linked")
+
+	// Привязка без сессии в шлюзе невозможна
+	env.doJSON(http.MethodPost, "/api/identities/partner/link", "", nil, nil, http.StatusUnauthorized)
+}
+
+func TestSSOUnlink(t *testing.T) {
+	corp := newStubIssuer(t)
+	env := newTestEnv(t, corp.provider("corp"))
+
+	// Единственный способ входа отвязать нельзя
+	corp.signIn(stubUser{Subject: "corp-5", Email: "grace@corp.example", EmailVerified: true})
+	ssoOnly := wantSignedIn(t, newSSOBrowser(env).login("corp"))
+	env.doJSON(http.MethodDelete, "/api/identities/corp", ssoOnly, nil, nil, http.StatusConflict)
+
+	env.addUser("heidi@example.com", "heidi-password", "user")
+	token := env.login("heidi@example.com", "heidi-password")
+	corp.signIn(stubUser{Subject: "corp-6", Email: "heidi@corp.example"})
+	newSSOBrowser(env).link(token, "corp")
+	env.doJSON(http.MethodDelete, "/api/identities/corp", token, nil, nil, http.StatusOK)
+	if list := env.listIdentities(token); len(list.Identities) != 0 {
+		t.Errorf("identities after unlink = %+v", list.Identities)
+	}
+	env.doJSON(http.MethodDelete, "/api/identities/corp", token, nil, nil, http.StatusNotFound)
+}
+
+func TestSSOUnknownProvider(t *testing.T) {
+	env := newTestEnv(t)
+	resp := env.doJSON(http.MethodGet, "/auth/oidc/nope/login", "", nil, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status %d, want 404", resp.StatusCode)
+	}
+}
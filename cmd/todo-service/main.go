@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
+	"os"
+	_ "time/tzdata" // Зоны для RRULE и фильтров по сроку не зависят от ОС
 	
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -12,7 +15,9 @@ import (
 
 	"server/internal/config"
 	"server/internal/models"
+	"server/internal/notifier"
 	"server/internal/proto"
+	"server/internal/rbac"
 	"server/internal/repository"
 	"server/internal/service"
 )
@@ -27,11 +32,15 @@ func main() {
 		cfg.TodoDBName,
 		cfg.DBPort,
 	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError превращает нарушение уникальности в gorm.ErrDuplicatedKey
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
-	db.AutoMigrate(&models.Todo{})
+	db.AutoMigrate(&models.Todo{}, &models.List{}, &models.Tag{})
+	if err := repository.MigrateTodoSearch(db); err != nil {
+		log.Fatalf("failed to migrate todo search: %v", err)
+	}
 	log.Println("Database migration for TodoService completed")
 	
 	userServiceAddr := fmt.Sprintf("localhost:%d", cfg.UserServicePort)
@@ -43,14 +52,25 @@ func main() {
 	userClient := proto.NewUserServiceClient(conn)
 
 	todoRepo := repository.NewTodoRepository(db)
-	todoService := service.NewTodoServiceServer(todoRepo, userClient)
+	listRepo := repository.NewListRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	todoService := service.NewTodoServiceServer(todoRepo, listRepo, tagRepo, userClient, cfg.MaxTodoDepth)
+
+	// Фоновая отправка напоминаний
+	reminderWorker := service.NewReminderWorker(todoRepo, notifier.NewLogNotifier(os.Stdout), cfg.ReminderInterval)
+	go reminderWorker.Run(context.Background())
+
+	// Окончательное удаление задач с истекшим сроком хранения в корзине
+	trashPurger := service.NewTrashPurger(todoRepo, cfg.TrashRetention, cfg.TrashPurgeInterval)
+	go trashPurger.Run(context.Background())
 
 	todoPort := fmt.Sprintf(":%d", cfg.TodoServicePort)
 	lis, err := net.Listen("tcp", todoPort)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	grpcServer := grpc.NewServer()
+	// Права на методы проверяются по роли, которую передает шлюз
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(rbac.UnaryServerInterceptor(service.TodoMethodPermissions, rbac.RoleFromIncomingContext)))
 	proto.RegisterTodoServiceServer(grpcServer, todoService)
 
 	log.Printf("TodoService listening on port %s", todoPort)
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
+	"time"
 	"server/internal/config"         
+	"server/internal/keyring"
+	"server/internal/loginguard"
+	"server/internal/mailer"
 	"server/internal/models"
 	"server/internal/proto"
+	"server/internal/rbac"
 	"server/internal/repository"
+	"server/internal/revocation"
 	"server/internal/service"
 
 	"google.golang.org/grpc"
@@ -27,18 +34,78 @@ func main() {
 		cfg.DBName,
 		cfg.DBPort,
 	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
 
 	// Автоматическая миграция
-	db.AutoMigrate(&models.User{})
+	if err := repository.MigrateUsers(db); err != nil {
+		log.Fatalf("failed to migrate users: %v", err)
+	}
+	db.AutoMigrate(&models.RefreshToken{}, &models.RevokedToken{}, &models.UserRevocation{}, &models.SigningKey{}, &models.UserToken{}, &models.TOTPCredential{}, &models.RecoveryCode{}, &models.LoginAttempt{}, &models.APIKey{}, &models.OAuthClient{}, &models.OAuthCode{}, &models.OAuthToken{}, &models.Identity{}, &models.Session{})
 	log.Println("Database migration completed")
 
 	// 3. Инициализация репозитория и сервиса
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserServiceServer(userRepo, cfg.JWTSecret)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+
+	var revocations revocation.Store = revocation.NewPostgresStore(db)
+	if cfg.RevocationStore == "memory" {
+		revocations = revocation.NewMemoryStore()
+	}
+	// Отзывы нужны, только пока отозванные токены не истекли сами
+	go revocation.RunCleanup(context.Background(), revocations, cfg.AccessTokenTTL)
+
+	// Защита входа от подбора. Счетчик сбрасывается, если неудач не было
+	// в несколько раз дольше максимальной блокировки.
+	var loginAttempts loginguard.Store = loginguard.NewPostgresStore(db)
+	if cfg.LoginAttemptStore == "memory" {
+		loginAttempts = loginguard.NewMemoryStore()
+	}
+	loginGuard := loginguard.New(loginAttempts,
+		loginguard.Policy{FreeAttempts: cfg.LoginMaxAttempts, BaseDelay: time.Second, MaxDelay: cfg.LoginLockoutDuration, ResetAfter: 4 * cfg.LoginLockoutDuration},
+		loginguard.Policy{FreeAttempts: cfg.LoginIPMaxAttempts, BaseDelay: time.Second, MaxDelay: cfg.LoginLockoutDuration, ResetAfter: 4 * cfg.LoginLockoutDuration},
+	)
+	go loginGuard.RunCleanup(context.Background(), time.Hour)
+
+	// Ключи подписи: выведенный из оборота ключ хранится, пока не истекут
+	// подписанные им access-токены
+	keys, err := keyring.New(repository.NewSigningKeyRepository(db), cfg.JWTSigningAlgorithm, cfg.AccessTokenTTL)
+	if err != nil {
+		log.Fatalf("failed to create keyring: %v", err)
+	}
+	if err := keys.Load(context.Background()); err != nil {
+		log.Fatalf("failed to load signing keys: %v", err)
+	}
+	go keys.Run(context.Background(), cfg.KeyRotationInterval, time.Minute)
+
+	var mail mailer.Mailer
+	if cfg.Mailer == "smtp" {
+		mail = mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		mail, err = mailer.NewFileMailer(cfg.MailOutboxDir, cfg.MailFrom)
+		if err != nil {
+			log.Fatalf("failed to create mailer: %v", err)
+		}
+		log.Printf("Mail is written to %s", cfg.MailOutboxDir)
+	}
+
+	userService := service.NewUserServiceServer(userRepo, refreshTokenRepo, repository.NewUserTokenRepository(db), repository.NewTOTPRepository(db), repository.NewAPIKeyRepository(db), repository.NewOAuthRepository(db), repository.NewIdentityRepository(db), repository.NewSessionRepository(db), revocations, loginGuard, keys, mail, service.UserServiceConfig{
+		AccessTokenTTL:   cfg.AccessTokenTTL,
+		RefreshTokenTTL:  cfg.RefreshTokenTTL,
+		PasswordResetTTL: cfg.PasswordResetTTL,
+		PasswordResetURL: cfg.PasswordResetURL,
+
+		EmailVerificationTTL: cfg.EmailVerificationTTL,
+		EmailVerificationURL: cfg.EmailVerificationURL,
+		RequireVerifiedEmail: cfg.UnverifiedLogin == config.UnverifiedLoginDeny,
+
+		TOTPIssuer:  cfg.TOTPIssuer,
+		OAuthIssuer: cfg.OAuthIssuer,
+	})
+	go userService.RunOAuthCleanup(context.Background(), time.Hour)
+	go userService.RunSessionCleanup(context.Background(), time.Hour)
 
 	// 4. Запуск gRPC-сервера
 	port := fmt.Sprintf(":%d", cfg.UserServicePort)
@@ -46,7 +113,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	grpcServer := grpc.NewServer()
+	// Права на административные методы проверяются по роли из базы
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(rbac.UnaryServerInterceptor(service.UserMethodPermissions, userService.UserRole)))
 	proto.RegisterUserServiceServer(grpcServer, userService)
 
 	log.Printf("UserService listening on port %s", port)
@@ -0,0 +1,13 @@
+// Package apikey описывает формат персональных токенов доступа. По префиксу
+// шлюз отличает API-ключ от JWT, а сканеры секретов находят утекшие ключи.
+package apikey
+
+import "strings"
+
+// Prefix — начало каждого API-ключа
+const Prefix = "todo_pat_"
+
+// IsAPIKey сообщает, похож ли токен на API-ключ
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
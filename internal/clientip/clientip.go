@@ -0,0 +1,53 @@
+// Package clientip передает IP-адрес и User-Agent клиента от API Gateway
+// сервисам через метаданные gRPC
+package clientip
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey — ключ метаданных с IP-адресом клиента. Значению можно
+// доверять, только если сервис доступен лишь через шлюз.
+const MetadataKey = "x-client-ip"
+
+// UserAgentKey — ключ метаданных с User-Agent клиента
+const UserAgentKey = "x-client-user-agent"
+
+// NewOutgoingContext добавляет IP-адрес к исходящему вызову
+func NewOutgoingContext(ctx context.Context, ip string) context.Context {
+	if ip == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, MetadataKey, ip)
+}
+
+// WithUserAgent добавляет User-Agent клиента к исходящему вызову
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	if userAgent == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, UserAgentKey, userAgent)
+}
+
+// FromIncomingContext возвращает IP-адрес клиента или пустую строку
+func FromIncomingContext(ctx context.Context) string {
+	return incoming(ctx, MetadataKey)
+}
+
+// UserAgentFromIncomingContext возвращает User-Agent клиента или пустую строку
+func UserAgentFromIncomingContext(ctx context.Context) string {
+	return incoming(ctx, UserAgentKey)
+}
+
+func incoming(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
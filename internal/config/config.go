@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,12 +17,105 @@ type Config struct {
 	DBPassword      string
 	DBName          string // Для UserService
 	DBPort          string
-	JWTSecret       string
 	UserServicePort int
 	TodoServicePort int
 	TodoDBName      string
+	// Как часто TodoService проверяет наступившие напоминания
+	ReminderInterval time.Duration
+	// Максимальная глубина вложенности подзадач (1 — без подзадач)
+	MaxTodoDepth int
+	// Сколько удаленные задачи хранятся в корзине и как часто она чистится
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	// Время жизни access-токена (JWT) и refresh-токена
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Где хранятся отозванные токены: "postgres" или "memory"
+	// (только для одного экземпляра UserService и тестов)
+	RevocationStore string
+	// Алгоритм подписи JWT ("EdDSA" или "RS256") и период плановой ротации ключа
+	JWTSigningAlgorithm string
+	KeyRotationInterval time.Duration
+	// Как шлюз проверяет токены: AuthModeRemote, AuthModeLocal или
+	// AuthModeLocalRevocation. В последнем режиме ответы об отзыве
+	// кэшируются на RevocationCacheTTL.
+	AuthMode           string
+	RevocationCacheTTL time.Duration
+	// Доставка писем: "file" — в каталог MailOutboxDir (для разработки)
+	// или "smtp" — через SMTP-сервер
+	Mailer        string
+	MailFrom      string
+	MailOutboxDir string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+	// Срок действия ссылки для сброса пароля и страница, на которую она ведет
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	// То же для подтверждения email
+	EmailVerificationTTL time.Duration
+	EmailVerificationURL string
+	// Что можно пользователю с неподтвержденным email:
+	// UnverifiedLoginLimited или UnverifiedLoginDeny
+	UnverifiedLogin string
+	// Имя сервиса в приложении-аутентификаторе (второй фактор)
+	TOTPIssuer string
+	// Защита входа от подбора: где хранятся счетчики неудач ("postgres" или
+	// "memory"), сколько неудач подряд допускается без задержки для аккаунта
+	// и для IP-адреса и наибольшая задержка (длительность блокировки)
+	LoginAttemptStore    string
+	LoginMaxAttempts     int
+	LoginIPMaxAttempts   int
+	LoginLockoutDuration time.Duration
+	// Адреса или подсети прокси перед шлюзом, которым можно доверить
+	// X-Forwarded-For. Пусто — IP клиента берется из соединения.
+	TrustedProxies []string
+	// Внешний адрес шлюза — идентификатор сервера авторизации OAuth (issuer),
+	// от него строятся адреса в документе discovery. OAuthLoginURL —
+	// страница входа и согласия, куда шлюз отправляет пользователя из
+	// /oauth/authorize с исходными параметрами запроса.
+	OAuthIssuer   string
+	OAuthLoginURL string
+	// Внешние провайдеры OpenID Connect для входа через SSO. Адрес возврата,
+	// который нужно зарегистрировать у провайдера:
+	// OAuthIssuer + "/auth/oidc/<имя>/callback". После входа шлюз отправляет
+	// браузер на OIDCLoginResultURL, токены или ошибка — во фрагменте адреса.
+	OIDCProviders      []OIDCProvider
+	OIDCLoginResultURL string
 }
 
+// OIDCProvider — настройки внешнего провайдера OpenID Connect. Задаются
+// переменными OIDC_<ИМЯ>_ISSUER, OIDC_<ИМЯ>_CLIENT_ID,
+// OIDC_<ИМЯ>_CLIENT_SECRET и OIDC_<ИМЯ>_SCOPES для каждого имени из
+// OIDC_PROVIDERS.
+type OIDCProvider struct {
+	Name         string // в адресах шлюза и в привязанных учетных записях
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// Режимы проверки токенов в API Gateway
+const (
+	// Каждый запрос проверяется вызовом UserService.ValidateToken
+	AuthModeRemote = "remote"
+	// Подпись и срок проверяются в шлюзе по ключам из JWKS; отозванные
+	// токены действуют до истечения срока
+	AuthModeLocal = "local"
+	// Как AuthModeLocal, но отзыв проверяется через UserService с кэшем
+	AuthModeLocalRevocation = "local_revocation"
+)
+
+// Политики входа для пользователей с неподтвержденным email
+const (
+	// Вход разрешен, но только с ограниченными правами (rbac.RoleUnverified)
+	UnverifiedLoginLimited = "limited"
+	// Вход запрещен до подтверждения
+	UnverifiedLoginDeny = "deny"
+)
+
 // LoadConfig reads configuration from environment variables or .env file
 func LoadConfig() *Config {
 	// Try to load .env file, ignore if not found
@@ -47,15 +142,311 @@ func LoadConfig() *Config {
 		log.Fatalf("Invalid TODO_SERVICE_PORT in .env: %v", err)
 	}
 
+	accessTokenTTL := 15 * time.Minute // Default value
+	if v := os.Getenv("ACCESS_TOKEN_TTL"); v != "" {
+		accessTokenTTL, err = time.ParseDuration(v)
+		if err != nil || accessTokenTTL <= 0 {
+			log.Fatalf("Invalid ACCESS_TOKEN_TTL in .env: %q", v)
+		}
+	}
+
+	refreshTokenTTL := 30 * 24 * time.Hour // Default value
+	if v := os.Getenv("REFRESH_TOKEN_TTL"); v != "" {
+		refreshTokenTTL, err = time.ParseDuration(v)
+		if err != nil || refreshTokenTTL <= 0 {
+			log.Fatalf("Invalid REFRESH_TOKEN_TTL in .env: %q", v)
+		}
+	}
+
+	revocationStore := os.Getenv("REVOCATION_STORE")
+	switch revocationStore {
+	case "":
+		revocationStore = "postgres" // Default value
+	case "postgres", "memory":
+	default:
+		log.Fatalf("Invalid REVOCATION_STORE in .env: %q", revocationStore)
+	}
+
+	jwtSigningAlgorithm := os.Getenv("JWT_SIGNING_ALG")
+	switch jwtSigningAlgorithm {
+	case "":
+		jwtSigningAlgorithm = "EdDSA" // Default value
+	case "EdDSA", "RS256":
+	default:
+		log.Fatalf("Invalid JWT_SIGNING_ALG in .env: %q", jwtSigningAlgorithm)
+	}
+
+	keyRotationInterval := 30 * 24 * time.Hour // Default value
+	if v := os.Getenv("KEY_ROTATION_INTERVAL"); v != "" {
+		keyRotationInterval, err = time.ParseDuration(v)
+		if err != nil || keyRotationInterval <= 0 {
+			log.Fatalf("Invalid KEY_ROTATION_INTERVAL in .env: %q", v)
+		}
+	}
+
+	authMode := os.Getenv("AUTH_MODE")
+	switch authMode {
+	case "":
+		authMode = AuthModeLocalRevocation // Default value
+	case AuthModeRemote, AuthModeLocal, AuthModeLocalRevocation:
+	default:
+		log.Fatalf("Invalid AUTH_MODE in .env: %q", authMode)
+	}
+
+	revocationCacheTTL := 30 * time.Second // Default value
+	if v := os.Getenv("REVOCATION_CACHE_TTL"); v != "" {
+		revocationCacheTTL, err = time.ParseDuration(v)
+		if err != nil || revocationCacheTTL <= 0 {
+			log.Fatalf("Invalid REVOCATION_CACHE_TTL in .env: %q", v)
+		}
+	}
+
+	mailer := os.Getenv("MAILER")
+	switch mailer {
+	case "":
+		mailer = "file" // Default value
+	case "file", "smtp":
+	default:
+		log.Fatalf("Invalid MAILER in .env: %q", mailer)
+	}
+
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "no-reply@localhost" // Default value
+	}
+
+	mailOutboxDir := os.Getenv("MAIL_OUTBOX_DIR")
+	if mailOutboxDir == "" {
+		mailOutboxDir = "outbox" // Default value
+	}
+
+	smtpHost := os.Getenv("SMTP_HOST")
+	if mailer == "smtp" && smtpHost == "" {
+		log.Fatalf("SMTP_HOST is required when MAILER is \"smtp\"")
+	}
+
+	smtpPort := 587 // Default value
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		smtpPort, err = strconv.Atoi(v)
+		if err != nil || smtpPort <= 0 {
+			log.Fatalf("Invalid SMTP_PORT in .env: %q", v)
+		}
+	}
+
+	passwordResetTTL := time.Hour // Default value
+	if v := os.Getenv("PASSWORD_RESET_TTL"); v != "" {
+		passwordResetTTL, err = time.ParseDuration(v)
+		if err != nil || passwordResetTTL <= 0 {
+			log.Fatalf("Invalid PASSWORD_RESET_TTL in .env: %q", v)
+		}
+	}
+
+	passwordResetURL := os.Getenv("PASSWORD_RESET_URL")
+	if passwordResetURL == "" {
+		passwordResetURL = "http://localhost:8080/reset-password" // Default value
+	}
+
+	emailVerificationTTL := 48 * time.Hour // Default value
+	if v := os.Getenv("EMAIL_VERIFICATION_TTL"); v != "" {
+		emailVerificationTTL, err = time.ParseDuration(v)
+		if err != nil || emailVerificationTTL <= 0 {
+			log.Fatalf("Invalid EMAIL_VERIFICATION_TTL in .env: %q", v)
+		}
+	}
+
+	emailVerificationURL := os.Getenv("EMAIL_VERIFICATION_URL")
+	if emailVerificationURL == "" {
+		emailVerificationURL = "http://localhost:8080/verify-email" // Default value
+	}
+
+	unverifiedLogin := os.Getenv("UNVERIFIED_LOGIN")
+	switch unverifiedLogin {
+	case "":
+		unverifiedLogin = UnverifiedLoginLimited // Default value
+	case UnverifiedLoginLimited, UnverifiedLoginDeny:
+	default:
+		log.Fatalf("Invalid UNVERIFIED_LOGIN in .env: %q", unverifiedLogin)
+	}
+
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = "Todo" // Default value
+	}
+
+	loginAttemptStore := os.Getenv("LOGIN_ATTEMPT_STORE")
+	switch loginAttemptStore {
+	case "":
+		loginAttemptStore = "postgres" // Default value
+	case "postgres", "memory":
+	default:
+		log.Fatalf("Invalid LOGIN_ATTEMPT_STORE in .env: %q", loginAttemptStore)
+	}
+
+	loginMaxAttempts := 5 // Default value
+	if v := os.Getenv("LOGIN_MAX_ATTEMPTS"); v != "" {
+		loginMaxAttempts, err = strconv.Atoi(v)
+		if err != nil || loginMaxAttempts < 1 {
+			log.Fatalf("Invalid LOGIN_MAX_ATTEMPTS in .env: %q", v)
+		}
+	}
+
+	loginIPMaxAttempts := 20 // Default value
+	if v := os.Getenv("LOGIN_IP_MAX_ATTEMPTS"); v != "" {
+		loginIPMaxAttempts, err = strconv.Atoi(v)
+		if err != nil || loginIPMaxAttempts < 1 {
+			log.Fatalf("Invalid LOGIN_IP_MAX_ATTEMPTS in .env: %q", v)
+		}
+	}
+
+	loginLockoutDuration := 15 * time.Minute // Default value
+	if v := os.Getenv("LOGIN_LOCKOUT_DURATION"); v != "" {
+		loginLockoutDuration, err = time.ParseDuration(v)
+		if err != nil || loginLockoutDuration <= 0 {
+			log.Fatalf("Invalid LOGIN_LOCKOUT_DURATION in .env: %q", v)
+		}
+	}
+
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	oauthIssuer := strings.TrimSuffix(os.Getenv("OAUTH_ISSUER"), "/")
+	if oauthIssuer == "" {
+		oauthIssuer = "http://localhost:8080" // Default value
+	}
+
+	oauthLoginURL := os.Getenv("OAUTH_LOGIN_URL")
+	if oauthLoginURL == "" {
+		oauthLoginURL = "http://localhost:8080/oauth/login" // Default value
+	}
+
+	var oidcProviders []OIDCProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			oidcProviders = append(oidcProviders, loadOIDCProvider(name))
+		}
+	}
+
+	oidcLoginResultURL := os.Getenv("OIDC_LOGIN_RESULT_URL")
+	if oidcLoginResultURL == "" {
+		oidcLoginResultURL = "http://localhost:8080/login/sso" // Default value
+	}
+
+	reminderInterval := time.Minute // Default value
+	if v := os.Getenv("REMINDER_INTERVAL"); v != "" {
+		reminderInterval, err = time.ParseDuration(v)
+		if err != nil || reminderInterval <= 0 {
+			log.Fatalf("Invalid REMINDER_INTERVAL in .env: %q", v)
+		}
+	}
+
+	maxTodoDepth := 3 // Default value
+	if v := os.Getenv("TODO_MAX_DEPTH"); v != "" {
+		maxTodoDepth, err = strconv.Atoi(v)
+		if err != nil || maxTodoDepth < 1 {
+			log.Fatalf("Invalid TODO_MAX_DEPTH in .env: %q", v)
+		}
+	}
+
+	trashRetention := 30 * 24 * time.Hour // Default value
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		trashRetention, err = time.ParseDuration(v)
+		if err != nil || trashRetention <= 0 {
+			log.Fatalf("Invalid TRASH_RETENTION in .env: %q", v)
+		}
+	}
+
+	trashPurgeInterval := time.Hour // Default value
+	if v := os.Getenv("TRASH_PURGE_INTERVAL"); v != "" {
+		trashPurgeInterval, err = time.ParseDuration(v)
+		if err != nil || trashPurgeInterval <= 0 {
+			log.Fatalf("Invalid TRASH_PURGE_INTERVAL in .env: %q", v)
+		}
+	}
+
 	return &Config{
-		DBHost:          os.Getenv("DB_HOST"),
-		DBUser:          os.Getenv("DB_USER"),
-		DBPassword:      os.Getenv("DB_PASSWORD"),
-		DBName:          os.Getenv("DB_NAME"),
-		DBPort:          os.Getenv("DB_PORT"),
-		JWTSecret:       os.Getenv("JWT_SECRET"),
-		UserServicePort: userServicePort,
-		TodoServicePort: todoServicePort,
-		TodoDBName:      os.Getenv("TODO_DB_NAME"),
-	}
-}                      
+		DBHost:           os.Getenv("DB_HOST"),
+		DBUser:           os.Getenv("DB_USER"),
+		DBPassword:       os.Getenv("DB_PASSWORD"),
+		DBName:           os.Getenv("DB_NAME"),
+		DBPort:           os.Getenv("DB_PORT"),
+		UserServicePort:  userServicePort,
+		TodoServicePort:  todoServicePort,
+		TodoDBName:       os.Getenv("TODO_DB_NAME"),
+		ReminderInterval: reminderInterval,
+		MaxTodoDepth:     maxTodoDepth,
+
+		TrashRetention:     trashRetention,
+		TrashPurgeInterval: trashPurgeInterval,
+
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+		RevocationStore: revocationStore,
+
+		JWTSigningAlgorithm: jwtSigningAlgorithm,
+		KeyRotationInterval: keyRotationInterval,
+
+		AuthMode:           authMode,
+		RevocationCacheTTL: revocationCacheTTL,
+
+		Mailer:        mailer,
+		MailFrom:      mailFrom,
+		MailOutboxDir: mailOutboxDir,
+		SMTPHost:      smtpHost,
+		SMTPPort:      smtpPort,
+		SMTPUsername:  os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
+
+		PasswordResetTTL: passwordResetTTL,
+		PasswordResetURL: passwordResetURL,
+
+		EmailVerificationTTL: emailVerificationTTL,
+		EmailVerificationURL: emailVerificationURL,
+		UnverifiedLogin:      unverifiedLogin,
+
+		TOTPIssuer: totpIssuer,
+
+		LoginAttemptStore:    loginAttemptStore,
+		LoginMaxAttempts:     loginMaxAttempts,
+		LoginIPMaxAttempts:   loginIPMaxAttempts,
+		LoginLockoutDuration: loginLockoutDuration,
+		TrustedProxies:       trustedProxies,
+
+		OAuthIssuer:   oauthIssuer,
+		OAuthLoginURL: oauthLoginURL,
+
+		OIDCProviders:      oidcProviders,
+		OIDCLoginResultURL: oidcLoginResultURL,
+	}
+}
+
+// loadOIDCProvider читает настройки провайдера name
+func loadOIDCProvider(name string) OIDCProvider {
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			log.Fatalf("Invalid OIDC_PROVIDERS in .env: %q (names may contain a-z, 0-9 and -)", name)
+		}
+	}
+	prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+	provider := OIDCProvider{
+		Name:         name,
+		Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+		ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+		ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+	}
+	if !strings.HasPrefix(provider.Issuer, "https://") && !strings.HasPrefix(provider.Issuer, "http://") {
+		log.Fatalf("Invalid %sISSUER in .env: %q", prefix, provider.Issuer)
+	}
+	if provider.ClientID == "" {
+		log.Fatalf("Invalid %sCLIENT_ID in .env: %q", prefix, provider.ClientID)
+	}
+	if len(provider.Scopes) == 0 {
+		provider.Scopes = []string{"openid", "email"} // Default value
+	}
+	return provider
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+)
+
+// AdminHandler — управление пользователями. Маршруты закрыты
+// middleware.RequirePermission(rbac.UsersManage), UserService проверяет
+// право еще раз по роли из базы.
+type AdminHandler struct {
+	userClient proto.UserServiceClient
+}
+
+func NewAdminHandler(userClient proto.UserServiceClient) *AdminHandler {
+	return &AdminHandler{userClient: userClient}
+}
+
+// userJSON — представление пользователя в REST API
+type userJSON struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	Disabled   bool       `json:"disabled"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+
+	EmailVerified bool `json:"email_verified"`
+}
+
+func newUserJSON(user *proto.User) userJSON {
+	return userJSON{
+		ID:         user.Id,
+		Email:      user.Email,
+		Role:       user.Role,
+		CreatedAt:  timestampToTime(user.CreatedAt),
+		Disabled:   user.DisabledAt != nil,
+		DisabledAt: timestampToTime(user.DisabledAt),
+
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.ListUsersRequest{
+		UserId:        userID.(string),
+		PageToken:     c.Query("page_token"),
+		EmailContains: c.Query("email"),
+	}
+	if v := c.Query("page_size"); v != "" {
+		pageSize, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || pageSize < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be a non-negative integer"})
+			return
+		}
+		req.PageSize = int32(pageSize)
+	}
+
+	resp, err := h.userClient.ListUsers(context.Background(), req)
+	if err != nil {
+		respondAdminError(c, err, "Failed to list users")
+		return
+	}
+
+	if resp.NextPageToken != "" {
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("page_token", resp.NextPageToken)
+		next.RawQuery = query.Encode()
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+	users := make([]userJSON, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		users = append(users, newUserJSON(user))
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.GetUserRequest{UserId: userID.(string), TargetUserId: c.Param("id")}
+	resp, err := h.userClient.GetUser(context.Background(), req)
+	if err != nil {
+		respondAdminError(c, err, "Failed to get user")
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserJSON(resp))
+}
+
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	h.userAction(c, h.userClient.DisableUser, "Failed to disable user")
+}
+
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	h.userAction(c, h.userClient.EnableUser, "Failed to enable user")
+}
+
+// UnlockUser снимает блокировку входа после неудачных попыток
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	h.userAction(c, h.userClient.UnlockUser, "Failed to unlock user")
+}
+
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.UserActionRequest{UserId: userID.(string), TargetUserId: c.Param("id")}
+	resp, err := h.userClient.DeleteUser(context.Background(), req)
+	if err != nil {
+		respondAdminError(c, err, "Failed to delete user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// ResetUserPassword возвращает временный пароль; все сессии пользователя завершаются
+func (h *AdminHandler) ResetUserPassword(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.UserActionRequest{UserId: userID.(string), TargetUserId: c.Param("id")}
+	resp, err := h.userClient.ResetUserPassword(context.Background(), req)
+	if err != nil {
+		respondAdminError(c, err, "Failed to reset password")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"temporary_password": resp.TemporaryPassword})
+}
+
+// ChangeUserRole меняет роль другого пользователя
+func (h *AdminHandler) ChangeUserRole(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &proto.ChangeUserRoleRequest{
+		UserId:       userID.(string),
+		TargetUserId: c.Param("id"),
+		Role:         payload.Role,
+	}
+	resp, err := h.userClient.ChangeUserRole(context.Background(), req)
+	if err != nil {
+		respondAdminError(c, err, "Failed to change user role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": resp.UserId, "role": resp.Role})
+}
+
+// userAction выполняет действие над пользователем :id и возвращает его
+// обновленное состояние
+func (h *AdminHandler) userAction(c *gin.Context, call func(context.Context, *proto.UserActionRequest, ...grpc.CallOption) (*proto.User, error), failure string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := call(context.Background(), &proto.UserActionRequest{UserId: userID.(string), TargetUserId: c.Param("id")})
+	if err != nil {
+		respondAdminError(c, err, failure)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserJSON(resp))
+}
+
+// respondAdminError переводит ошибки административных методов в ответы HTTP
+func respondAdminError(c *gin.Context, err error, failure string) {
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+			return
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+			return
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+			return
+		case codes.FailedPrecondition:
+			c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+			return
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+)
+
+// apiKeyJSON — API-ключ в ответах; сам токен сюда не входит
+type apiKeyJSON struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func newAPIKeyJSON(key *proto.APIKey) apiKeyJSON {
+	return apiKeyJSON{
+		ID:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  timestampToTime(key.CreatedAt),
+		ExpiresAt:  timestampToTime(key.ExpiresAt),
+		LastUsedAt: timestampToTime(key.LastUsedAt),
+	}
+}
+
+// CreateAPIKey выдает персональный токен доступа. Токен показывается один раз.
+func (h *UserHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		Name      string   `json:"name" binding:"required"`
+		Scopes    []string `json:"scopes" binding:"required"`
+		ExpiresIn int64    `json:"expires_in"` // в секундах; 0 — бессрочный
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &proto.CreateAPIKeyRequest{
+		UserId:    userID.(string),
+		Name:      payload.Name,
+		Scopes:    payload.Scopes,
+		ExpiresIn: payload.ExpiresIn,
+	}
+	resp, err := h.userClient.CreateAPIKey(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument:
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			case codes.PermissionDenied:
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			case codes.FailedPrecondition:
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, gin.H{"api_key": newAPIKeyJSON(resp.ApiKey), "token": resp.Token})
+}
+
+func (h *UserHandler) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.userClient.ListAPIKeys(context.Background(), &proto.ListAPIKeysRequest{UserId: userID.(string)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
+
+	keys := make([]apiKeyJSON, 0, len(resp.ApiKeys))
+	for _, key := range resp.ApiKeys {
+		keys = append(keys, newAPIKeyJSON(key))
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+func (h *UserHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.RevokeAPIKeyRequest{UserId: userID.(string), ApiKeyId: c.Param("id")}
+	resp, err := h.userClient.RevokeAPIKey(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument:
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			case codes.NotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
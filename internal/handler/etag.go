@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// todoETag строит сильный ETag задачи из ее версии
+func todoETag(version uint64) string {
+	return fmt.Sprintf("%q", strconv.FormatUint(version, 10))
+}
+
+// ifMatchVersion извлекает ожидаемую версию из заголовка If-Match.
+// Возвращает 0, если заголовка нет или он равен "*", и present=true,
+// если клиент прислал условие. Слабые и составные ETag не поддерживаются:
+// для них ok=false, и запрос должен завершиться 412.
+func ifMatchVersion(c *gin.Context) (version uint64, present bool, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, false, true
+	}
+	if header == "*" {
+		return 0, true, true
+	}
+	if strings.HasPrefix(header, "W/") || strings.Contains(header, ",") {
+		return 0, true, false
+	}
+	version, err := strconv.ParseUint(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version == 0 {
+		return 0, true, false
+	}
+	return version, true, true
+}
+
+// ifNoneMatch сообщает, совпадает ли etag с одним из значений If-None-Match.
+// Для GET сравнение слабое, поэтому префикс W/ игнорируется.
+func ifNoneMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
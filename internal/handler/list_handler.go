@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+)
+
+type ListHandler struct {
+	todoClient proto.TodoServiceClient
+}
+
+func NewListHandler(todoClient proto.TodoServiceClient) *ListHandler {
+	return &ListHandler{todoClient: todoClient}
+}
+
+func (h *ListHandler) CreateList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req proto.CreateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserId = userID.(string)
+
+	resp, err := h.todoClient.CreateList(roleContext(c), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create list"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *ListHandler) GetLists(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.todoClient.GetLists(roleContext(c), &proto.GetListsRequest{UserId: userID.(string)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Lists)
+}
+
+func (h *ListHandler) UpdateList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req proto.UpdateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Id = c.Param("id")
+	req.UserId = userID.(string)
+
+	resp, err := h.todoClient.UpdateList(roleContext(c), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument || st.Code() == codes.FailedPrecondition {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteList удаляет список. С ?cascade=true задачи удаляются вместе с ним,
+// иначе переносятся в Inbox.
+func (h *ListHandler) DeleteList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.DeleteListRequest{Id: c.Param("id"), UserId: userID.(string)}
+	if v := c.Query("cascade"); v != "" {
+		cascade, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cascade must be true or false"})
+			return
+		}
+		req.Cascade = cascade
+	}
+
+	_, err := h.todoClient.DeleteList(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument || st.Code() == codes.FailedPrecondition {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete list"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/oidc"
+	"server/internal/proto"
+)
+
+// OAuthHandler — сервер авторизации OAuth 2.0 / OpenID Connect для
+// внутренних приложений. Проверки и выдача токенов — в UserService,
+// здесь только протокол HTTP.
+type OAuthHandler struct {
+	userClient       proto.UserServiceClient
+	issuer           string
+	loginURL         string
+	signingAlgorithm string
+}
+
+func NewOAuthHandler(userClient proto.UserServiceClient, issuer, loginURL, signingAlgorithm string) *OAuthHandler {
+	return &OAuthHandler{
+		userClient:       userClient,
+		issuer:           issuer,
+		loginURL:         loginURL,
+		signingAlgorithm: signingAlgorithm,
+	}
+}
+
+type oauthClientJSON struct {
+	ClientID     string     `json:"client_id"`
+	Name         string     `json:"name"`
+	RedirectURIs []string   `json:"redirect_uris"`
+	Confidential bool       `json:"confidential"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
+
+func newOAuthClientJSON(client *proto.OAuthClient) oauthClientJSON {
+	return oauthClientJSON{
+		ClientID:     client.ClientId,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Confidential: client.Confidential,
+		CreatedAt:    timestampToTime(client.CreatedAt),
+	}
+}
+
+// Discovery отдает документ OpenID Connect Discovery
+func (h *OAuthHandler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, oidc.Discovery{
+		Issuer:                            h.issuer,
+		AuthorizationEndpoint:             h.issuer + "/oauth/authorize",
+		TokenEndpoint:                     h.issuer + "/oauth/token",
+		UserinfoEndpoint:                  h.issuer + "/oauth/userinfo",
+		JWKSURI:                           h.issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   oidc.Scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{h.signingAlgorithm},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{oidc.CodeChallengeMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "email", "email_verified"},
+	})
+}
+
+// Authorize — точка авторизации. Проверяет запрос и отправляет браузер на
+// страницу входа с теми же параметрами; та после входа и согласия
+// пользователя вызывает Approve.
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	query := c.Request.URL.Query()
+	req := &proto.AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientId:            query.Get("client_id"),
+		RedirectUri:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+		Nonce:               query.Get("nonce"),
+	}
+	resp, err := h.userClient.ValidateAuthorizeRequest(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.InvalidArgument {
+			c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process authorization request"})
+		return
+	}
+
+	if resp.ErrorRedirectTo != "" {
+		c.Redirect(http.StatusFound, resp.ErrorRedirectTo)
+		return
+	}
+	sep := "?"
+	if strings.Contains(h.loginURL, "?") {
+		sep = "&"
+	}
+	c.Redirect(http.StatusFound, h.loginURL+sep+c.Request.URL.RawQuery)
+}
+
+// Approve выдает код авторизации от имени вошедшего пользователя и
+// возвращает адрес, куда страница входа должна отправить браузер
+func (h *OAuthHandler) Approve(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		ResponseType        string `json:"response_type" binding:"required"`
+		ClientID            string `json:"client_id" binding:"required"`
+		RedirectURI         string `json:"redirect_uri"`
+		Scope               string `json:"scope"`
+		State               string `json:"state"`
+		CodeChallenge       string `json:"code_challenge"`
+		CodeChallengeMethod string `json:"code_challenge_method"`
+		Nonce               string `json:"nonce"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &proto.AuthorizeRequest{
+		UserId:              userID.(string),
+		ResponseType:        payload.ResponseType,
+		ClientId:            payload.ClientID,
+		RedirectUri:         payload.RedirectURI,
+		Scope:               payload.Scope,
+		State:               payload.State,
+		CodeChallenge:       payload.CodeChallenge,
+		CodeChallengeMethod: payload.CodeChallengeMethod,
+		Nonce:               payload.Nonce,
+	}
+	resp, err := h.userClient.Authorize(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument:
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			case codes.PermissionDenied:
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authorize client"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"redirect_to": resp.RedirectTo})
+}
+
+// Token — точка выдачи токенов (RFC 6749, 4.1.3). Клиент передает
+// учетные данные через HTTP Basic или в теле запроса.
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	req := &proto.OAuthTokenRequest{
+		GrantType:    c.PostForm("grant_type"),
+		Code:         c.PostForm("code"),
+		RedirectUri:  c.PostForm("redirect_uri"),
+		ClientId:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+		CodeVerifier: c.PostForm("code_verifier"),
+	}
+	basicID, basicSecret, basic := c.Request.BasicAuth()
+	if basic {
+		if req.ClientSecret != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "use only one client authentication method"})
+			return
+		}
+		// Учетные данные в Basic дополнительно закодированы (RFC 6749, 2.3.1)
+		id, idErr := url.QueryUnescape(basicID)
+		secret, secretErr := url.QueryUnescape(basicSecret)
+		if idErr != nil || secretErr != nil {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": "malformed client credentials"})
+			return
+		}
+		req.ClientId, req.ClientSecret = id, secret
+	}
+
+	resp, err := h.userClient.OAuthToken(context.Background(), req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		switch st.Code() {
+		case codes.Unauthenticated:
+			if basic {
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": oauthErrorReason(st, "invalid_client"), "error_description": st.Message()})
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": oauthErrorReason(st, "invalid_request"), "error_description": st.Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+
+	body := gin.H{
+		"access_token": resp.AccessToken,
+		"token_type":   resp.TokenType,
+		"expires_in":   resp.ExpiresIn,
+		"scope":        resp.Scope,
+	}
+	if resp.IdToken != "" {
+		body["id_token"] = resp.IdToken
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// UserInfo — сведения о пользователе по access-токену OAuth (не JWT приложения)
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" || token == c.GetHeader("Authorization") {
+		c.Header("WWW-Authenticate", `Bearer realm="oauth"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_request", "error_description": "bearer token is required"})
+		return
+	}
+
+	resp, err := h.userClient.OAuthUserInfo(context.Background(), &proto.OAuthUserInfoRequest{AccessToken: token})
+	if err != nil {
+		st, _ := status.FromError(err)
+		switch st.Code() {
+		case codes.Unauthenticated:
+			c.Header("WWW-Authenticate", `Bearer realm="oauth", error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": st.Message()})
+		case codes.PermissionDenied:
+			c.Header("WWW-Authenticate", `Bearer realm="oauth", error="insufficient_scope"`)
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient_scope", "error_description": st.Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	body := gin.H{"sub": resp.Sub}
+	if resp.Email != "" {
+		body["email"] = resp.Email
+		body["email_verified"] = resp.EmailVerified
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// CreateClient регистрирует приложение. Секрет конфиденциального клиента
+// показывается один раз.
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		Name         string   `json:"name" binding:"required"`
+		RedirectURIs []string `json:"redirect_uris" binding:"required"`
+		Confidential bool     `json:"confidential"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &proto.CreateOAuthClientRequest{
+		UserId:       userID.(string),
+		Name:         payload.Name,
+		RedirectUris: payload.RedirectURIs,
+		Confidential: payload.Confidential,
+	}
+	resp, err := h.userClient.CreateOAuthClient(context.Background(), req)
+	if err != nil {
+		respondAdminError(c, err, "Failed to create OAuth client")
+		return
+	}
+
+	body := gin.H{"client": newOAuthClientJSON(resp.Client)}
+	if resp.ClientSecret != "" {
+		body["client_secret"] = resp.ClientSecret
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, body)
+}
+
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.userClient.ListOAuthClients(context.Background(), &proto.ListOAuthClientsRequest{UserId: userID.(string)})
+	if err != nil {
+		respondAdminError(c, err, "Failed to list OAuth clients")
+		return
+	}
+
+	clients := make([]oauthClientJSON, 0, len(resp.Clients))
+	for _, client := range resp.Clients {
+		clients = append(clients, newOAuthClientJSON(client))
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.DeleteOAuthClientRequest{UserId: userID.(string), ClientId: c.Param("client_id")}
+	resp, err := h.userClient.DeleteOAuthClient(context.Background(), req)
+	if err != nil {
+		respondAdminError(c, err, "Failed to delete OAuth client")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// oauthErrorReason достает код ошибки OAuth из google.rpc.ErrorInfo
+func oauthErrorReason(st *status.Status, fallback string) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Reason != "" {
+			return info.Reason
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+)
+
+// sessionJSON — сессия в ответах. Current отмечает сессию, токеном
+// которой сделан запрос.
+type sessionJSON struct {
+	ID         string     `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+// ListSessions возвращает устройства, на которых выполнен вход
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.userClient.ListSessions(context.Background(), &proto.ListSessionsRequest{UserId: userID.(string)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+
+	current := c.GetString("session_id")
+	sessions := make([]sessionJSON, 0, len(resp.Sessions))
+	for _, session := range resp.Sessions {
+		sessions = append(sessions, sessionJSON{
+			ID:         session.Id,
+			UserAgent:  session.UserAgent,
+			IP:         session.Ip,
+			CreatedAt:  timestampToTime(session.CreatedAt),
+			LastSeenAt: timestampToTime(session.LastSeenAt),
+			Current:    current != "" && session.Id == current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession завершает сессию на другом (или этом же) устройстве
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.RevokeSessionRequest{UserId: userID.(string), SessionId: c.Param("id")}
+	resp, err := h.userClient.RevokeSession(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument:
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			case codes.NotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
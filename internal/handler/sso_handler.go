@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/oidc"
+	"server/internal/proto"
+)
+
+const (
+	// Cookie с параметрами входа у провайдера; доступна только адресу возврата
+	ssoStateCookie     = "sso_state"
+	ssoStateCookiePath = "/auth/oidc/"
+	// Столько у пользователя есть на вход у провайдера
+	ssoStateTTL = 10 * time.Minute
+)
+
+// SSOHandler — вход через внешних провайдеров OpenID Connect. Шлюз —
+// клиент провайдера: проверяет ID-токен и передает claims в UserService,
+// а тот выдает обычные токены сессии. Результат входа передается странице
+// resultURL во фрагменте адреса, чтобы токены не попадали в логи серверов.
+type SSOHandler struct {
+	userClient   proto.UserServiceClient
+	providers    map[string]*oidc.Provider
+	resultURL    string
+	secureCookie bool
+}
+
+func NewSSOHandler(userClient proto.UserServiceClient, providers []*oidc.Provider, resultURL string, secureCookie bool) *SSOHandler {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return &SSOHandler{
+		userClient:   userClient,
+		providers:    byName,
+		resultURL:    resultURL,
+		secureCookie: secureCookie,
+	}
+}
+
+// ssoState — параметры одного входа у провайдера. Хранятся в cookie браузера:
+// state в ответе провайдера должен совпасть с cookie, поэтому чужой код
+// авторизации подсунуть нельзя.
+type ssoState struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	// Задан при привязке учетной записи к уже вошедшему пользователю
+	LinkToken string `json:"link_token,omitempty"`
+}
+
+type identityJSON struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// Login отправляет браузер на вход к провайдеру
+func (h *SSOHandler) Login(c *gin.Context) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+	redirectTo, err := h.begin(c, provider, "")
+	if err != nil {
+		log.Printf("sso: %s: %v", provider.Name(), err)
+		h.finish(c, url.Values{"error": {"temporarily_unavailable"}, "error_description": {"identity provider is unavailable"}})
+		return
+	}
+	c.Redirect(http.StatusFound, redirectTo)
+}
+
+// Link начинает привязку учетной записи провайдера к вошедшему пользователю
+// и возвращает адрес, куда страница должна отправить браузер
+func (h *SSOHandler) Link(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	resp, err := h.userClient.CreateIdentityLinkToken(context.Background(), &proto.CreateIdentityLinkTokenRequest{UserId: userID.(string)})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start linking"})
+		return
+	}
+	redirectTo, err := h.begin(c, provider, resp.LinkToken)
+	if err != nil {
+		log.Printf("sso: %s: %v", provider.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
+
+// begin запоминает параметры входа в cookie и возвращает адрес провайдера
+func (h *SSOHandler) begin(c *gin.Context, provider *oidc.Provider, linkToken string) (string, error) {
+	state := ssoState{Provider: provider.Name(), LinkToken: linkToken}
+	for _, field := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		value, err := randomURLSafe()
+		if err != nil {
+			return "", err
+		}
+		*field = value
+	}
+
+	redirectTo, err := provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		return "", err
+	}
+	value, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	h.setStateCookie(c, base64.RawURLEncoding.EncodeToString(value), int(ssoStateTTL.Seconds()))
+	return redirectTo, nil
+}
+
+// Callback — адрес возврата от провайдера
+func (h *SSOHandler) Callback(c *gin.Context) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	// Параметры входа одноразовые
+	state, err := h.readStateCookie(c)
+	h.setStateCookie(c, "", -1)
+	if err != nil || state.Provider != provider.Name() || state.State != c.Query("state") {
+		h.finish(c, url.Values{"error": {"invalid_request"}, "error_description": {"login session expired or state mismatch, try again"}})
+		return
+	}
+	if code := c.Query("error"); code != "" {
+		h.finish(c, url.Values{"error": {code}, "error_description": {c.Query("error_description")}})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.Verifier, state.Nonce)
+	if err != nil {
+		log.Printf("sso: %s: %v", provider.Name(), err)
+		var providerErr *oidc.ProviderError
+		if errors.As(err, &providerErr) {
+			h.finish(c, url.Values{"error": {providerErr.Code}, "error_description": {providerErr.Description}})
+			return
+		}
+		h.finish(c, url.Values{"error": {"server_error"}, "error_description": {"failed to verify the identity provider response"}})
+		return
+	}
+	identity := &proto.ExternalIdentity{
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}
+
+	if state.LinkToken != "" {
+		_, err := h.userClient.LinkIdentity(context.Background(), &proto.LinkIdentityRequest{LinkToken: state.LinkToken, Identity: identity})
+		if err != nil {
+			h.finish(c, ssoErrorValues(err))
+			return
+		}
+		h.finish(c, url.Values{"linked": {provider.Name()}})
+		return
+	}
+
+	resp, err := h.userClient.ExternalLogin(clientContext(c), &proto.ExternalLoginRequest{Identity: identity})
+	if err != nil {
+		h.finish(c, ssoErrorValues(err))
+		return
+	}
+	if resp.SecondFactorRequired {
+		h.finish(c, url.Values{"second_factor_required": {"true"}, "challenge_token": {resp.ChallengeToken}})
+		return
+	}
+	h.finish(c, url.Values{
+		"token":         {resp.Token},
+		"refresh_token": {resp.RefreshToken},
+		"expires_in":    {strconv.FormatInt(resp.ExpiresIn, 10)},
+	})
+}
+
+func (h *SSOHandler) ListIdentities(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.userClient.ListIdentities(context.Background(), &proto.ListIdentitiesRequest{UserId: userID.(string)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list identities"})
+		return
+	}
+
+	identities := make([]identityJSON, 0, len(resp.Identities))
+	for _, identity := range resp.Identities {
+		identities = append(identities, identityJSON{
+			Provider:    identity.Provider,
+			Email:       identity.Email,
+			CreatedAt:   timestampToTime(identity.CreatedAt),
+			LastLoginAt: timestampToTime(identity.LastLoginAt),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+func (h *SSOHandler) UnlinkIdentity(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.userClient.UnlinkIdentity(context.Background(), &proto.UnlinkIdentityRequest{
+		UserId:   userID.(string),
+		Provider: c.Param("provider"),
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.NotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			case codes.FailedPrecondition:
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink identity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// finish возвращает браузер на страницу результата входа
+func (h *SSOHandler) finish(c *gin.Context, result url.Values) {
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, h.resultURL+"#"+result.Encode())
+}
+
+func (h *SSOHandler) setStateCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    value,
+		Path:     ssoStateCookiePath,
+		MaxAge:   maxAge,
+		Secure:   h.secureCookie,
+		HttpOnly: true,
+		// Lax: cookie должна прийти с переходом от провайдера на адрес возврата
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *SSOHandler) readStateCookie(c *gin.Context) (*ssoState, error) {
+	value, err := c.Cookie(ssoStateCookie)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var state ssoState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.State == "" {
+		return nil, errors.New("empty state")
+	}
+	return &state, nil
+}
+
+// ssoErrorValues переводит ошибку UserService в параметры страницы результата
+func ssoErrorValues(err error) url.Values {
+	st, ok := status.FromError(err)
+	if !ok {
+		return url.Values{"error": {"server_error"}}
+	}
+	code := "server_error"
+	switch st.Code() {
+	case codes.PermissionDenied:
+		code = "access_denied"
+	case codes.FailedPrecondition, codes.InvalidArgument, codes.Aborted:
+		code = "login_failed"
+	case codes.AlreadyExists:
+		code = "already_linked"
+	case codes.Unauthenticated:
+		code = "invalid_request"
+	default:
+		return url.Values{"error": {code}}
+	}
+	return url.Values{"error": {code}, "error_description": {st.Message()}}
+}
+
+func randomURLSafe() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
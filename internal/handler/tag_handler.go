@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+)
+
+type TagHandler struct {
+	todoClient proto.TodoServiceClient
+}
+
+func NewTagHandler(todoClient proto.TodoServiceClient) *TagHandler {
+	return &TagHandler{todoClient: todoClient}
+}
+
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req proto.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserId = userID.(string)
+
+	resp, err := h.todoClient.CreateTag(roleContext(c), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.AlreadyExists {
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.todoClient.GetTags(roleContext(c), &proto.GetTagsRequest{UserId: userID.(string)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp.Tags)
+}
+
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req proto.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Id = c.Param("id")
+	req.UserId = userID.(string)
+
+	resp, err := h.todoClient.UpdateTag(roleContext(c), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.AlreadyExists {
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.DeleteTagRequest{Id: c.Param("id"), UserId: userID.(string)}
+
+	_, err := h.todoClient.DeleteTag(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -2,14 +2,46 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"server/internal/proto"
+	"server/internal/rbac"
 )
 
+var todoSortParams = map[string]proto.TodoSortField{
+	"created":  proto.TodoSortField_TODO_SORT_FIELD_CREATED,
+	"updated":  proto.TodoSortField_TODO_SORT_FIELD_UPDATED,
+	"title":    proto.TodoSortField_TODO_SORT_FIELD_TITLE,
+	"priority": proto.TodoSortField_TODO_SORT_FIELD_PRIORITY,
+	"position": proto.TodoSortField_TODO_SORT_FIELD_POSITION,
+}
+
+var todoPriorities = map[string]proto.Priority{
+	"":       proto.Priority_PRIORITY_NONE,
+	"none":   proto.Priority_PRIORITY_NONE,
+	"low":    proto.Priority_PRIORITY_LOW,
+	"medium": proto.Priority_PRIORITY_MEDIUM,
+	"high":   proto.Priority_PRIORITY_HIGH,
+	"urgent": proto.Priority_PRIORITY_URGENT,
+}
+
+// todoUpdateFields — JSON-ключи задачи, которые можно изменить через PUT/PATCH
+var todoUpdateFields = []string{
+	"title", "completed", "due_at", "remind_at", "tags", "parent_id", "recurrence", "time_zone", "priority",
+}
+
+// todoRequiredFields — ключи, без которых PUT отклоняется
+var todoRequiredFields = []string{"title", "completed"}
+
 type TodoHandler struct {
 	todoClient proto.TodoServiceClient
 }
@@ -18,6 +50,12 @@ func NewTodoHandler(todoClient proto.TodoServiceClient) *TodoHandler {
 	return &TodoHandler{todoClient: todoClient}
 }
 
+// roleContext передает TodoService роль пользователя из токена: по ней
+// сервис проверяет права на метод
+func roleContext(c *gin.Context) context.Context {
+	return rbac.WithRole(context.Background(), c.GetString("user_role"))
+}
+
 func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -25,22 +63,69 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 		return
 	}
 
-	var req proto.CreateTodoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var payload struct {
+		Title      string     `json:"title"`
+		DueAt      *time.Time `json:"due_at"`
+		RemindAt   *time.Time `json:"remind_at"`
+		ListID     string     `json:"list_id"`
+		Tags       []string   `json:"tags"`
+		ParentID   string     `json:"parent_id"`
+		Recurrence string     `json:"recurrence"`
+		TimeZone   string     `json:"time_zone"`
+		Priority   string     `json:"priority"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	req.UserId = userID.(string)
+	priority, ok := todoPriorities[payload.Priority]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "priority must be one of none, low, medium, high, urgent"})
+		return
+	}
+	req := &proto.CreateTodoRequest{
+		UserId:     userID.(string),
+		Title:      payload.Title,
+		DueAt:      timeToTimestamp(payload.DueAt),
+		RemindAt:   timeToTimestamp(payload.RemindAt),
+		ListId:     payload.ListID,
+		Tags:       payload.Tags,
+		ParentId:   payload.ParentID,
+		Recurrence: payload.Recurrence,
+		TimeZone:   payload.TimeZone,
+		Priority:   priority,
+	}
+	// POST /api/lists/:id/todos создает задачу в указанном списке
+	if listID := c.Param("id"); listID != "" {
+		req.ListId = listID
+	}
 
-	resp, err := h.todoClient.CreateTodo(context.Background(), &req)
+	resp, err := h.todoClient.CreateTodo(roleContext(c), req)
 	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, resp)
+	c.JSON(http.StatusCreated, newTodoJSON(resp))
 }
 
+// GetTodos отдает одну страницу задач. Параметры запроса:
+// page_size, page_token, completed (true/false), title, sort (created|updated|title|priority|position),
+// order (asc|desc), due (overdue|today), due_within_days (N), tz (IANA-зона для "today")
+// list_id, tags_any и tags_all (метки через запятую или повтором параметра),
+// view=tree (задачи верхнего уровня с вложенными подзадачами в children).
+// Для GET /api/lists/:id/todos список берется из пути. Ссылка на следующую страницу передается в заголовке Link,
+// общее количество — в X-Total-Count.
 func (h *TodoHandler) GetTodos(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -48,36 +133,427 @@ func (h *TodoHandler) GetTodos(c *gin.Context) {
 		return
 	}
 
-	req := &proto.GetTodosRequest{UserId: userID.(string)}
+	req := &proto.GetTodosRequest{
+		UserId:        userID.(string),
+		PageToken:     c.Query("page_token"),
+		TitleContains: c.Query("title"),
+		ListId:        c.Query("list_id"),
+		AnyTags:       queryList(c, "tags_any"),
+		AllTags:       queryList(c, "tags_all"),
+	}
+	switch c.Query("view") {
+	case "", "flat":
+	case "tree":
+		req.AsTree = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be flat or tree"})
+		return
+	}
+	if listID := c.Param("id"); listID != "" {
+		req.ListId = listID
+	}
+	if v := c.Query("page_size"); v != "" {
+		pageSize, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || pageSize < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be a non-negative integer"})
+			return
+		}
+		req.PageSize = int32(pageSize)
+	}
+	if v := c.Query("completed"); v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "completed must be true or false"})
+			return
+		}
+		req.Completed = &completed
+	}
+	if v := c.Query("sort"); v != "" {
+		sortBy, ok := todoSortParams[v]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of created, updated, title, priority, position"})
+			return
+		}
+		req.SortBy = sortBy
+	}
+	switch c.Query("due") {
+	case "":
+	case "overdue":
+		req.DueFilter = proto.DueFilter_DUE_FILTER_OVERDUE
+	case "today":
+		req.DueFilter = proto.DueFilter_DUE_FILTER_DUE_TODAY
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "due must be overdue or today"})
+		return
+	}
+	if v := c.Query("due_within_days"); v != "" {
+		days, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || days <= 0 || req.DueFilter != proto.DueFilter_DUE_FILTER_UNSPECIFIED {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "due_within_days must be a positive integer and cannot be combined with due"})
+			return
+		}
+		req.DueFilter = proto.DueFilter_DUE_FILTER_DUE_WITHIN
+		req.DueWithinDays = int32(days)
+	}
+	req.TimeZone = c.Query("tz")
+	switch c.Query("order") {
+	case "", "asc":
+	case "desc":
+		req.Descending = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
 
-	resp, err := h.todoClient.GetTodos(context.Background(), req)
+	resp, err := h.todoClient.GetTodos(roleContext(c), req)
 	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get todos"})
 		return
 	}
 
-	c.JSON(http.StatusOK, resp.Todos)
+	c.Header("X-Total-Count", strconv.FormatInt(resp.TotalCount, 10))
+	if resp.NextPageToken != "" {
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("page_token", resp.NextPageToken)
+		next.RawQuery = query.Encode()
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+	c.JSON(http.StatusOK, newTodoListJSON(resp.Todos))
+}
+
+// SearchTodos — полнотекстовый поиск по задачам: q (текст запроса),
+// page_size (сколько лучших совпадений вернуть)
+func (h *TodoHandler) SearchTodos(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.SearchTodosRequest{UserId: userID.(string), Query: c.Query("q")}
+	if v := c.Query("page_size"); v != "" {
+		pageSize, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || pageSize < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be a non-negative integer"})
+			return
+		}
+		req.PageSize = int32(pageSize)
+	}
+
+	resp, err := h.todoClient.SearchTodos(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search todos"})
+		return
+	}
+
+	hits := make([]gin.H, 0, len(resp.Hits))
+	for _, hit := range resp.Hits {
+		hits = append(hits, gin.H{
+			"todo":    newTodoJSON(hit.Todo),
+			"rank":    hit.Rank,
+			"snippet": hit.Snippet,
+		})
+	}
+	c.JSON(http.StatusOK, hits)
 }
 
+func (h *TodoHandler) GetTodo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.GetTodoRequest{Id: c.Param("id"), UserId: userID.(string)}
+
+	resp, err := h.todoClient.GetTodo(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get todo"})
+		return
+	}
+
+	etag := todoETag(resp.Version)
+	c.Header("ETag", etag)
+	if ifNoneMatch(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, newTodoJSON(resp))
+}
+
+// UpdateTodo — PUT: полная замена, все поля задачи обязательны,
+// кроме parent_id
 func (h *TodoHandler) UpdateTodo(c *gin.Context) {
+	req, fields, ok := bindTodoUpdate(c)
+	if !ok {
+		return
+	}
+	for _, field := range todoRequiredFields {
+		if _, present := fields[field]; !present {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("field %q is required", field)})
+			return
+		}
+	}
+	// parent_id меняется, только если передан: иначе PUT клиента, не знающего
+	// о подзадачах, оторвал бы подзадачу от родителя
+	mask := &fieldmaskpb.FieldMask{}
+	for _, field := range todoUpdateFields {
+		if _, present := fields[field]; present || field != "parent_id" {
+			mask.Paths = append(mask.Paths, field)
+		}
+	}
+	req.UpdateMask = mask
+	h.updateTodo(c, req)
+}
+
+// PatchTodo — PATCH: меняются только поля, присутствующие в JSON
+func (h *TodoHandler) PatchTodo(c *gin.Context) {
+	req, fields, ok := bindTodoUpdate(c)
+	if !ok {
+		return
+	}
+	delete(fields, "version")
+	delete(fields, "complete_subtasks")
+	mask := &fieldmaskpb.FieldMask{}
+	for _, field := range todoUpdateFields {
+		if _, present := fields[field]; present {
+			mask.Paths = append(mask.Paths, field)
+			delete(fields, field)
+		}
+	}
+	for field := range fields {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown field %q", field)})
+		return
+	}
+	if len(mask.Paths) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+	req.UpdateMask = mask
+	h.updateTodo(c, req)
+}
+
+func (h *TodoHandler) updateTodo(c *gin.Context, req *proto.UpdateTodoRequest) {
+	version, conditional, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return
+	}
+	if conditional {
+		req.ExpectedVersion = version
+	}
+
+	resp, err := h.todoClient.UpdateTodo(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.Aborted {
+				c.JSON(versionConflictStatus(conditional), gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.PermissionDenied {
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument || st.Code() == codes.FailedPrecondition {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
+		return
+	}
+
+	c.Header("ETag", todoETag(resp.Version))
+	c.JSON(http.StatusOK, newTodoJSON(resp))
+}
+
+// bindTodoUpdate разбирает тело запроса на обновление задачи и возвращает
+// набор ключей верхнего уровня, чтобы отличить отсутствующее поле от нулевого
+func bindTodoUpdate(c *gin.Context) (*proto.UpdateTodoRequest, map[string]json.RawMessage, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return nil, nil, false
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	var payload struct {
+		Title      string     `json:"title"`
+		Completed  bool       `json:"completed"`
+		Version    uint64     `json:"version"`
+		DueAt      *time.Time `json:"due_at"`
+		RemindAt   *time.Time `json:"remind_at"`
+		Tags       []string   `json:"tags"`
+		ParentID   string     `json:"parent_id"`
+		Recurrence string     `json:"recurrence"`
+		TimeZone   string     `json:"time_zone"`
+		Priority   string     `json:"priority"`
+		// Не поле задачи, а опция: выполнить и все подзадачи
+		CompleteSubtasks bool `json:"complete_subtasks"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	priority, ok := todoPriorities[payload.Priority]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "priority must be one of none, low, medium, high, urgent"})
+		return nil, nil, false
+	}
+
+	req := &proto.UpdateTodoRequest{
+		Id:               c.Param("id"),
+		UserId:           userID.(string),
+		Title:            payload.Title,
+		Completed:        payload.Completed,
+		ExpectedVersion:  payload.Version,
+		DueAt:            timeToTimestamp(payload.DueAt),
+		RemindAt:         timeToTimestamp(payload.RemindAt),
+		Tags:             payload.Tags,
+		ParentId:         payload.ParentID,
+		Recurrence:       payload.Recurrence,
+		TimeZone:         payload.TimeZone,
+		Priority:         priority,
+		CompleteSubtasks: payload.CompleteSubtasks,
+	}
+	return req, fields, true
+}
+
+// MoveTodo переносит задачу в другой список; пустой list_id — в Inbox
+func (h *TodoHandler) MoveTodo(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
 		return
 	}
 
-	todoID := c.Param("id")
-	var req proto.UpdateTodoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var payload struct {
+		ListID string `json:"list_id"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	version, conditional, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return
+	}
+
+	req := &proto.MoveTodoRequest{
+		Id:              c.Param("id"),
+		UserId:          userID.(string),
+		ListId:          payload.ListID,
+		ExpectedVersion: version,
+	}
+
+	resp, err := h.todoClient.MoveTodo(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.Aborted {
+				c.JSON(versionConflictStatus(conditional), gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.PermissionDenied {
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument || st.Code() == codes.FailedPrecondition {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move todo"})
+		return
+	}
+
+	c.Header("ETag", todoETag(resp.Version))
+	c.JSON(http.StatusOK, newTodoJSON(resp))
+}
+
+// ReorderTodo ставит задачу в своем списке между after_id и before_id.
+// Достаточно указать одного соседа.
+func (h *TodoHandler) ReorderTodo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		AfterID  string `json:"after_id"`
+		BeforeID string `json:"before_id"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	req.Id = todoID
-	req.UserId = userID.(string)
+	version, conditional, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return
+	}
+
+	req := &proto.ReorderTodoRequest{
+		Id:              c.Param("id"),
+		UserId:          userID.(string),
+		AfterId:         payload.AfterID,
+		BeforeId:        payload.BeforeID,
+		ExpectedVersion: version,
+	}
 
-	resp, err := h.todoClient.UpdateTodo(context.Background(), &req)
+	resp, err := h.todoClient.ReorderTodo(roleContext(c), req)
 	if err != nil {
 		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.Aborted {
+				c.JSON(versionConflictStatus(conditional), gin.H{"error": st.Message()})
+				return
+			}
 			if st.Code() == codes.PermissionDenied {
 				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
 				return
@@ -86,12 +562,17 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
 				return
 			}
+			if st.Code() == codes.InvalidArgument || st.Code() == codes.FailedPrecondition {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder todo"})
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.Header("ETag", todoETag(resp.Version))
+	c.JSON(http.StatusOK, newTodoJSON(resp))
 }
 
 func (h *TodoHandler) DeleteTodo(c *gin.Context) {
@@ -101,12 +582,22 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 		return
 	}
 
+	version, conditional, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return
+	}
+
 	todoID := c.Param("id")
-	req := &proto.DeleteTodoRequest{Id: todoID, UserId: userID.(string)}
+	req := &proto.DeleteTodoRequest{Id: todoID, UserId: userID.(string), ExpectedVersion: version}
 
-	_, err := h.todoClient.DeleteTodo(context.Background(), req)
+	resp, err := h.todoClient.DeleteTodo(roleContext(c), req)
 	if err != nil {
 		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.Aborted {
+				c.JSON(versionConflictStatus(conditional), gin.H{"error": st.Message()})
+				return
+			}
 			if st.Code() == codes.PermissionDenied {
 				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
 				return
@@ -120,5 +611,30 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 		return
 	}
 
-	c.Status(http.StatusNoContent)
-}
\ No newline at end of file
+	// Удаленная задача возвращается в ответе: клиент может предложить отмену
+	// через POST /api/trash/:id/restore
+	c.JSON(http.StatusOK, newTodoJSON(resp.Todo))
+}
+
+// versionConflictStatus: несовпадение версии из If-Match — 412,
+// из тела запроса или при гонке без условия — 409
+func versionConflictStatus(conditional bool) int {
+	if conditional {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusConflict
+}
+
+// queryList собирает значения параметра, заданного повтором (?tag=a&tag=b)
+// или через запятую (?tag=a,b)
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"server/internal/proto"
+)
+
+// todoJSON — представление задачи в REST API. Даты отдаются в RFC 3339,
+// а не в виде google.protobuf.Timestamp.
+type todoJSON struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Title     string     `json:"title"`
+	Completed bool       `json:"completed"`
+	Version   uint64     `json:"version"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	RemindAt  *time.Time `json:"remind_at,omitempty"`
+	ListID    string     `json:"list_id,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	ParentID  string     `json:"parent_id,omitempty"`
+	Children  []todoJSON `json:"children,omitempty"`
+	Priority  string     `json:"priority"`
+	Position  string     `json:"position,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Recurrence     string    `json:"recurrence,omitempty"`
+	TimeZone       string    `json:"time_zone,omitempty"`
+	NextOccurrence *todoJSON `json:"next_occurrence,omitempty"`
+}
+
+func newTodoJSON(item *proto.TodoItem) todoJSON {
+	todo := todoJSON{
+		ID:        item.Id,
+		UserID:    item.UserId,
+		Title:     item.Title,
+		Completed: item.Completed,
+		Version:   item.Version,
+		DueAt:     timestampToTime(item.DueAt),
+		RemindAt:  timestampToTime(item.RemindAt),
+		ListID:    item.ListId,
+		Tags:      item.Tags,
+		ParentID:  item.ParentId,
+		Children:  newTodoListJSON(item.Children),
+		Priority:  priorityName(item.Priority),
+		Position:  item.Position,
+		DeletedAt: timestampToTime(item.DeletedAt),
+
+		Recurrence: item.Recurrence,
+		TimeZone:   item.TimeZone,
+	}
+	if item.NextOccurrence != nil {
+		next := newTodoJSON(item.NextOccurrence)
+		todo.NextOccurrence = &next
+	}
+	return todo
+}
+
+func newTodoListJSON(items []*proto.TodoItem) []todoJSON {
+	if items == nil {
+		return nil
+	}
+	todos := make([]todoJSON, 0, len(items))
+	for _, item := range items {
+		todos = append(todos, newTodoJSON(item))
+	}
+	return todos
+}
+
+// priorityName — обратное отображение todoPriorities
+func priorityName(p proto.Priority) string {
+	for name, v := range todoPriorities {
+		if v == p && name != "" {
+			return name
+		}
+	}
+	return "none"
+}
+
+func timestampToTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func timeToTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
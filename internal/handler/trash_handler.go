@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+)
+
+type TrashHandler struct {
+	todoClient proto.TodoServiceClient
+}
+
+func NewTrashHandler(todoClient proto.TodoServiceClient) *TrashHandler {
+	return &TrashHandler{todoClient: todoClient}
+}
+
+// ListTrash отдает одну страницу корзины, от недавно удаленных задач
+// к давним. Параметры запроса: page_size, page_token. Ссылка на следующую
+// страницу передается в заголовке Link.
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.ListTrashRequest{
+		UserId:    userID.(string),
+		PageToken: c.Query("page_token"),
+	}
+	if v := c.Query("page_size"); v != "" {
+		pageSize, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || pageSize < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be a non-negative integer"})
+			return
+		}
+		req.PageSize = int32(pageSize)
+	}
+
+	resp, err := h.todoClient.ListTrash(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trash"})
+		return
+	}
+
+	if resp.NextPageToken != "" {
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("page_token", resp.NextPageToken)
+		next.RawQuery = query.Encode()
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+	c.JSON(http.StatusOK, newTodoListJSON(resp.Todos))
+}
+
+func (h *TrashHandler) RestoreTodo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.RestoreTodoRequest{Id: c.Param("id"), UserId: userID.(string)}
+
+	resp, err := h.todoClient.RestoreTodo(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.PermissionDenied {
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.FailedPrecondition {
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore todo"})
+		return
+	}
+
+	c.Header("ETag", todoETag(resp.Version))
+	c.JSON(http.StatusOK, newTodoJSON(resp))
+}
+
+// PurgeTodo окончательно удаляет задачу из корзины
+func (h *TrashHandler) PurgeTodo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	req := &proto.PurgeTodoRequest{Id: c.Param("id"), UserId: userID.(string)}
+
+	_, err := h.todoClient.PurgeTodo(roleContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.PermissionDenied {
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge todo"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -2,12 +2,17 @@ package handler
 
 import (
 	"context"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"server/internal/clientip"
+	"server/internal/keyring"
 	"server/internal/proto"
 )
 
@@ -33,6 +38,10 @@ func (h *UserHandler) Register(c *gin.Context) {
 				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
 				return
 			}
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		return
@@ -48,17 +57,451 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.userClient.Login(context.Background(), &req)
+	resp, err := h.userClient.Login(clientContext(c), &req)
 	if err != nil {
 		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.ResourceExhausted {
+				tooManyRequests(c, st)
+				return
+			}
 			if st.Code() == codes.Unauthenticated {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message()})
 				return
 			}
+			if st.Code() == codes.PermissionDenied {
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": resp.Token})
-}
\ No newline at end of file
+	if resp.SecondFactorRequired {
+		// Токены выдаст LoginSecondFactor после проверки кода
+		c.JSON(http.StatusOK, gin.H{
+			"second_factor_required": true,
+			"challenge_token":        resp.ChallengeToken,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse(resp))
+}
+
+// clientContext передает UserService IP и User-Agent клиента: они нужны
+// для ограничения попыток входа и списка сессий
+func clientContext(c *gin.Context) context.Context {
+	ctx := clientip.NewOutgoingContext(context.Background(), c.ClientIP())
+	return clientip.WithUserAgent(ctx, c.Request.UserAgent())
+}
+
+// tooManyRequests отвечает 429 с Retry-After из RetryInfo, если он передан
+func tooManyRequests(c *gin.Context, st *status.Status) {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := int64(math.Ceil(info.GetRetryDelay().AsDuration().Seconds()))
+			c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+			break
+		}
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": st.Message()})
+}
+
+// LoginSecondFactor — второй шаг входа: challenge-токен из /api/login
+// и код из приложения или код восстановления
+func (h *UserHandler) LoginSecondFactor(c *gin.Context) {
+	var req proto.LoginSecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.LoginSecondFactor(clientContext(c), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.ResourceExhausted:
+				tooManyRequests(c, st)
+				return
+			case codes.InvalidArgument:
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			case codes.Unauthenticated:
+				c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message()})
+				return
+			case codes.PermissionDenied:
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(resp))
+}
+
+// RefreshToken обменивает refresh-токен на новую пару токенов
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req proto.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.Refresh(clientContext(c), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.Unauthenticated {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message()})
+				return
+			}
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(resp))
+}
+
+// Logout отзывает текущий access-токен; refresh_token в теле необязателен
+func (h *UserHandler) Logout(c *gin.Context) {
+	var payload struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	// Тело необязательно
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	req := &proto.LogoutRequest{Token: c.GetString("access_token"), RefreshToken: payload.RefreshToken}
+	resp, err := h.userClient.Logout(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.Unauthenticated {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// LogoutAll отзывает все токены пользователя на всех устройствах
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	req := &proto.LogoutAllRequest{Token: c.GetString("access_token")}
+	resp, err := h.userClient.LogoutAll(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.Unauthenticated {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// EnrollTOTP выдает секрет для приложения-аутентификатора
+func (h *UserHandler) EnrollTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.userClient.EnrollTOTP(context.Background(), &proto.EnrollTOTPRequest{UserId: userID.(string)})
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.FailedPrecondition {
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll two-factor authentication"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"secret": resp.Secret, "otpauth_uri": resp.OtpauthUri})
+}
+
+// ConfirmTOTP включает второй фактор и возвращает коды восстановления
+func (h *UserHandler) ConfirmTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &proto.ConfirmTOTPRequest{UserId: userID.(string), Code: payload.Code}
+	resp, err := h.userClient.ConfirmTOTP(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument:
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			case codes.FailedPrecondition:
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": resp.RecoveryCodes})
+}
+
+// DisableTOTP выключает второй фактор; нужны пароль и код
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &proto.DisableTOTPRequest{UserId: userID.(string), Password: payload.Password, Code: payload.Code}
+	resp, err := h.userClient.DisableTOTP(context.Background(), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.PermissionDenied:
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			case codes.FailedPrecondition:
+				c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// ChangePassword меняет пароль и возвращает новую пару токенов:
+// прежние токены, включая текущий, отзываются
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var payload struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &proto.ChangePasswordRequest{
+		UserId:          userID.(string),
+		CurrentPassword: payload.CurrentPassword,
+		NewPassword:     payload.NewPassword,
+	}
+	resp, err := h.userClient.ChangePassword(clientContext(c), req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument:
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			case codes.PermissionDenied:
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(resp))
+}
+
+// RequestPasswordReset отправляет ссылку для сброса пароля на почту
+func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
+	var req proto.RequestPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.RequestPasswordReset(context.Background(), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request password reset"})
+		return
+	}
+
+	// 202: письмо могло и не уйти — например, если адрес не зарегистрирован
+	c.JSON(http.StatusAccepted, gin.H{"message": resp.Message})
+}
+
+// ConfirmPasswordReset задает новый пароль по токену из письма
+func (h *UserHandler) ConfirmPasswordReset(c *gin.Context) {
+	var req proto.ConfirmPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.ConfirmPasswordReset(context.Background(), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req proto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.VerifyEmail(context.Background(), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// ResendVerificationEmail повторно отправляет письмо для подтверждения email
+func (h *UserHandler) ResendVerificationEmail(c *gin.Context) {
+	var req proto.ResendVerificationEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.ResendVerificationEmail(context.Background(), &req)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.InvalidArgument {
+				c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": resp.Message})
+}
+
+// JWKS публикует открытые ключи проверки токенов (RFC 7517)
+func (h *UserHandler) JWKS(c *gin.Context) {
+	resp, err := h.userClient.GetJWKS(context.Background(), &proto.GetJWKSRequest{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get signing keys"})
+		return
+	}
+
+	keys := make([]keyring.JWK, 0, len(resp.Keys))
+	for _, key := range resp.Keys {
+		keys = append(keys, keyring.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Alg: key.Alg,
+			Use: key.Use,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Crv,
+			X:   key.X,
+		})
+	}
+	// Новый ключ появляется в наборе при ротации, а подписывать им начинают
+	// сразу, поэтому надолго набор не кэшируется
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// RotateSigningKey — внеплановая ротация ключа подписи (только для администраторов)
+func (h *UserHandler) RotateSigningKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	resp, err := h.userClient.RotateSigningKey(context.Background(), &proto.RotateSigningKeyRequest{UserId: userID.(string)})
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			if st.Code() == codes.PermissionDenied {
+				c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate signing key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"kid": resp.Kid})
+}
+
+func tokenResponse(resp *proto.LoginResponse) gin.H {
+	return gin.H{
+		"token":         resp.Token,
+		"refresh_token": resp.RefreshToken,
+		"expires_in":    resp.ExpiresIn,
+	}
+}
@@ -0,0 +1,314 @@
+// Package keyring управляет ключами подписи JWT: генерирует их, ротирует
+// и публикует открытые части в виде JWKS. Ключи хранятся в базе, поэтому
+// все экземпляры UserService подписывают и проверяют одним набором.
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+// Поддерживаемые алгоритмы подписи
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+const rsaKeyBits = 2048
+
+// reloadCooldown ограничивает перечитывание ключей из базы при встрече
+// незнакомого kid, чтобы токены с выдуманным kid не нагружали базу
+const reloadCooldown = 10 * time.Second
+
+// ErrUnknownKey возвращается для kid, которого нет среди действующих
+// и выведенных из оборота ключей
+var ErrUnknownKey = errors.New("keyring: unknown key id")
+
+// Key — ключ подписи вместе с его идентификатором
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	RetiredAt *time.Time
+	private   crypto.Signer
+}
+
+// SigningMethod возвращает метод jwt, соответствующий алгоритму ключа
+func (k *Key) SigningMethod() jwt.SigningMethod {
+	if k.Algorithm == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// PrivateKey — ключ для jwt.Token.SignedString
+func (k *Key) PrivateKey() crypto.Signer {
+	return k.private
+}
+
+// PublicKey — ключ для проверки подписи в jwt.Keyfunc
+func (k *Key) PublicKey() crypto.PublicKey {
+	return k.private.Public()
+}
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWK возвращает открытую часть ключа
+func (k *Key) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+	switch pub := k.PublicKey().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// Keyring — набор ключей подписи: один действующий и выведенные из оборота,
+// которыми еще могут быть подписаны неистекшие токены
+type Keyring struct {
+	repo      repository.SigningKeyRepository
+	algorithm string
+	// Сколько хранится выведенный из оборота ключ — не меньше срока жизни токена
+	retiredTTL time.Duration
+
+	mu       sync.RWMutex
+	keys     map[string]*Key
+	current  *Key
+	loadedAt time.Time
+}
+
+func New(repo repository.SigningKeyRepository, algorithm string, retiredTTL time.Duration) (*Keyring, error) {
+	if algorithm != AlgEdDSA && algorithm != AlgRS256 {
+		return nil, fmt.Errorf("keyring: unsupported algorithm %q", algorithm)
+	}
+	return &Keyring{repo: repo, algorithm: algorithm, retiredTTL: retiredTTL}, nil
+}
+
+// Load перечитывает ключи из базы. Если действующего ключа нет или он
+// подписан другим алгоритмом, создается новый.
+func (k *Keyring) Load(ctx context.Context) error {
+	records, err := k.repo.ListSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	keys := make(map[string]*Key, len(records))
+	var current *Key
+	for _, record := range records {
+		if record.RetiredAt != nil && record.RetiredAt.Add(k.retiredTTL).Before(now) {
+			continue
+		}
+		key, err := decodeKey(record)
+		if err != nil {
+			return err
+		}
+		keys[key.ID] = key
+		// Записи идут от новых к старым
+		if current == nil && key.RetiredAt == nil {
+			current = key
+		}
+	}
+
+	k.mu.Lock()
+	k.keys, k.current, k.loadedAt = keys, current, now
+	k.mu.Unlock()
+
+	if current == nil || current.Algorithm != k.algorithm {
+		_, err := k.Rotate(ctx)
+		return err
+	}
+	return nil
+}
+
+// Rotate создает новый действующий ключ. Прежний выводится из оборота,
+// но продолжает проверять подписанные им токены до их истечения.
+func (k *Keyring) Rotate(ctx context.Context) (*Key, error) {
+	record, err := generateKey(k.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.repo.AddSigningKey(ctx, record); err != nil {
+		return nil, err
+	}
+	key, err := decodeKey(record)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.keys == nil {
+		k.keys = make(map[string]*Key)
+	}
+	if k.current != nil {
+		k.current.RetiredAt = &record.CreatedAt
+	}
+	k.keys[key.ID] = key
+	k.current = key
+	log.Printf("keyring: rotated signing key, new kid %s", key.ID)
+	return key, nil
+}
+
+// Current возвращает действующий ключ подписи
+func (k *Keyring) Current() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Lookup ищет ключ по kid. Незнакомый kid мог появиться после ротации
+// на другом экземпляре, поэтому ключи перечитываются (не чаще reloadCooldown).
+func (k *Keyring) Lookup(ctx context.Context, kid string) (*Key, error) {
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	stale := time.Since(k.loadedAt) > reloadCooldown
+	k.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, ErrUnknownKey
+	}
+	if err := k.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// Keys возвращает все ключи, которыми могут быть подписаны действующие токены
+func (k *Keyring) Keys() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Run раз в refreshInterval перечитывает ключи, ротирует действующий ключ,
+// которому исполнилось rotationInterval, и удаляет ключи, переставшие быть
+// нужными. Блокируется до отмены ctx.
+func (k *Keyring) Run(ctx context.Context, rotationInterval, refreshInterval time.Duration) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := k.Load(ctx); err != nil {
+				log.Printf("keyring: failed to reload keys: %v", err)
+				continue
+			}
+			if current := k.Current(); current != nil && now.Sub(current.CreatedAt) >= rotationInterval {
+				if _, err := k.Rotate(ctx); err != nil {
+					log.Printf("keyring: failed to rotate key: %v", err)
+				}
+			}
+			if err := k.repo.DeleteRetiredBefore(ctx, now.Add(-k.retiredTTL)); err != nil {
+				log.Printf("keyring: failed to delete retired keys: %v", err)
+			}
+		}
+	}
+}
+
+func generateKey(algorithm string) (*models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	if algorithm == AlgRS256 {
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	} else {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := make([]byte, 12)
+	if _, err := rand.Read(kid); err != nil {
+		return nil, err
+	}
+	return &models.SigningKey{
+		KID:        base64.RawURLEncoding.EncodeToString(kid),
+		Algorithm:  algorithm,
+		PrivateKey: der,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func decodeKey(record *models.SigningKey) (*Key, error) {
+	parsed, err := x509.ParsePKCS8PrivateKey(record.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: key %s: %w", record.KID, err)
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("keyring: key %s is not a signing key", record.KID)
+	}
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if record.Algorithm != AlgRS256 {
+			return nil, fmt.Errorf("keyring: key %s does not match algorithm %s", record.KID, record.Algorithm)
+		}
+	case ed25519.PrivateKey:
+		if record.Algorithm != AlgEdDSA {
+			return nil, fmt.Errorf("keyring: key %s does not match algorithm %s", record.KID, record.Algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("keyring: key %s has unsupported type %T", record.KID, parsed)
+	}
+	return &Key{
+		ID:        record.KID,
+		Algorithm: record.Algorithm,
+		CreatedAt: record.CreatedAt,
+		RetiredAt: record.RetiredAt,
+		private:   private,
+	}, nil
+}
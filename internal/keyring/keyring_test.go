@@ -0,0 +1,132 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"server/internal/repository/repotest"
+)
+
+func newTestKeyring(t *testing.T, repo *repotest.SigningKeys, algorithm string, retiredTTL time.Duration) *Keyring {
+	t.Helper()
+	keys, err := New(repo, algorithm, retiredTTL)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return keys
+}
+
+func sign(t *testing.T, key *Key) string {
+	t.Helper()
+	token := jwt.NewWithClaims(key.SigningMethod(), jwt.RegisteredClaims{Subject: "1"})
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.PrivateKey())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+// verify проверяет подпись токена ключом, найденным по kid
+func verify(keys *Keyring, token string) error {
+	_, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.Lookup(context.Background(), kid)
+		if err != nil {
+			return nil, err
+		}
+		return key.PublicKey(), nil
+	})
+	return err
+}
+
+func TestRotateKeepsOldKeyUntilRetired(t *testing.T) {
+	const retiredTTL = 50 * time.Millisecond
+	repo := repotest.NewSigningKeys()
+	keys := newTestKeyring(t, repo, AlgEdDSA, retiredTTL)
+	old := keys.Current()
+	if old == nil {
+		t.Fatalf("Load did not create a signing key")
+	}
+	token := sign(t, old)
+
+	current, err := keys.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if current.ID == old.ID || keys.Current().ID != current.ID {
+		t.Fatalf("Rotate did not replace the current key")
+	}
+	if err := verify(keys, token); err != nil {
+		t.Fatalf("token signed before rotation: %v", err)
+	}
+
+	// Другой экземпляр видит ротацию и тоже принимает старый ключ
+	other := newTestKeyring(t, repo, AlgEdDSA, retiredTTL)
+	if other.Current().ID != current.ID {
+		t.Fatalf("other instance current kid = %s, want %s", other.Current().ID, current.ID)
+	}
+	if err := verify(other, token); err != nil {
+		t.Fatalf("other instance: token signed before rotation: %v", err)
+	}
+
+	// После retiredTTL старый ключ забывается
+	time.Sleep(2 * retiredTTL)
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, err := keys.Lookup(context.Background(), old.ID); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Lookup of a retired key = %v, want ErrUnknownKey", err)
+	}
+	if list := keys.Keys(); len(list) != 1 || list[0].ID != current.ID {
+		t.Fatalf("Keys() = %d keys, want only the current one", len(list))
+	}
+	if err := verify(keys, sign(t, keys.Current())); err != nil {
+		t.Fatalf("token signed with the current key: %v", err)
+	}
+}
+
+func TestLookupReloadsUnknownKidAfterCooldown(t *testing.T) {
+	repo := repotest.NewSigningKeys()
+	keys := newTestKeyring(t, repo, AlgEdDSA, time.Hour)
+	other := newTestKeyring(t, repo, AlgEdDSA, time.Hour)
+
+	// Ротация на другом экземпляре
+	rotated, err := other.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	// Сразу после загрузки незнакомый kid не перечитывает базу
+	if _, err := keys.Lookup(context.Background(), rotated.ID); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Lookup within cooldown = %v, want ErrUnknownKey", err)
+	}
+	keys.mu.Lock()
+	keys.loadedAt = time.Now().Add(-reloadCooldown - time.Second)
+	keys.mu.Unlock()
+	if key, err := keys.Lookup(context.Background(), rotated.ID); err != nil || key.ID != rotated.ID {
+		t.Fatalf("Lookup after cooldown = %v, %v", key, err)
+	}
+	if _, err := keys.Lookup(context.Background(), "no-such-kid"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Lookup of a made-up kid = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestLoadRotatesOnAlgorithmChange(t *testing.T) {
+	repo := repotest.NewSigningKeys()
+	eddsa := newTestKeyring(t, repo, AlgEdDSA, time.Hour)
+	rsa := newTestKeyring(t, repo, AlgRS256, time.Hour)
+	if rsa.Current().Algorithm != AlgRS256 || rsa.Current().ID == eddsa.Current().ID {
+		t.Fatalf("keyring with a new algorithm kept the old key")
+	}
+	// Токены, подписанные прежним алгоритмом, проверяются до истечения
+	if err := verify(rsa, sign(t, eddsa.Current())); err != nil {
+		t.Fatalf("token signed with the previous algorithm: %v", err)
+	}
+}
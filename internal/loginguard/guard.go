@@ -0,0 +1,144 @@
+// Package loginguard защищает вход от подбора паролей. Неудачные попытки
+// считаются отдельно по аккаунту и по IP-адресу; после нескольких
+// бесплатных попыток каждая следующая откладывается на паузу, которая
+// удваивается с каждой неудачей, пока не достигнет максимума — это
+// и есть временная блокировка.
+package loginguard
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Attempts — неудачные попытки подряд для одного ключа
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// Store хранит счетчики попыток
+type Store interface {
+	// Get возвращает счетчики для keys; ключей без неудач в ответе нет
+	Get(ctx context.Context, keys []string) (map[string]Attempts, error)
+	// RecordFailure засчитывает неудачу. Если предыдущая неудача была
+	// раньше resetBefore, счет начинается заново.
+	RecordFailure(ctx context.Context, key string, at, resetBefore time.Time) error
+	// Reset обнуляет счетчик
+	Reset(ctx context.Context, key string) error
+	// DeleteStale удаляет счетчики, последняя неудача которых была раньше before
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Policy — правила для одного вида ключей
+type Policy struct {
+	// Сколько неудач подряд допускается без задержки
+	FreeAttempts int
+	// Пауза после первой неудачи сверх бесплатных; дальше она удваивается
+	BaseDelay time.Duration
+	// Наибольшая пауза — длительность временной блокировки
+	MaxDelay time.Duration
+	// Через сколько после последней неудачи счетчик сбрасывается
+	ResetAfter time.Duration
+}
+
+// delay возвращает, сколько еще ждать до следующей попытки
+func (p Policy) delay(a Attempts, now time.Time) time.Duration {
+	if a.Failures < p.FreeAttempts || now.Sub(a.LastFailure) >= p.ResetAfter {
+		return 0
+	}
+	d := p.MaxDelay
+	if shift := a.Failures - p.FreeAttempts; shift < 32 {
+		if scaled := p.BaseDelay << shift; scaled > 0 && scaled < p.MaxDelay {
+			d = scaled
+		}
+	}
+	return a.LastFailure.Add(d).Sub(now)
+}
+
+// Guard применяет политики к аккаунтам и IP-адресам
+type Guard struct {
+	store   Store
+	account Policy
+	ip      Policy
+}
+
+func New(store Store, account, ip Policy) *Guard {
+	return &Guard{store: store, account: account, ip: ip}
+}
+
+func accountKey(email string) string { return "account:" + email }
+func ipKey(ip string) string         { return "ip:" + ip }
+
+// Check возвращает, сколько нужно подождать до следующей попытки входа
+// в аккаунт email с адреса ip; 0 — можно пробовать сразу. Пустой ip
+// не проверяется. Несуществующие аккаунты считаются так же, как
+// существующие, чтобы блокировка не выдавала, зарегистрирован ли адрес.
+func (g *Guard) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	keys := []string{accountKey(email)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	attempts, err := g.store.Get(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	wait := g.account.delay(attempts[accountKey(email)], now)
+	if ip != "" {
+		if d := g.ip.delay(attempts[ipKey(ip)], now); d > wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, nil
+}
+
+// Failure засчитывает неудачную попытку аккаунту и адресу
+func (g *Guard) Failure(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	if err := g.store.RecordFailure(ctx, accountKey(email), now, now.Add(-g.account.ResetAfter)); err != nil {
+		return err
+	}
+	if ip != "" {
+		return g.store.RecordFailure(ctx, ipKey(ip), now, now.Add(-g.ip.ResetAfter))
+	}
+	return nil
+}
+
+// Success сбрасывает счетчик аккаунта после успешного входа. Счетчик IP
+// не сбрасывается: иначе, входя в свой аккаунт, можно было бы бесконечно
+// подбирать пароли к чужим с того же адреса.
+func (g *Guard) Success(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, accountKey(email))
+}
+
+// Unlock снимает блокировку аккаунта
+func (g *Guard) Unlock(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, accountKey(email))
+}
+
+// RunCleanup периодически удаляет счетчики, которые уже сбросились бы сами.
+// Блокируется до отмены ctx.
+func (g *Guard) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	resetAfter := g.account.ResetAfter
+	if g.ip.ResetAfter > resetAfter {
+		resetAfter = g.ip.ResetAfter
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := g.store.DeleteStale(ctx, now.Add(-resetAfter)); err != nil {
+				log.Printf("loginguard: failed to delete stale attempts: %v", err)
+			}
+		}
+	}
+}
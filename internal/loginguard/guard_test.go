@@ -0,0 +1,121 @@
+package loginguard
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     10 * time.Second,
+	ResetAfter:   time.Hour,
+}
+
+func TestDelayGrowsAndCaps(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 8 * time.Second},
+		{7, 10 * time.Second},
+		{40, 10 * time.Second},
+		{1000, 10 * time.Second},
+	} {
+		got := testPolicy.delay(Attempts{Failures: tc.failures, LastFailure: now}, now)
+		if got != tc.want {
+			t.Errorf("delay after %d failures = %v, want %v", tc.failures, got, tc.want)
+		}
+	}
+
+	// Пауза отсчитывается от последней неудачи
+	last := now.Add(-3 * time.Second)
+	if got := testPolicy.delay(Attempts{Failures: 6, LastFailure: last}, now); got != 5*time.Second {
+		t.Errorf("remaining delay = %v, want 5s", got)
+	}
+	// Через ResetAfter после последней неудачи пауз нет
+	stale := now.Add(-testPolicy.ResetAfter)
+	if got := testPolicy.delay(Attempts{Failures: 40, LastFailure: stale}, now); got != 0 {
+		t.Errorf("delay after ResetAfter = %v, want 0", got)
+	}
+}
+
+func TestMemoryStoreResetsAfterQuietPeriod(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Now().Add(-2 * testPolicy.ResetAfter)
+	for i := 0; i < 5; i++ {
+		at := start.Add(time.Duration(i) * time.Second)
+		if err := store.RecordFailure(ctx, "k", at, at.Add(-testPolicy.ResetAfter)); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	attempts, _ := store.Get(ctx, []string{"k"})
+	if attempts["k"].Failures != 5 {
+		t.Fatalf("failures = %d, want 5", attempts["k"].Failures)
+	}
+
+	// Неудача после долгого перерыва начинает счет заново
+	now := time.Now()
+	if err := store.RecordFailure(ctx, "k", now, now.Add(-testPolicy.ResetAfter)); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	attempts, _ = store.Get(ctx, []string{"k"})
+	if attempts["k"].Failures != 1 {
+		t.Fatalf("failures after quiet period = %d, want 1", attempts["k"].Failures)
+	}
+
+	if n, _ := store.DeleteStale(ctx, now); n != 0 {
+		t.Fatalf("DeleteStale removed a fresh counter")
+	}
+	if n, _ := store.DeleteStale(ctx, now.Add(time.Second)); n != 1 {
+		t.Fatalf("DeleteStale removed %d counters, want 1", n)
+	}
+}
+
+func TestGuardSuccessResetsOnlyAccount(t *testing.T) {
+	ctx := context.Background()
+	ipPolicy := testPolicy
+	ipPolicy.FreeAttempts = 5
+	guard := New(NewMemoryStore(), testPolicy, ipPolicy)
+
+	for i := 0; i < testPolicy.FreeAttempts; i++ {
+		if wait, err := guard.Check(ctx, "a@example.com", "10.0.0.1"); err != nil || wait != 0 {
+			t.Fatalf("attempt %d: wait = %v, err = %v", i, wait, err)
+		}
+		if err := guard.Failure(ctx, "a@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("Failure: %v", err)
+		}
+	}
+	if wait, _ := guard.Check(ctx, "a@example.com", "10.0.0.1"); wait <= 0 || wait > testPolicy.BaseDelay {
+		t.Fatalf("wait after free attempts = %v, want (0, %v]", wait, testPolicy.BaseDelay)
+	}
+	// Другой адрес в тот же аккаунт тоже ждет
+	if wait, _ := guard.Check(ctx, "a@example.com", "10.0.0.2"); wait <= 0 {
+		t.Fatalf("account lock does not apply from another address")
+	}
+
+	if err := guard.Success(ctx, "a@example.com"); err != nil {
+		t.Fatalf("Success: %v", err)
+	}
+	if wait, _ := guard.Check(ctx, "a@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("wait after success = %v, want 0", wait)
+	}
+
+	// Счетчик адреса успешный вход не сбрасывает
+	for i := 0; i < 2; i++ {
+		if err := guard.Failure(ctx, "b@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("Failure: %v", err)
+		}
+	}
+	if wait, _ := guard.Check(ctx, "c@example.com", "10.0.0.1"); wait <= 0 {
+		t.Fatalf("address counter was reset by another account's success")
+	}
+}
@@ -0,0 +1,63 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит счетчики в памяти процесса. Подходит для тестов и для
+// единственного экземпляра UserService: после перезапуска счетчики теряются.
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]Attempts)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, keys []string) (map[string]Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]Attempts, len(keys))
+	for _, key := range keys {
+		if a, ok := s.attempts[key]; ok {
+			result[key] = a
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) RecordFailure(ctx context.Context, key string, at, resetBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.attempts[key]
+	if a.LastFailure.Before(resetBefore) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailure = at
+	s.attempts[key] = a
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *MemoryStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for key, a := range s.attempts {
+		if a.LastFailure.Before(before) {
+			delete(s.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,53 @@
+package loginguard
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/internal/models"
+)
+
+// PostgresStore хранит счетчики в базе UserService и общий для всех ее экземпляров
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, keys []string) (map[string]Attempts, error) {
+	var rows []models.LoginAttempt
+	if err := s.db.WithContext(ctx).Where("key IN ?", keys).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]Attempts, len(rows))
+	for _, row := range rows {
+		result[row.Key] = Attempts{Failures: row.Failures, LastFailure: row.LastFailureAt}
+	}
+	return result, nil
+}
+
+func (s *PostgresStore) RecordFailure(ctx context.Context, key string, at, resetBefore time.Time) error {
+	// Один запрос, чтобы параллельные неудачи не потеряли инкремент
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END", resetBefore),
+				"last_failure_at": gorm.Expr("EXCLUDED.last_failure_at"),
+			}),
+		}).
+		Create(&models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: at}).Error
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func (s *PostgresStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("last_failure_at < ?", before).Delete(&models.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
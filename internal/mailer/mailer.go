@@ -0,0 +1,118 @@
+// Package mailer отправляет служебные письма пользователям: сброс пароля,
+// подтверждение адреса и т. п.
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message — текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer доставляет письма. Реализации: SMTPMailer для почтового сервера
+// и FileMailer, который складывает письма в каталог.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPMailer отправляет письма через SMTP-сервер. Если сервер поддерживает
+// STARTTLS, соединение шифруется (см. smtp.SendMail).
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer создает отправителя для сервера host:port. Если username
+// пустой, письма отправляются без аутентификации.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{addr: fmt.Sprintf("%s:%d", host, port), from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := format(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, data); err != nil {
+		return fmt.Errorf("mailer: failed to send to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// FileMailer сохраняет каждое письмо в отдельный .eml-файл каталога dir.
+// Нужен для локальной разработки и тестов: письмо можно открыть почтовым
+// клиентом или прочитать из каталога.
+type FileMailer struct {
+	dir  string
+	from string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("mailer: failed to create outbox: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	data, err := format(m.from, msg)
+	if err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	// Время в имени упорядочивает письма при просмотре каталога
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Письма содержат одноразовые токены, поэтому доступны только владельцу
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("mailer: failed to write message: %w", err)
+	}
+	return nil
+}
+
+// format собирает письмо в формате RFC 5322. Перевод строки в адресе
+// позволил бы дописать в письмо чужие заголовки, поэтому такой адрес отвергается.
+func format(from string, msg Message) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(from, "\r\n") {
+		return nil, fmt.Errorf("mailer: invalid address %q", msg.To)
+	}
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String()), nil
+}
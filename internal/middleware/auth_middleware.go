@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"log"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -49,9 +51,62 @@ func AuthMiddleware(userClient proto.UserServiceClient) gin.HandlerFunc {
 		}
 
 		// Если токен валиден, сохраняем user_id и role в контексте Gin
-		c.Set("user_id", resp.UserId)
-		c.Set("user_role", resp.Role)
+		setAuthContext(c, &VerifiedToken{UserID: resp.UserId, Role: resp.Role, SessionID: resp.SessionId, APIKey: resp.ApiKey, Scopes: resp.Scopes}, token)
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// setAuthContext сохраняет данные проверенного токена в контексте Gin.
+// JWT и API-ключ дают одни и те же user_id и user_role; у API-ключа
+// дополнительно есть api_key_scopes (см. RequirePermission).
+func setAuthContext(c *gin.Context, verified *VerifiedToken, token string) {
+	c.Set("user_id", verified.UserID)
+	c.Set("user_role", verified.Role)
+	if verified.APIKey {
+		c.Set("api_key_scopes", verified.Scopes)
+		return
+	}
+	// Сам токен нужен для выхода (Logout), сессия — для списка сессий
+	c.Set("access_token", token)
+	if verified.SessionID != "" {
+		c.Set("session_id", verified.SessionID)
+	}
+}
+
+// LocalAuthMiddleware проверяет токен в самом шлюзе через TokenVerifier,
+// не обращаясь к UserService на каждый запрос
+func LocalAuthMiddleware(verifier *TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(401, gin.H{"error": "Authorization header is required"})
+			c.Abort()
+			return
+		}
+
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+		if token == "" {
+			c.JSON(401, gin.H{"error": "Token is missing"})
+			c.Abort()
+			return
+		}
+
+		verified, err := verifier.Verify(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) {
+				c.JSON(401, gin.H{"error": "Invalid or expired token"})
+				c.Abort()
+				return
+			}
+			log.Printf("auth: failed to verify token: %v", err)
+			c.JSON(500, gin.H{"error": "Failed to validate token"})
+			c.Abort()
+			return
+		}
+
+		setAuthContext(c, verified, token)
+
+		c.Next()
+	}
+}
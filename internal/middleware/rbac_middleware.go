@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"server/internal/rbac"
+)
+
+// RequirePermission пропускает запрос, только если роль из токена дает
+// право permission, а для API-ключа — если оно есть еще и среди его прав.
+// Ставится после AuthMiddleware/LocalAuthMiddleware.
+// Роль в токене может устареть, поэтому сервис проверяет право еще раз.
+func RequirePermission(permission rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("user_role")
+		if !rbac.HasPermission(role, permission) || !scopeAllows(c, permission) {
+			c.JSON(403, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession закрывает маршрут для API-ключей. Управление учетной
+// записью (пароль, второй фактор, сами ключи) доступно только после входа.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get("api_key_scopes"); isAPIKey {
+			c.JSON(403, gin.H{"error": "This action is not available with an API key"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func scopeAllows(c *gin.Context, permission rbac.Permission) bool {
+	value, isAPIKey := c.Get("api_key_scopes")
+	if !isAPIKey {
+		return true
+	}
+	scopes, _ := value.([]string)
+	for _, scope := range scopes {
+		if rbac.Permission(scope) == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"server/internal/rbac"
+)
+
+// serve пропускает запрос через middleware после «аутентификации»,
+// выставившей role и, для API-ключа, scopes; возвращает код ответа
+func serve(role string, scopes []string, handlers ...gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	chain := []gin.HandlerFunc{func(c *gin.Context) {
+		c.Set("user_id", "1")
+		c.Set("user_role", role)
+		if scopes != nil {
+			c.Set("api_key_scopes", scopes)
+		}
+	}}
+	chain = append(chain, handlers...)
+	chain = append(chain, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/", chain...)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		role       string
+		scopes     []string
+		permission rbac.Permission
+		want       int
+	}{
+		{"user writes todos", rbac.RoleUser, nil, rbac.TodosWrite, http.StatusNoContent},
+		{"unverified writes todos", rbac.RoleUnverified, nil, rbac.TodosWrite, http.StatusForbidden},
+		{"user manages users", rbac.RoleUser, nil, rbac.UsersManage, http.StatusForbidden},
+		{"admin manages users", rbac.RoleAdmin, nil, rbac.UsersManage, http.StatusNoContent},
+		{"no role", "", nil, rbac.TodosRead, http.StatusForbidden},
+		{"key with scope", rbac.RoleUser, []string{"todos:read", "todos:write"}, rbac.TodosWrite, http.StatusNoContent},
+		{"key without scope", rbac.RoleUser, []string{"todos:read"}, rbac.TodosWrite, http.StatusForbidden},
+		{"key without scopes", rbac.RoleUser, []string{}, rbac.TodosRead, http.StatusForbidden},
+		// Право ключа не расширяет права роли
+		{"key scope beyond role", rbac.RoleUser, []string{"users:manage"}, rbac.UsersManage, http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := serve(tc.role, tc.scopes, RequirePermission(tc.permission)); got != tc.want {
+				t.Fatalf("status = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	if got := serve(rbac.RoleUser, nil, RequireSession()); got != http.StatusNoContent {
+		t.Fatalf("session: status = %d, want %d", got, http.StatusNoContent)
+	}
+	if got := serve(rbac.RoleAdmin, []string{"users:manage"}, RequireSession()); got != http.StatusForbidden {
+		t.Fatalf("API key: status = %d, want %d", got, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/apikey"
+	"server/internal/proto"
+)
+
+// jwksRefreshCooldown ограничивает перезагрузку ключей при незнакомом kid,
+// чтобы токены с выдуманным kid не превращались в поток запросов к UserService
+const jwksRefreshCooldown = 10 * time.Second
+
+// maxRevocationCacheSize — при превышении из кэша выбрасываются просроченные
+// записи, а если таких нет — все
+const maxRevocationCacheSize = 10000
+
+var (
+	// ErrInvalidToken оборачивает все ошибки, вызванные самим токеном
+	ErrInvalidToken = errors.New("invalid token")
+
+	errUnknownKey      = errors.New("unknown signing key")
+	errKeysUnavailable = errors.New("signing keys are unavailable")
+	errTokenRevoked    = errors.New("token has been revoked")
+)
+
+// VerifiedToken — данные из токена, прошедшего проверку
+type VerifiedToken struct {
+	UserID string
+	Role   string
+	// Сессия, к которой относится access-токен
+	SessionID string
+	// Для API-ключа — права, которыми он ограничен
+	APIKey bool
+	Scopes []string
+}
+
+// TokenVerifier проверяет JWT в шлюзе без обращения к UserService на каждый
+// запрос. Открытые ключи берутся из JWKS UserService и кэшируются; при
+// незнакомом kid (после ротации) набор ключей загружается заново.
+// Если задан revocationTTL, отзыв токена проверяется через UserService,
+// а ответы кэшируются на этот срок.
+type TokenVerifier struct {
+	userClient    proto.UserServiceClient
+	revocationTTL time.Duration // 0 — список отзывов не проверяется
+
+	mu         sync.Mutex
+	keys       map[string]crypto.PublicKey
+	algorithms map[string]string // kid -> alg
+	fetchedAt  time.Time
+	revoked    map[string]revocationEntry // jti -> ответ UserService
+}
+
+type revocationEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+func NewTokenVerifier(userClient proto.UserServiceClient, revocationTTL time.Duration) *TokenVerifier {
+	return &TokenVerifier{
+		userClient:    userClient,
+		revocationTTL: revocationTTL,
+		revoked:       make(map[string]revocationEntry),
+	}
+}
+
+// Verify проверяет подпись, срок действия и, в режиме с отзывами, список отзывов.
+// API-ключи проверить локально нельзя: они всегда сверяются с UserService.
+func (v *TokenVerifier) Verify(ctx context.Context, tokenString string) (*VerifiedToken, error) {
+	if apikey.IsAPIKey(tokenString) {
+		return v.verifyAPIKey(ctx, tokenString)
+	}
+
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, alg, err := v.lookupKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != alg {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		// Недоступность UserService — не вина клиента
+		if errors.Is(err, errKeysUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	userID, _ := claims["user_id"].(string)
+	if userID == "" {
+		return nil, ErrInvalidToken
+	}
+	role, _ := claims["role"].(string)
+	sessionID, _ := claims["sid"].(string)
+
+	if v.revocationTTL > 0 {
+		jti, _ := claims["jti"].(string)
+		iat, _ := claims["iat"].(float64)
+		revoked, err := v.isRevoked(ctx, jti, userID, sessionID, int64(math.Round(iat*1e6)))
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, errTokenRevoked)
+		}
+	}
+
+	return &VerifiedToken{UserID: userID, Role: role, SessionID: sessionID}, nil
+}
+
+func (v *TokenVerifier) verifyAPIKey(ctx context.Context, key string) (*VerifiedToken, error) {
+	resp, err := v.userClient.ValidateToken(ctx, &proto.ValidateTokenRequest{Token: key})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, status.Convert(err).Message())
+		}
+		return nil, fmt.Errorf("failed to validate API key: %w", err)
+	}
+	if !resp.IsValid {
+		return nil, ErrInvalidToken
+	}
+	return &VerifiedToken{UserID: resp.UserId, Role: resp.Role, APIKey: resp.ApiKey, Scopes: resp.Scopes}, nil
+}
+
+func (v *TokenVerifier) lookupKey(ctx context.Context, kid string) (crypto.PublicKey, string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, v.algorithms[kid], nil
+	}
+	if time.Since(v.fetchedAt) < jwksRefreshCooldown {
+		return nil, "", errUnknownKey
+	}
+	if err := v.fetchKeys(ctx); err != nil {
+		return nil, "", err
+	}
+	if key, ok := v.keys[kid]; ok {
+		return key, v.algorithms[kid], nil
+	}
+	return nil, "", errUnknownKey
+}
+
+// fetchKeys загружает JWKS. Вызывается под v.mu.
+func (v *TokenVerifier) fetchKeys(ctx context.Context) error {
+	resp, err := v.userClient.GetJWKS(ctx, &proto.GetJWKSRequest{})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errKeysUnavailable, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(resp.Keys))
+	algorithms := make(map[string]string, len(resp.Keys))
+	for _, jwk := range resp.Keys {
+		key, err := publicKeyFromJWK(jwk)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errKeysUnavailable, err)
+		}
+		keys[jwk.Kid] = key
+		algorithms[jwk.Kid] = jwk.Alg
+	}
+	v.keys, v.algorithms, v.fetchedAt = keys, algorithms, time.Now()
+	return nil
+}
+
+func (v *TokenVerifier) isRevoked(ctx context.Context, jti, userID, sessionID string, issuedAtMicros int64) (bool, error) {
+	now := time.Now()
+	v.mu.Lock()
+	entry, ok := v.revoked[jti]
+	v.mu.Unlock()
+	if ok && jti != "" && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	resp, err := v.userClient.IsTokenRevoked(ctx, &proto.IsTokenRevokedRequest{
+		Jti:            jti,
+		UserId:         userID,
+		SessionId:      sessionID,
+		IssuedAtMicros: issuedAtMicros,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	if jti != "" {
+		v.mu.Lock()
+		if len(v.revoked) >= maxRevocationCacheSize {
+			for key, e := range v.revoked {
+				if !now.Before(e.expiresAt) {
+					delete(v.revoked, key)
+				}
+			}
+			// Все записи свежие — кэш начинается заново, а не растет без предела
+			if len(v.revoked) >= maxRevocationCacheSize {
+				v.revoked = make(map[string]revocationEntry)
+			}
+		}
+		v.revoked[jti] = revocationEntry{revoked: resp.Revoked, expiresAt: now.Add(v.revocationTTL)}
+		v.mu.Unlock()
+	}
+	return resp.Revoked, nil
+}
+
+func publicKeyFromJWK(jwk *proto.JWK) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid modulus: %w", jwk.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid exponent: %w", jwk.Kid, err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: invalid Ed25519 key", jwk.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %q", jwk.Kid, jwk.Kty)
+	}
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+
+	"server/internal/proto"
+)
+
+// fakeUserClient отдает JWKS и ответы об отзыве и считает обращения
+type fakeUserClient struct {
+	proto.UserServiceClient
+
+	mu          sync.Mutex
+	keys        []*proto.JWK
+	jwksCalls   int
+	revokeCalls int
+	revoked     map[string]bool
+}
+
+func (c *fakeUserClient) GetJWKS(ctx context.Context, req *proto.GetJWKSRequest, opts ...grpc.CallOption) (*proto.GetJWKSResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jwksCalls++
+	return &proto.GetJWKSResponse{Keys: c.keys}, nil
+}
+
+func (c *fakeUserClient) IsTokenRevoked(ctx context.Context, req *proto.IsTokenRevokedRequest, opts ...grpc.CallOption) (*proto.IsTokenRevokedResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revokeCalls++
+	return &proto.IsTokenRevokedResponse{Revoked: c.revoked[req.Jti]}, nil
+}
+
+// addKey публикует новый ключ Ed25519 и возвращает закрытую часть
+func (c *fakeUserClient) addKey(t *testing.T, kid string) ed25519.PrivateKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = append(c.keys, &proto.JWK{
+		Kty: "OKP",
+		Kid: kid,
+		Alg: "EdDSA",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(public),
+	})
+	return private
+}
+
+func (c *fakeUserClient) calls() (jwks, revoke int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.jwksCalls, c.revokeCalls
+}
+
+func signToken(t *testing.T, kid string, key ed25519.PrivateKey, jti string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"user_id": "1",
+		"role":    "user",
+		"jti":     jti,
+		"iat":     time.Now().Unix(),
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+func TestVerifierReloadsUnknownKidAfterCooldown(t *testing.T) {
+	client := &fakeUserClient{}
+	first := client.addKey(t, "k1")
+	verifier := NewTokenVerifier(client, 0)
+
+	if _, err := verifier.Verify(context.Background(), signToken(t, "k1", first, "a")); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if jwks, _ := client.calls(); jwks != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", jwks)
+	}
+
+	// Ключ после ротации: в пределах паузы набор ключей не перезагружается
+	second := client.addKey(t, "k2")
+	rotated := signToken(t, "k2", second, "b")
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(context.Background(), rotated); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Verify within cooldown = %v, want ErrInvalidToken", err)
+		}
+	}
+	if jwks, _ := client.calls(); jwks != 1 {
+		t.Fatalf("JWKS fetched %d times within cooldown, want 1", jwks)
+	}
+
+	verifier.mu.Lock()
+	verifier.fetchedAt = time.Now().Add(-jwksRefreshCooldown - time.Second)
+	verifier.mu.Unlock()
+	if _, err := verifier.Verify(context.Background(), rotated); err != nil {
+		t.Fatalf("Verify after cooldown: %v", err)
+	}
+	if jwks, _ := client.calls(); jwks != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", jwks)
+	}
+	// Выдуманный kid снова упирается в паузу
+	if _, err := verifier.Verify(context.Background(), signToken(t, "k3", second, "c")); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify with unknown kid = %v, want ErrInvalidToken", err)
+	}
+	if jwks, _ := client.calls(); jwks != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", jwks)
+	}
+}
+
+func TestVerifierCachesRevocation(t *testing.T) {
+	client := &fakeUserClient{revoked: map[string]bool{"revoked": true}}
+	key := client.addKey(t, "k1")
+	verifier := NewTokenVerifier(client, time.Hour)
+
+	token := signToken(t, "k1", key, "live")
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(context.Background(), token); err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+	}
+	if _, revoke := client.calls(); revoke != 1 {
+		t.Fatalf("revocation checked %d times, want 1", revoke)
+	}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "k1", key, "revoked")); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify of a revoked token = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifierRevocationCacheIsBounded(t *testing.T) {
+	client := &fakeUserClient{}
+	key := client.addKey(t, "k1")
+	verifier := NewTokenVerifier(client, time.Hour)
+
+	// Переполненный кэш без просроченных записей сбрасывается целиком
+	fresh := time.Now().Add(time.Hour)
+	for i := 0; i < maxRevocationCacheSize; i++ {
+		verifier.revoked[strconv.Itoa(i)] = revocationEntry{expiresAt: fresh}
+	}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "k1", key, "new")); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if n := len(verifier.revoked); n != 1 {
+		t.Fatalf("cache holds %d entries after overflow, want 1", n)
+	}
+
+	// Из переполненного кэша выбрасываются только просроченные записи
+	expired := time.Now().Add(-time.Second)
+	for i := 0; i < maxRevocationCacheSize; i++ {
+		entry := revocationEntry{expiresAt: fresh}
+		if i%2 == 0 {
+			entry.expiresAt = expired
+		}
+		verifier.revoked[strconv.Itoa(i)] = entry
+	}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "k1", key, "newer")); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if n, want := len(verifier.revoked), maxRevocationCacheSize/2+2; n != want {
+		t.Fatalf("cache holds %d entries, want %d", n, want)
+	}
+	if _, ok := verifier.revoked["1"]; !ok {
+		t.Fatalf("live entry was evicted")
+	}
+}
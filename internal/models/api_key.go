@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIKey — персональный токен доступа для скриптов и CI. Хранится только
+// SHA-256 токена; Prefix — его начало, по которому владелец узнает ключ
+// в списке.
+type APIKey struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	Name      string `gorm:"not null"`
+	Prefix    string `gorm:"not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	// Права (rbac.Permission) через пробел
+	Scopes     string     `gorm:"not null"`
+	ExpiresAt  *time.Time // nil — бессрочный
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
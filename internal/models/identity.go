@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Identity — учетная запись внешнего провайдера OpenID Connect (SSO),
+// привязанная к пользователю. У пользователя может быть по одной учетной
+// записи каждого провайдера.
+type Identity struct {
+	ID     uint `gorm:"primarykey"`
+	UserID uint `gorm:"not null;uniqueIndex:idx_identities_user_provider"`
+	// Имя провайдера из настроек шлюза и sub из его ID-токена
+	Provider string `gorm:"not null;uniqueIndex:idx_identities_user_provider;uniqueIndex:idx_identities_provider_subject"`
+	Subject  string `gorm:"not null;uniqueIndex:idx_identities_provider_subject"`
+	// Email у провайдера на момент последнего входа
+	Email       string
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+}
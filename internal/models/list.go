@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+// InboxListName — имя списка по умолчанию
+const InboxListName = "Inbox"
+
+type List struct {
+	gorm.Model
+	UserID  uint   `gorm:"index;not null"`
+	Name    string `gorm:"not null"`
+	IsInbox bool   `gorm:"not null;default:false"`
+	// Для Inbox равно UserID, для остальных списков NULL: уникальный индекс
+	// не дает создать второй Inbox при параллельных запросах
+	InboxOwner *uint `gorm:"uniqueIndex"`
+}
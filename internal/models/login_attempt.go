@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginAttempt — счетчик неудачных попыток входа подряд для одного ключа
+// (аккаунта или IP-адреса), см. пакет loginguard
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey"`
+	Failures      int       `gorm:"not null"`
+	LastFailureAt time.Time `gorm:"index;not null"`
+}
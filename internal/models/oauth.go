@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// OAuthClient — приложение, которому разрешен вход через OAuth
+type OAuthClient struct {
+	ID       uint   `gorm:"primarykey"`
+	ClientID string `gorm:"uniqueIndex;not null"`
+	Name     string `gorm:"not null"`
+	// SHA-256 секрета; пусто у публичных клиентов (SPA, CLI), которые
+	// секрет хранить не могут и полагаются только на PKCE
+	SecretHash string
+	// Разрешенные адреса возврата через пробел
+	RedirectURIs string `gorm:"not null"`
+	CreatedBy    uint
+	CreatedAt    time.Time
+}
+
+// OAuthCode — код авторизации. Как и остальные токены, хранится только его SHA-256.
+type OAuthCode struct {
+	ID       uint   `gorm:"primarykey"`
+	CodeHash string `gorm:"uniqueIndex;not null"`
+	ClientID string `gorm:"index;not null"`
+	UserID   uint   `gorm:"index;not null"`
+	// redirect_uri из запроса авторизации; пусто, если он не передавался
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string `gorm:"not null"` // S256
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
+
+// OAuthToken — непрозрачный access-токен OAuth. Дает доступ только
+// к userinfo, но не к API приложения.
+type OAuthToken struct {
+	ID        uint   `gorm:"primarykey"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	// Код, в обмен на который выдан токен: при повторном предъявлении
+	// кода токен отзывается
+	CodeID    uint   `gorm:"index"`
+	ClientID  string `gorm:"index;not null"`
+	UserID    uint   `gorm:"index;not null"`
+	Scope     string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken — выданный refresh-токен. Сам токен не хранится, только его
+// SHA-256. Все токены, полученные ротацией из одного входа, образуют
+// семейство: при повторном предъявлении уже использованного токена
+// отзывается все семейство.
+type RefreshToken struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	FamilyID  string `gorm:"index;not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time // когда токен обменяли на новый
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RevokedToken — отозванный до истечения срока access-токен
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+// UserRevocation — "выход на всех устройствах": недействительны все токены
+// пользователя, выданные раньше RevokedBefore
+type UserRevocation struct {
+	UserID        uint      `gorm:"primaryKey;autoIncrement:false"`
+	RevokedBefore time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+}
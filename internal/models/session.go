@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Session — вход пользователя с одного устройства. Refresh-токены сессии
+// образуют одно семейство (RefreshToken.FamilyID = Session.ID),
+// access-токены несут ее идентификатор в claim sid.
+type Session struct {
+	ID        string `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	UserAgent string
+	IP        string
+	CreatedAt time.Time
+	// Обновляется не на каждый запрос, а не чаще раза в минуту
+	LastSeenAt time.Time
+	// Когда истечет последний выданный refresh-токен сессии
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SigningKey — ключ подписи JWT. Действующий ключ один — самый новый
+// неотозванный; выведенные из оборота хранятся, пока подписанные ими
+// токены не истекут.
+type SigningKey struct {
+	KID        string `gorm:"primaryKey"`
+	Algorithm  string `gorm:"not null"` // "EdDSA" или "RS256"
+	PrivateKey []byte `gorm:"not null"` // PKCS #8, DER
+	CreatedAt  time.Time
+	RetiredAt  *time.Time `gorm:"index"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Tag — метка задачи. Имя уникально в пределах пользователя. Мягкого
+// удаления нет: удаленное имя должно сразу освобождаться для upsert.
+type Tag struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Name      string `gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
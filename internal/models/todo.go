@@ -1,12 +1,28 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type Todo struct {
 	gorm.Model
-	UserID    uint
-	Title     string
-	Completed bool
-}
\ No newline at end of file
+	UserID     uint  `gorm:"index"`
+	ListID     uint  `gorm:"index"`
+	ParentID   *uint `gorm:"index"` // nil — задача верхнего уровня
+	Title      string
+	Completed  bool
+	Priority   int    `gorm:"not null;default:0"` // значение proto.Priority
+	Position   string `gorm:"index"`              // ключ ручного порядка, см. пакет position
+	Version    uint64 `gorm:"not null;default:1"`
+	DueAt      *time.Time
+	RemindAt   *time.Time `gorm:"index"`
+	RemindedAt *time.Time // когда по RemindAt уже отправлено напоминание
+	Tags       []Tag      `gorm:"many2many:todo_tags;"`
+	// Правило повторения (RRULE) и его параметры. SeriesStart — due_at первого
+	// вхождения серии, от него считаются COUNT и дни недели.
+	Recurrence  string
+	SeriesStart *time.Time
+	TimeZone    string
+}
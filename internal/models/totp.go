@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TOTPCredential — секрет второго фактора пользователя. Пока ConfirmedAt
+// пустой, секрет только выдан приложению и вход не защищает.
+type TOTPCredential struct {
+	UserID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Secret []byte `gorm:"not null"`
+	// Номер последнего принятого временного шага: код нельзя использовать дважды
+	LastCounter uint64
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// RecoveryCode — одноразовый код восстановления на случай потери
+// устройства. Хранится только SHA-256 кода.
+type RecoveryCode struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	CodeHash  string `gorm:"uniqueIndex;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -1,11 +1,19 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
 	Email    string `gorm:"uniqueIndex;not null"`
 	Password string `gorm:"not null"`
 	Role     string `gorm:"not null;default:'user'"` // Например, "admin" или "user"
+	// Заблокированный пользователь не может войти; nil — активен
+	DisabledAt *time.Time
+	// Подтвердил ли пользователь адрес по ссылке из письма
+	EmailVerified bool `gorm:"not null;default:false"`
 }
 
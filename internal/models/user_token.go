@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Назначения одноразовых токенов
+const (
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailVerification = "email_verification"
+	// Выдается после проверки пароля, если включен второй фактор
+	UserTokenLoginChallenge = "login_challenge"
+	// Привязка учетной записи внешнего провайдера к вошедшему пользователю
+	UserTokenIdentityLink = "identity_link"
+)
+
+// UserToken — одноразовый токен пользователя: ссылка из письма или
+// промежуточный токен входа. Как и у refresh-токенов, хранится только
+// SHA-256 токена.
+type UserToken struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	Purpose   string `gorm:"not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time // когда токен использовали или он стал недействителен
+	// Неудачные попытки использовать токен (например, неверные коды 2FA)
+	Attempts  int `gorm:"not null;default:0"`
+	CreatedAt time.Time
+}
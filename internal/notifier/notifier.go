@@ -0,0 +1,41 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+)
+
+// ReminderEvent — напоминание о задаче, у которой наступило время remind_at
+type ReminderEvent struct {
+	TodoID   uint
+	UserID   uint
+	Title    string
+	DueAt    *time.Time
+	RemindAt time.Time
+}
+
+// Notifier доставляет напоминания пользователям. Реализации могут
+// отправлять письма, push-уведомления или писать в очередь.
+type Notifier interface {
+	NotifyReminder(ctx context.Context, event ReminderEvent) error
+}
+
+// LogNotifier пишет напоминания в лог — для локальной разработки
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{logger: log.New(w, "[reminder] ", log.LstdFlags)}
+}
+
+func (n *LogNotifier) NotifyReminder(ctx context.Context, event ReminderEvent) error {
+	due := "no due date"
+	if event.DueAt != nil {
+		due = "due " + event.DueAt.Format(time.RFC3339)
+	}
+	n.logger.Printf("user=%d todo=%d %q (%s)", event.UserID, event.TodoID, event.Title, due)
+	return nil
+}
@@ -0,0 +1,61 @@
+// Package oidc — общее для сервера авторизации OAuth 2.0 / OpenID Connect
+// в UserService и шлюзе: поддерживаемые scope, документ discovery и PKCE.
+// Provider — клиент внешнего провайдера для входа через SSO.
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Поддерживаемые scope
+const (
+	ScopeOpenID = "openid"
+	ScopeEmail  = "email"
+)
+
+// Scopes — все поддерживаемые scope
+var Scopes = []string{ScopeOpenID, ScopeEmail}
+
+// PKCE: допускается только S256, "plain" не защищает от перехвата кода
+const CodeChallengeMethodS256 = "S256"
+
+// Discovery — документ /.well-known/openid-configuration (OpenID Connect Discovery 1.0)
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported,omitempty"`
+	ClaimsSupported                   []string `json:"claims_supported,omitempty"`
+}
+
+// S256Challenge вычисляет code_challenge для code_verifier (RFC 7636, 4.2)
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ValidVerifier проверяет формат code_verifier: 43–128 символов
+// из набора [A-Za-z0-9-._~] (RFC 7636, 4.1)
+func ValidVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,419 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWKS провайдера перечитывается при неизвестном kid не чаще раза в минуту
+const providerKeysRefreshCooldown = time.Minute
+
+// ProviderConfig — настройки внешнего провайдера OpenID Connect
+type ProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Адрес возврата, зарегистрированный у провайдера
+	RedirectURL string
+	Scopes      []string
+}
+
+// Provider — внешний провайдер OpenID Connect (SSO), через которого входят
+// пользователи; шлюз выступает клиентом (relying party). Документ discovery
+// и ключи загружаются при первом входе: недоступность провайдера не мешает
+// запуску шлюза.
+type Provider struct {
+	cfg        ProviderConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *Discovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// IdentityClaims — проверенные claims ID-токена
+type IdentityClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// ProviderError — ошибка, которую вернул провайдер (RFC 6749, 5.2)
+type ProviderError struct {
+	Code        string
+	Description string
+}
+
+func (e *ProviderError) Error() string {
+	if e.Description == "" {
+		return "oidc: provider error: " + e.Code
+	}
+	return "oidc: provider error: " + e.Code + ": " + e.Description
+}
+
+func NewProvider(cfg ProviderConfig) *Provider {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = Scopes
+	}
+	return &Provider{cfg: cfg, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL возвращает адрес, на который отправляется браузер для входа
+// у провайдера. Код авторизации защищается PKCE (S256).
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {S256Challenge(codeVerifier)},
+		"code_challenge_method": {CodeChallengeMethodS256},
+	}
+	sep := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return discovery.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на токены и возвращает claims
+// проверенного ID-токена. Если email в ID-токене нет, он запрашивается
+// в userinfo.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*IdentityClaims, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	// По умолчанию секрет передается через HTTP Basic (Discovery 1.0, 3);
+	// в теле запроса — только если провайдер поддерживает лишь этот способ
+	methods := discovery.TokenEndpointAuthMethodsSupported
+	basicAuth := supports(methods, "client_secret_basic") || !supports(methods, "client_secret_post")
+	if !basicAuth {
+		form.Set("client_id", p.cfg.ClientID)
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicAuth {
+		// RFC 6749, 2.3.1: идентификатор и секрет кодируются перед Basic
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var tokens struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.doJSON(req, &tokens); err != nil {
+		return nil, err
+	}
+	if tokens.Error != "" {
+		return nil, &ProviderError{Code: tokens.Error, Description: tokens.ErrorDescription}
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, discovery, tokens.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Email == "" && discovery.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		if err := p.fillFromUserInfo(ctx, discovery.UserinfoEndpoint, tokens.AccessToken, claims); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// verifyIDToken проверяет подпись по JWKS провайдера и claims по
+// OpenID Connect Core, 3.1.3.7
+func (p *Provider) verifyIDToken(ctx context.Context, discovery *Discovery, idToken, nonce string) (*IdentityClaims, error) {
+	mapClaims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, mapClaims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := p.lookupKey(ctx, discovery.JWKSURI, kid)
+		if err != nil {
+			return nil, err
+		}
+		if !keyMatchesMethod(key, t.Method) {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id_token: %w", err)
+	}
+
+	if iss, _ := mapClaims["iss"].(string); iss != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: id_token issuer %q does not match %q", iss, p.cfg.Issuer)
+	}
+	if !mapClaims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, errors.New("oidc: id_token is not issued for this client")
+	}
+	if azp, ok := mapClaims["azp"].(string); ok && azp != p.cfg.ClientID {
+		return nil, errors.New("oidc: id_token is authorized for another client")
+	}
+	if _, ok := mapClaims["exp"]; !ok {
+		return nil, errors.New("oidc: id_token has no exp")
+	}
+	if got, _ := mapClaims["nonce"].(string); got != nonce {
+		return nil, errors.New("oidc: id_token nonce does not match")
+	}
+
+	claims := &IdentityClaims{}
+	claims.Subject, _ = mapClaims["sub"].(string)
+	if claims.Subject == "" {
+		return nil, errors.New("oidc: id_token has no sub")
+	}
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.EmailVerified = boolClaim(mapClaims["email_verified"])
+	return claims, nil
+}
+
+// fillFromUserInfo берет email из userinfo. Ответ относится к тому же
+// пользователю, только если sub совпадает (OpenID Connect Core, 5.3.2).
+func (p *Provider) fillFromUserInfo(ctx context.Context, endpoint, accessToken string, claims *IdentityClaims) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var info map[string]interface{}
+	if err := p.doJSON(req, &info); err != nil {
+		return err
+	}
+	if sub, _ := info["sub"].(string); sub != claims.Subject {
+		return errors.New("oidc: userinfo sub does not match id_token")
+	}
+	claims.Email, _ = info["email"].(string)
+	claims.EmailVerified = boolClaim(info["email_verified"])
+	return nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery Discovery
+	if err := p.doJSON(req, &discovery); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	// Документ должен принадлежать настроенному провайдеру (Discovery 1.0, 4.3)
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", discovery.Issuer, p.cfg.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document is incomplete")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (p *Provider) lookupKey(ctx context.Context, jwksURI, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	// Провайдер мог сменить ключ: JWKS перечитывается, но не на каждый
+	// токен с неизвестным kid
+	if time.Since(p.keysFetchedAt) < providerKeysRefreshCooldown {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.doJSON(req, &jwks); err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Ключи неподдерживаемых типов пропускаются: ими подписаны не наши токены
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys, p.keysFetchedAt = keys, time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// doJSON выполняет запрос и разбирает JSON-ответ. Ответ с ошибкой OAuth
+// (400/401 с полем error) тоже разбирается: его проверяет вызывающий.
+func (p *Provider) doJSON(req *http.Request, out interface{}) error {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("%s %s: unexpected status %d", req.Method, req.URL.Redacted(), resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &oauthErr); oauthErr.Error == "" {
+			return fmt.Errorf("%s %s: unexpected status %d", req.Method, req.URL.Redacted(), resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// jsonWebKey — открытый ключ из JWKS провайдера (RFC 7517, 7518, 8037)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return key, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// keyMatchesMethod не дает подменить алгоритм в заголовке токена
+func keyMatchesMethod(key crypto.PublicKey, method jwt.SigningMethod) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodRSA)
+		return ok
+	case *ecdsa.PublicKey:
+		return method.Alg() == jwt.SigningMethodES256.Alg()
+	case ed25519.PublicKey:
+		return method.Alg() == jwt.SigningMethodEdDSA.Alg()
+	}
+	return false
+}
+
+// boolClaim читает булев claim; некоторые провайдеры передают его строкой
+func boolClaim(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+func supports(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// Package position генерирует лексикографические ключи для ручного
+// порядка элементов. Ключ между двумя соседями вычисляется без
+// перенумерации остальных элементов: достаточно обновить одну строку.
+//
+// Ключи состоят из цифр base62 ("0-9A-Za-z" — порядок совпадает с ASCII)
+// и никогда не заканчиваются на '0', поэтому между любыми двумя
+// различными ключами всегда найдется еще один.
+package position
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ErrInvalidRange возвращается, если before не меньше after
+var ErrInvalidRange = errors.New("position: lower bound must be less than upper bound")
+
+// Between возвращает ключ строго между before и after. Пустой before
+// означает "в начало", пустой after — "в конец".
+func Between(before, after string) (string, error) {
+	if !valid(before) || !valid(after) {
+		return "", errors.New("position: invalid key")
+	}
+	if after != "" && before >= after {
+		return "", ErrInvalidRange
+	}
+	return midpoint(before, after), nil
+}
+
+// Spread возвращает n возрастающих ключей, равномерно распределенных
+// по всему диапазону. Используется для перебалансировки списка.
+func Spread(n int) []string {
+	keys := make([]string, 0, n)
+	// Две цифры дают 62*62 позиции, для больших списков добавляем разряды
+	width := 2
+	for capacity := len(digits) * len(digits); capacity <= n; capacity *= len(digits) {
+		width++
+	}
+	total := 1
+	for i := 0; i < width; i++ {
+		total *= len(digits)
+	}
+	step := total / (n + 1)
+	for i := 1; i <= n; i++ {
+		keys = append(keys, strings.TrimRight(encode(i*step, width), "0"))
+	}
+	return keys
+}
+
+// midpoint — середина между a и b, где b == "" означает бесконечность
+func midpoint(a, b string) string {
+	if b != "" {
+		// Общий префикс переносится в результат как есть
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(digits, a[0])
+	}
+	digitB := len(digits)
+	if b != "" {
+		digitB = strings.IndexByte(digits, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(digits[(digitA+digitB)/2])
+	}
+	// Первые цифры соседние: если у b есть продолжение, подходит его первая
+	// цифра, иначе углубляемся в хвост a
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[digitA]) + midpoint(rest, "")
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return '0'
+}
+
+func encode(v, width int) string {
+	buf := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = digits[v%len(digits)]
+		v /= len(digits)
+	}
+	return string(buf)
+}
+
+func valid(key string) bool {
+	if strings.HasSuffix(key, "0") {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package position
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+// checkBetween проверяет, что key — допустимый ключ строго между before и after
+func checkBetween(t *testing.T, before, after, key string) {
+	t.Helper()
+	if !valid(key) || key == "" {
+		t.Fatalf("Between(%q, %q) = %q: not a valid key", before, after, key)
+	}
+	if key <= before || (after != "" && key >= after) {
+		t.Fatalf("Between(%q, %q) = %q: not strictly between", before, after, key)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+	}{
+		{"empty bounds", "", ""},
+		{"start", "", "V"},
+		{"end", "V", ""},
+		{"adjacent digits", "U", "V"},
+		{"adjacent at min digit", "1", "2"},
+		{"adjacent at max digit", "y", "z"},
+		{"before min key", "", "1"},
+		{"before min digits", "", "01"},
+		{"after max digit", "z", ""},
+		{"after max digits", "zzz", ""},
+		{"prefix", "U", "U1"},
+		{"prefix with zeros", "a", "a01"},
+		{"shorter before", "Uz", "V"},
+		{"longer after", "U", "Uzz"},
+		{"longer before", "Uzzz", "V"},
+		{"wide gap", "1", "z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := Between(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("Between(%q, %q): %v", tt.before, tt.after, err)
+			}
+			checkBetween(t, tt.before, tt.after, key)
+		})
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		wantRange     bool
+	}{
+		{"equal", "U", "U", true},
+		{"reversed", "V", "U", true},
+		{"reversed unequal length", "U1", "U", true},
+		{"trailing zero", "U0", "", false},
+		{"invalid digit", "U-", "", false},
+		{"invalid upper bound", "", "V_", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Between(tt.before, tt.after)
+			if err == nil {
+				t.Fatalf("Between(%q, %q) succeeded", tt.before, tt.after)
+			}
+			if errors.Is(err, ErrInvalidRange) != tt.wantRange {
+				t.Fatalf("Between(%q, %q) = %v", tt.before, tt.after, err)
+			}
+		})
+	}
+}
+
+// Многократные вставки в одно место: ключи растут, но порядок сохраняется
+func TestBetweenRepeated(t *testing.T) {
+	t.Run("front", func(t *testing.T) {
+		first := "V"
+		for i := 0; i < 200; i++ {
+			key, err := Between("", first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkBetween(t, "", first, key)
+			first = key
+		}
+	})
+	t.Run("back", func(t *testing.T) {
+		last := "V"
+		for i := 0; i < 200; i++ {
+			key, err := Between(last, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkBetween(t, last, "", key)
+			last = key
+		}
+	})
+	t.Run("same gap", func(t *testing.T) {
+		before, after := "U", "V"
+		for i := 0; i < 200; i++ {
+			key, err := Between(before, after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkBetween(t, before, after, key)
+			after = key
+		}
+	})
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 61, 62 * 62, 62*62 + 1} {
+		keys := Spread(n)
+		if len(keys) != n {
+			t.Fatalf("Spread(%d) returned %d keys", n, len(keys))
+		}
+		if !sort.StringsAreSorted(keys) {
+			t.Fatalf("Spread(%d) keys are not sorted", n)
+		}
+		for i, key := range keys {
+			if !valid(key) || key == "" {
+				t.Fatalf("Spread(%d)[%d] = %q: invalid key", n, i, key)
+			}
+			if i > 0 && key == keys[i-1] {
+				t.Fatalf("Spread(%d) has duplicate key %q", n, key)
+			}
+		}
+	}
+}
@@ -9,6 +9,8 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,31 +23,1831 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Priority int32
+
+const (
+	Priority_PRIORITY_NONE   Priority = 0
+	Priority_PRIORITY_LOW    Priority = 1
+	Priority_PRIORITY_MEDIUM Priority = 2
+	Priority_PRIORITY_HIGH   Priority = 3
+	Priority_PRIORITY_URGENT Priority = 4
+)
+
+// Enum value maps for Priority.
+var (
+	Priority_name = map[int32]string{
+		0: "PRIORITY_NONE",
+		1: "PRIORITY_LOW",
+		2: "PRIORITY_MEDIUM",
+		3: "PRIORITY_HIGH",
+		4: "PRIORITY_URGENT",
+	}
+	Priority_value = map[string]int32{
+		"PRIORITY_NONE":   0,
+		"PRIORITY_LOW":    1,
+		"PRIORITY_MEDIUM": 2,
+		"PRIORITY_HIGH":   3,
+		"PRIORITY_URGENT": 4,
+	}
+)
+
+func (x Priority) Enum() *Priority {
+	p := new(Priority)
+	*p = x
+	return p
+}
+
+func (x Priority) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Priority) Descriptor() protoreflect.EnumDescriptor {
+	return file_todo_proto_enumTypes[0].Descriptor()
+}
+
+func (Priority) Type() protoreflect.EnumType {
+	return &file_todo_proto_enumTypes[0]
+}
+
+func (x Priority) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Priority.Descriptor instead.
+func (Priority) EnumDescriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{0}
+}
+
+// Поле, по которому сортируется список задач
+type TodoSortField int32
+
+const (
+	TodoSortField_TODO_SORT_FIELD_UNSPECIFIED TodoSortField = 0 // по умолчанию — CREATED
+	TodoSortField_TODO_SORT_FIELD_CREATED     TodoSortField = 1
+	TodoSortField_TODO_SORT_FIELD_UPDATED     TodoSortField = 2
+	TodoSortField_TODO_SORT_FIELD_TITLE       TodoSortField = 3
+	TodoSortField_TODO_SORT_FIELD_PRIORITY    TodoSortField = 4
+	TodoSortField_TODO_SORT_FIELD_POSITION    TodoSortField = 5 // ручной порядок
+)
+
+// Enum value maps for TodoSortField.
+var (
+	TodoSortField_name = map[int32]string{
+		0: "TODO_SORT_FIELD_UNSPECIFIED",
+		1: "TODO_SORT_FIELD_CREATED",
+		2: "TODO_SORT_FIELD_UPDATED",
+		3: "TODO_SORT_FIELD_TITLE",
+		4: "TODO_SORT_FIELD_PRIORITY",
+		5: "TODO_SORT_FIELD_POSITION",
+	}
+	TodoSortField_value = map[string]int32{
+		"TODO_SORT_FIELD_UNSPECIFIED": 0,
+		"TODO_SORT_FIELD_CREATED":     1,
+		"TODO_SORT_FIELD_UPDATED":     2,
+		"TODO_SORT_FIELD_TITLE":       3,
+		"TODO_SORT_FIELD_PRIORITY":    4,
+		"TODO_SORT_FIELD_POSITION":    5,
+	}
+)
+
+func (x TodoSortField) Enum() *TodoSortField {
+	p := new(TodoSortField)
+	*p = x
+	return p
+}
+
+func (x TodoSortField) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TodoSortField) Descriptor() protoreflect.EnumDescriptor {
+	return file_todo_proto_enumTypes[1].Descriptor()
+}
+
+func (TodoSortField) Type() protoreflect.EnumType {
+	return &file_todo_proto_enumTypes[1]
+}
+
+func (x TodoSortField) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TodoSortField.Descriptor instead.
+func (TodoSortField) EnumDescriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{1}
+}
+
+// Фильтр по сроку выполнения
+type DueFilter int32
+
+const (
+	DueFilter_DUE_FILTER_UNSPECIFIED DueFilter = 0
+	DueFilter_DUE_FILTER_OVERDUE     DueFilter = 1 // срок прошел, задача не выполнена
+	DueFilter_DUE_FILTER_DUE_TODAY   DueFilter = 2 // срок — сегодня в часовом поясе time_zone
+	DueFilter_DUE_FILTER_DUE_WITHIN  DueFilter = 3 // срок в ближайшие due_within_days дней
+)
+
+// Enum value maps for DueFilter.
+var (
+	DueFilter_name = map[int32]string{
+		0: "DUE_FILTER_UNSPECIFIED",
+		1: "DUE_FILTER_OVERDUE",
+		2: "DUE_FILTER_DUE_TODAY",
+		3: "DUE_FILTER_DUE_WITHIN",
+	}
+	DueFilter_value = map[string]int32{
+		"DUE_FILTER_UNSPECIFIED": 0,
+		"DUE_FILTER_OVERDUE":     1,
+		"DUE_FILTER_DUE_TODAY":   2,
+		"DUE_FILTER_DUE_WITHIN":  3,
+	}
+)
+
+func (x DueFilter) Enum() *DueFilter {
+	p := new(DueFilter)
+	*p = x
+	return p
+}
+
+func (x DueFilter) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DueFilter) Descriptor() protoreflect.EnumDescriptor {
+	return file_todo_proto_enumTypes[2].Descriptor()
+}
+
+func (DueFilter) Type() protoreflect.EnumType {
+	return &file_todo_proto_enumTypes[2]
+}
+
+func (x DueFilter) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DueFilter.Descriptor instead.
+func (DueFilter) EnumDescriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{2}
+}
+
 type TodoItem struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId     string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Title      string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Completed  bool                   `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	Version    uint64                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"` // растет на 1 при каждом изменении
+	DueAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=due_at,json=dueAt,proto3" json:"due_at,omitempty"`
+	RemindAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=remind_at,json=remindAt,proto3" json:"remind_at,omitempty"`
+	ListId     string                 `protobuf:"bytes,8,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	Tags       []string               `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	ParentId   string                 `protobuf:"bytes,10,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"` // пусто — задача верхнего уровня
+	Children   []*TodoItem            `protobuf:"bytes,11,rep,name=children,proto3" json:"children,omitempty"`                 // заполняется только в режиме дерева
+	Recurrence string                 `protobuf:"bytes,12,opt,name=recurrence,proto3" json:"recurrence,omitempty"`             // RRULE, например "FREQ=WEEKLY;BYDAY=MO"
+	TimeZone   string                 `protobuf:"bytes,13,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"` // IANA-зона, в которой раскрывается RRULE
+	// Следующее вхождение, созданное при выполнении повторяющейся задачи
+	NextOccurrence *TodoItem `protobuf:"bytes,14,opt,name=next_occurrence,json=nextOccurrence,proto3" json:"next_occurrence,omitempty"`
+	Priority       Priority  `protobuf:"varint,15,opt,name=priority,proto3,enum=todo.Priority" json:"priority,omitempty"`
+	Position       string    `protobuf:"bytes,16,opt,name=position,proto3" json:"position,omitempty"` // ключ ручного порядка внутри списка
+	// Время удаления; заполняется только для задач из корзины
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TodoItem) Reset() {
+	*x = TodoItem{}
+	mi := &file_todo_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoItem) ProtoMessage() {}
+
+func (x *TodoItem) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoItem.ProtoReflect.Descriptor instead.
+func (*TodoItem) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TodoItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TodoItem) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *TodoItem) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *TodoItem) GetCompleted() bool {
+	if x != nil {
+		return x.Completed
+	}
+	return false
+}
+
+func (x *TodoItem) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *TodoItem) GetDueAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueAt
+	}
+	return nil
+}
+
+func (x *TodoItem) GetRemindAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RemindAt
+	}
+	return nil
+}
+
+func (x *TodoItem) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *TodoItem) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *TodoItem) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *TodoItem) GetChildren() []*TodoItem {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+func (x *TodoItem) GetRecurrence() string {
+	if x != nil {
+		return x.Recurrence
+	}
+	return ""
+}
+
+func (x *TodoItem) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *TodoItem) GetNextOccurrence() *TodoItem {
+	if x != nil {
+		return x.NextOccurrence
+	}
+	return nil
+}
+
+func (x *TodoItem) GetPriority() Priority {
+	if x != nil {
+		return x.Priority
+	}
+	return Priority_PRIORITY_NONE
+}
+
+func (x *TodoItem) GetPosition() string {
+	if x != nil {
+		return x.Position
+	}
+	return ""
+}
+
+func (x *TodoItem) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+// Метка пользователя, например "work" или "urgent"
+type Tag struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tag) Reset() {
+	*x = Tag{}
+	mi := &file_todo_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tag) ProtoMessage() {}
+
+func (x *Tag) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tag.ProtoReflect.Descriptor instead.
+func (*Tag) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Tag) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Tag) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Tag) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Список (проект), в котором лежат задачи. У каждого пользователя
+// есть неудаляемый список Inbox, он создается при первом обращении.
+type TodoList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	IsInbox       bool                   `protobuf:"varint,4,opt,name=is_inbox,json=isInbox,proto3" json:"is_inbox,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TodoList) Reset() {
+	*x = TodoList{}
+	mi := &file_todo_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoList) ProtoMessage() {}
+
+func (x *TodoList) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoList.ProtoReflect.Descriptor instead.
+func (*TodoList) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TodoList) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TodoList) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *TodoList) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TodoList) GetIsInbox() bool {
+	if x != nil {
+		return x.IsInbox
+	}
+	return false
+}
+
+type CreateTodoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	DueAt         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=due_at,json=dueAt,proto3" json:"due_at,omitempty"`
+	RemindAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=remind_at,json=remindAt,proto3" json:"remind_at,omitempty"` // должно быть раньше due_at
+	ListId        string                 `protobuf:"bytes,5,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`       // пусто — Inbox
+	Tags          []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`                         // несуществующие метки создаются
+	ParentId      string                 `protobuf:"bytes,7,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"` // подзадача попадает в список родителя
+	Recurrence    string                 `protobuf:"bytes,8,opt,name=recurrence,proto3" json:"recurrence,omitempty"`             // RRULE; требует due_at
+	TimeZone      string                 `protobuf:"bytes,9,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"` // по умолчанию UTC
+	Priority      Priority               `protobuf:"varint,10,opt,name=priority,proto3,enum=todo.Priority" json:"priority,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTodoRequest) Reset() {
+	*x = CreateTodoRequest{}
+	mi := &file_todo_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTodoRequest) ProtoMessage() {}
+
+func (x *CreateTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTodoRequest.ProtoReflect.Descriptor instead.
+func (*CreateTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateTodoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetDueAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueAt
+	}
+	return nil
+}
+
+func (x *CreateTodoRequest) GetRemindAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RemindAt
+	}
+	return nil
+}
+
+func (x *CreateTodoRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateTodoRequest) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetRecurrence() string {
+	if x != nil {
+		return x.Recurrence
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetPriority() Priority {
+	if x != nil {
+		return x.Priority
+	}
+	return Priority_PRIORITY_NONE
+}
+
+type GetTodoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTodoRequest) Reset() {
+	*x = GetTodoRequest{}
+	mi := &file_todo_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTodoRequest) ProtoMessage() {}
+
+func (x *GetTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTodoRequest.ProtoReflect.Descriptor instead.
+func (*GetTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetTodoRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetTodoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetTodosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`               // 0 — размер страницы по умолчанию
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`             // непрозрачный курсор из next_page_token
+	Completed     *bool                  `protobuf:"varint,4,opt,name=completed,proto3,oneof" json:"completed,omitempty"`                       // не задан — без фильтра
+	TitleContains string                 `protobuf:"bytes,5,opt,name=title_contains,json=titleContains,proto3" json:"title_contains,omitempty"` // поиск подстроки без учета регистра
+	SortBy        TodoSortField          `protobuf:"varint,6,opt,name=sort_by,json=sortBy,proto3,enum=todo.TodoSortField" json:"sort_by,omitempty"`
+	Descending    bool                   `protobuf:"varint,7,opt,name=descending,proto3" json:"descending,omitempty"`
+	DueFilter     DueFilter              `protobuf:"varint,8,opt,name=due_filter,json=dueFilter,proto3,enum=todo.DueFilter" json:"due_filter,omitempty"`
+	DueWithinDays int32                  `protobuf:"varint,9,opt,name=due_within_days,json=dueWithinDays,proto3" json:"due_within_days,omitempty"` // для DUE_FILTER_DUE_WITHIN
+	TimeZone      string                 `protobuf:"bytes,10,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`                  // IANA, например "Europe/Moscow"; по умолчанию UTC
+	ListId        string                 `protobuf:"bytes,11,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`                        // пусто — задачи из всех списков
+	AnyTags       []string               `protobuf:"bytes,12,rep,name=any_tags,json=anyTags,proto3" json:"any_tags,omitempty"`                     // есть хотя бы одна из меток
+	AllTags       []string               `protobuf:"bytes,13,rep,name=all_tags,json=allTags,proto3" json:"all_tags,omitempty"`                     // есть все перечисленные метки
+	// Дерево: страница состоит из задач верхнего уровня, подзадачи
+	// приходят в children. Иначе — плоский список всех подходящих задач.
+	AsTree        bool `protobuf:"varint,14,opt,name=as_tree,json=asTree,proto3" json:"as_tree,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTodosRequest) Reset() {
+	*x = GetTodosRequest{}
+	mi := &file_todo_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTodosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTodosRequest) ProtoMessage() {}
+
+func (x *GetTodosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTodosRequest.ProtoReflect.Descriptor instead.
+func (*GetTodosRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetTodosRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetTodosRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *GetTodosRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *GetTodosRequest) GetCompleted() bool {
+	if x != nil && x.Completed != nil {
+		return *x.Completed
+	}
+	return false
+}
+
+func (x *GetTodosRequest) GetTitleContains() string {
+	if x != nil {
+		return x.TitleContains
+	}
+	return ""
+}
+
+func (x *GetTodosRequest) GetSortBy() TodoSortField {
+	if x != nil {
+		return x.SortBy
+	}
+	return TodoSortField_TODO_SORT_FIELD_UNSPECIFIED
+}
+
+func (x *GetTodosRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *GetTodosRequest) GetDueFilter() DueFilter {
+	if x != nil {
+		return x.DueFilter
+	}
+	return DueFilter_DUE_FILTER_UNSPECIFIED
+}
+
+func (x *GetTodosRequest) GetDueWithinDays() int32 {
+	if x != nil {
+		return x.DueWithinDays
+	}
+	return 0
+}
+
+func (x *GetTodosRequest) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *GetTodosRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *GetTodosRequest) GetAnyTags() []string {
+	if x != nil {
+		return x.AnyTags
+	}
+	return nil
+}
+
+func (x *GetTodosRequest) GetAllTags() []string {
+	if x != nil {
+		return x.AllTags
+	}
+	return nil
+}
+
+func (x *GetTodosRequest) GetAsTree() bool {
+	if x != nil {
+		return x.AsTree
+	}
+	return false
+}
+
+type GetTodosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Todos         []*TodoItem            `protobuf:"bytes,1,rep,name=todos,proto3" json:"todos,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // пустой, если страниц больше нет
+	TotalCount    int64                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`           // количество задач с учетом фильтров
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTodosResponse) Reset() {
+	*x = GetTodosResponse{}
+	mi := &file_todo_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTodosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTodosResponse) ProtoMessage() {}
+
+func (x *GetTodosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTodosResponse.ProtoReflect.Descriptor instead.
+func (*GetTodosResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetTodosResponse) GetTodos() []*TodoItem {
+	if x != nil {
+		return x.Todos
+	}
+	return nil
+}
+
+func (x *GetTodosResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *GetTodosResponse) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+type UpdateTodoRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId    string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Title     string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Completed bool                   `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	// Какие поля обновлять ("title", "completed", "due_at", "remind_at", "tags",
+	// "parent_id", "recurrence", "time_zone", "priority").
+	// Пустая маска — полная замена всех полей, кроме parent_id.
+	UpdateMask      *fieldmaskpb.FieldMask `protobuf:"bytes,5,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedVersion uint64                 `protobuf:"varint,6,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 0 — без проверки версии
+	DueAt           *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=due_at,json=dueAt,proto3" json:"due_at,omitempty"`
+	RemindAt        *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=remind_at,json=remindAt,proto3" json:"remind_at,omitempty"`
+	Tags            []string               `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	ParentId        string                 `protobuf:"bytes,10,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	// При completed = true отметить выполненными и все подзадачи
+	CompleteSubtasks bool     `protobuf:"varint,11,opt,name=complete_subtasks,json=completeSubtasks,proto3" json:"complete_subtasks,omitempty"`
+	Recurrence       string   `protobuf:"bytes,12,opt,name=recurrence,proto3" json:"recurrence,omitempty"`
+	TimeZone         string   `protobuf:"bytes,13,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`
+	Priority         Priority `protobuf:"varint,14,opt,name=priority,proto3,enum=todo.Priority" json:"priority,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateTodoRequest) Reset() {
+	*x = UpdateTodoRequest{}
+	mi := &file_todo_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTodoRequest) ProtoMessage() {}
+
+func (x *UpdateTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTodoRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateTodoRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetCompleted() bool {
+	if x != nil {
+		return x.Completed
+	}
+	return false
+}
+
+func (x *UpdateTodoRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateTodoRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *UpdateTodoRequest) GetDueAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueAt
+	}
+	return nil
+}
+
+func (x *UpdateTodoRequest) GetRemindAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RemindAt
+	}
+	return nil
+}
+
+func (x *UpdateTodoRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdateTodoRequest) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetCompleteSubtasks() bool {
+	if x != nil {
+		return x.CompleteSubtasks
+	}
+	return false
+}
+
+func (x *UpdateTodoRequest) GetRecurrence() string {
+	if x != nil {
+		return x.Recurrence
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetPriority() Priority {
+	if x != nil {
+		return x.Priority
+	}
+	return Priority_PRIORITY_NONE
+}
+
+type DeleteTodoRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId          string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ExpectedVersion uint64                 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 0 — без проверки версии
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteTodoRequest) Reset() {
+	*x = DeleteTodoRequest{}
+	mi := &file_todo_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTodoRequest) ProtoMessage() {}
+
+func (x *DeleteTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTodoRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteTodoRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteTodoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteTodoRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteTodoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Todo          *TodoItem              `protobuf:"bytes,2,opt,name=todo,proto3" json:"todo,omitempty"` // удаленная задача, чтобы клиент мог предложить отмену
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTodoResponse) Reset() {
+	*x = DeleteTodoResponse{}
+	mi := &file_todo_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTodoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTodoResponse) ProtoMessage() {}
+
+func (x *DeleteTodoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTodoResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTodoResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteTodoResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DeleteTodoResponse) GetTodo() *TodoItem {
+	if x != nil {
+		return x.Todo
+	}
+	return nil
+}
+
+// Полнотекстовый поиск: каждое слово запроса ищется как префикс,
+// задача должна содержать все слова
+type SearchTodosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Query         string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // сколько лучших совпадений вернуть
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchTodosRequest) Reset() {
+	*x = SearchTodosRequest{}
+	mi := &file_todo_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchTodosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchTodosRequest) ProtoMessage() {}
+
+func (x *SearchTodosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchTodosRequest.ProtoReflect.Descriptor instead.
+func (*SearchTodosRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SearchTodosRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SearchTodosRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchTodosRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type SearchTodosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hits          []*SearchHit           `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"` // от более релевантных к менее
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchTodosResponse) Reset() {
+	*x = SearchTodosResponse{}
+	mi := &file_todo_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchTodosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchTodosResponse) ProtoMessage() {}
+
+func (x *SearchTodosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchTodosResponse.ProtoReflect.Descriptor instead.
+func (*SearchTodosResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SearchTodosResponse) GetHits() []*SearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+type SearchHit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Todo          *TodoItem              `protobuf:"bytes,1,opt,name=todo,proto3" json:"todo,omitempty"`
+	Rank          float64                `protobuf:"fixed64,2,opt,name=rank,proto3" json:"rank,omitempty"`
+	Snippet       string                 `protobuf:"bytes,3,opt,name=snippet,proto3" json:"snippet,omitempty"` // название (HTML-экранированное) с найденными словами в <b>...</b>
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_todo_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHit) ProtoMessage() {}
+
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SearchHit) GetTodo() *TodoItem {
+	if x != nil {
+		return x.Todo
+	}
+	return nil
+}
+
+func (x *SearchHit) GetRank() float64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *SearchHit) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+type ListTrashRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTrashRequest) Reset() {
+	*x = ListTrashRequest{}
+	mi := &file_todo_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTrashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTrashRequest) ProtoMessage() {}
+
+func (x *ListTrashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTrashRequest.ProtoReflect.Descriptor instead.
+func (*ListTrashRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListTrashRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListTrashRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListTrashRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// Задачи, удаленные вместе с родителем, в список не попадают:
+// они восстанавливаются вместе с ним
+type ListTrashResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Todos         []*TodoItem            `protobuf:"bytes,1,rep,name=todos,proto3" json:"todos,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTrashResponse) Reset() {
+	*x = ListTrashResponse{}
+	mi := &file_todo_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTrashResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTrashResponse) ProtoMessage() {}
+
+func (x *ListTrashResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTrashResponse.ProtoReflect.Descriptor instead.
+func (*ListTrashResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListTrashResponse) GetTodos() []*TodoItem {
+	if x != nil {
+		return x.Todos
+	}
+	return nil
+}
+
+func (x *ListTrashResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type RestoreTodoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreTodoRequest) Reset() {
+	*x = RestoreTodoRequest{}
+	mi := &file_todo_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreTodoRequest) ProtoMessage() {}
+
+func (x *RestoreTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreTodoRequest.ProtoReflect.Descriptor instead.
+func (*RestoreTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RestoreTodoRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RestoreTodoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type PurgeTodoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeTodoRequest) Reset() {
+	*x = PurgeTodoRequest{}
+	mi := &file_todo_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeTodoRequest) ProtoMessage() {}
+
+func (x *PurgeTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeTodoRequest.ProtoReflect.Descriptor instead.
+func (*PurgeTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PurgeTodoRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PurgeTodoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type PurgeTodoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeTodoResponse) Reset() {
+	*x = PurgeTodoResponse{}
+	mi := &file_todo_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeTodoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeTodoResponse) ProtoMessage() {}
+
+func (x *PurgeTodoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeTodoResponse.ProtoReflect.Descriptor instead.
+func (*PurgeTodoResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PurgeTodoResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type CreateListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateListRequest) Reset() {
+	*x = CreateListRequest{}
+	mi := &file_todo_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateListRequest) ProtoMessage() {}
+
+func (x *CreateListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateListRequest.ProtoReflect.Descriptor instead.
+func (*CreateListRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CreateListRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateListRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetListsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetListsRequest) Reset() {
+	*x = GetListsRequest{}
+	mi := &file_todo_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetListsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetListsRequest) ProtoMessage() {}
+
+func (x *GetListsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetListsRequest.ProtoReflect.Descriptor instead.
+func (*GetListsRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetListsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetListsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lists         []*TodoList            `protobuf:"bytes,1,rep,name=lists,proto3" json:"lists,omitempty"` // Inbox всегда первый
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetListsResponse) Reset() {
+	*x = GetListsResponse{}
+	mi := &file_todo_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetListsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetListsResponse) ProtoMessage() {}
+
+func (x *GetListsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetListsResponse.ProtoReflect.Descriptor instead.
+func (*GetListsResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetListsResponse) GetLists() []*TodoList {
+	if x != nil {
+		return x.Lists
+	}
+	return nil
+}
+
+type UpdateListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateListRequest) Reset() {
+	*x = UpdateListRequest{}
+	mi := &file_todo_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateListRequest) ProtoMessage() {}
+
+func (x *UpdateListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateListRequest.ProtoReflect.Descriptor instead.
+func (*UpdateListRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateListRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateListRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateListRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteListRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// true — удалить задачи списка вместе с ним, false — перенести их в Inbox
+	Cascade       bool `protobuf:"varint,3,opt,name=cascade,proto3" json:"cascade,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteListRequest) Reset() {
+	*x = DeleteListRequest{}
+	mi := &file_todo_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteListRequest) ProtoMessage() {}
+
+func (x *DeleteListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteListRequest.ProtoReflect.Descriptor instead.
+func (*DeleteListRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteListRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteListRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteListRequest) GetCascade() bool {
+	if x != nil {
+		return x.Cascade
+	}
+	return false
+}
+
+type DeleteListResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
-	Completed     bool                   `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TodoItem) Reset() {
-	*x = TodoItem{}
-	mi := &file_todo_proto_msgTypes[0]
+func (x *DeleteListResponse) Reset() {
+	*x = DeleteListResponse{}
+	mi := &file_todo_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TodoItem) String() string {
+func (x *DeleteListResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TodoItem) ProtoMessage() {}
+func (*DeleteListResponse) ProtoMessage() {}
 
-func (x *TodoItem) ProtoReflect() protoreflect.Message {
-	mi := &file_todo_proto_msgTypes[0]
+func (x *DeleteListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,62 +1858,188 @@ func (x *TodoItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TodoItem.ProtoReflect.Descriptor instead.
-func (*TodoItem) Descriptor() ([]byte, []int) {
-	return file_todo_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use DeleteListResponse.ProtoReflect.Descriptor instead.
+func (*DeleteListResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *TodoItem) GetId() string {
+func (x *DeleteListResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Ставит задачу между двумя соседями. after_id — задача, которая должна
+// оказаться перед ней, before_id — после нее. Пустой after_id — в начало
+// списка, пустой before_id — в конец.
+type ReorderTodoRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId          string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AfterId         string                 `protobuf:"bytes,3,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	BeforeId        string                 `protobuf:"bytes,4,opt,name=before_id,json=beforeId,proto3" json:"before_id,omitempty"`
+	ExpectedVersion uint64                 `protobuf:"varint,5,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ReorderTodoRequest) Reset() {
+	*x = ReorderTodoRequest{}
+	mi := &file_todo_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReorderTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReorderTodoRequest) ProtoMessage() {}
+
+func (x *ReorderTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReorderTodoRequest.ProtoReflect.Descriptor instead.
+func (*ReorderTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ReorderTodoRequest) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *TodoItem) GetUserId() string {
+func (x *ReorderTodoRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *TodoItem) GetTitle() string {
+func (x *ReorderTodoRequest) GetAfterId() string {
 	if x != nil {
-		return x.Title
+		return x.AfterId
 	}
 	return ""
 }
 
-func (x *TodoItem) GetCompleted() bool {
+func (x *ReorderTodoRequest) GetBeforeId() string {
 	if x != nil {
-		return x.Completed
+		return x.BeforeId
 	}
-	return false
+	return ""
 }
 
-type CreateTodoRequest struct {
+func (x *ReorderTodoRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type MoveTodoRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId          string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ListId          string                 `protobuf:"bytes,3,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`                             // пусто — Inbox
+	ExpectedVersion uint64                 `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 0 — без проверки версии
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *MoveTodoRequest) Reset() {
+	*x = MoveTodoRequest{}
+	mi := &file_todo_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveTodoRequest) ProtoMessage() {}
+
+func (x *MoveTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveTodoRequest.ProtoReflect.Descriptor instead.
+func (*MoveTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *MoveTodoRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *MoveTodoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *MoveTodoRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *MoveTodoRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type CreateTagRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateTodoRequest) Reset() {
-	*x = CreateTodoRequest{}
-	mi := &file_todo_proto_msgTypes[1]
+func (x *CreateTagRequest) Reset() {
+	*x = CreateTagRequest{}
+	mi := &file_todo_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateTodoRequest) String() string {
+func (x *CreateTagRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateTodoRequest) ProtoMessage() {}
+func (*CreateTagRequest) ProtoMessage() {}
 
-func (x *CreateTodoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_todo_proto_msgTypes[1]
+func (x *CreateTagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -122,47 +2050,47 @@ func (x *CreateTodoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateTodoRequest.ProtoReflect.Descriptor instead.
-func (*CreateTodoRequest) Descriptor() ([]byte, []int) {
-	return file_todo_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use CreateTagRequest.ProtoReflect.Descriptor instead.
+func (*CreateTagRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *CreateTodoRequest) GetUserId() string {
+func (x *CreateTagRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *CreateTodoRequest) GetTitle() string {
+func (x *CreateTagRequest) GetName() string {
 	if x != nil {
-		return x.Title
+		return x.Name
 	}
 	return ""
 }
 
-type GetTodosRequest struct {
+type GetTagsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetTodosRequest) Reset() {
-	*x = GetTodosRequest{}
-	mi := &file_todo_proto_msgTypes[2]
+func (x *GetTagsRequest) Reset() {
+	*x = GetTagsRequest{}
+	mi := &file_todo_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetTodosRequest) String() string {
+func (x *GetTagsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetTodosRequest) ProtoMessage() {}
+func (*GetTagsRequest) ProtoMessage() {}
 
-func (x *GetTodosRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_todo_proto_msgTypes[2]
+func (x *GetTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -173,40 +2101,40 @@ func (x *GetTodosRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetTodosRequest.ProtoReflect.Descriptor instead.
-func (*GetTodosRequest) Descriptor() ([]byte, []int) {
-	return file_todo_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use GetTagsRequest.ProtoReflect.Descriptor instead.
+func (*GetTagsRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *GetTodosRequest) GetUserId() string {
+func (x *GetTagsRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-type GetTodosResponse struct {
+type GetTagsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Todos         []*TodoItem            `protobuf:"bytes,1,rep,name=todos,proto3" json:"todos,omitempty"`
+	Tags          []*Tag                 `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetTodosResponse) Reset() {
-	*x = GetTodosResponse{}
-	mi := &file_todo_proto_msgTypes[3]
+func (x *GetTagsResponse) Reset() {
+	*x = GetTagsResponse{}
+	mi := &file_todo_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetTodosResponse) String() string {
+func (x *GetTagsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetTodosResponse) ProtoMessage() {}
+func (*GetTagsResponse) ProtoMessage() {}
 
-func (x *GetTodosResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_todo_proto_msgTypes[3]
+func (x *GetTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -217,43 +2145,42 @@ func (x *GetTodosResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetTodosResponse.ProtoReflect.Descriptor instead.
-func (*GetTodosResponse) Descriptor() ([]byte, []int) {
-	return file_todo_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use GetTagsResponse.ProtoReflect.Descriptor instead.
+func (*GetTagsResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *GetTodosResponse) GetTodos() []*TodoItem {
+func (x *GetTagsResponse) GetTags() []*Tag {
 	if x != nil {
-		return x.Todos
+		return x.Tags
 	}
 	return nil
 }
 
-type UpdateTodoRequest struct {
+type UpdateTagRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
-	Completed     bool                   `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateTodoRequest) Reset() {
-	*x = UpdateTodoRequest{}
-	mi := &file_todo_proto_msgTypes[4]
+func (x *UpdateTagRequest) Reset() {
+	*x = UpdateTagRequest{}
+	mi := &file_todo_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateTodoRequest) String() string {
+func (x *UpdateTagRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateTodoRequest) ProtoMessage() {}
+func (*UpdateTagRequest) ProtoMessage() {}
 
-func (x *UpdateTodoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_todo_proto_msgTypes[4]
+func (x *UpdateTagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -264,40 +2191,33 @@ func (x *UpdateTodoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateTodoRequest.ProtoReflect.Descriptor instead.
-func (*UpdateTodoRequest) Descriptor() ([]byte, []int) {
-	return file_todo_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use UpdateTagRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTagRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *UpdateTodoRequest) GetId() string {
+func (x *UpdateTagRequest) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *UpdateTodoRequest) GetUserId() string {
+func (x *UpdateTagRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *UpdateTodoRequest) GetTitle() string {
+func (x *UpdateTagRequest) GetName() string {
 	if x != nil {
-		return x.Title
+		return x.Name
 	}
 	return ""
 }
 
-func (x *UpdateTodoRequest) GetCompleted() bool {
-	if x != nil {
-		return x.Completed
-	}
-	return false
-}
-
-type DeleteTodoRequest struct {
+type DeleteTagRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -305,21 +2225,21 @@ type DeleteTodoRequest struct {
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteTodoRequest) Reset() {
-	*x = DeleteTodoRequest{}
-	mi := &file_todo_proto_msgTypes[5]
+func (x *DeleteTagRequest) Reset() {
+	*x = DeleteTagRequest{}
+	mi := &file_todo_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteTodoRequest) String() string {
+func (x *DeleteTagRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteTodoRequest) ProtoMessage() {}
+func (*DeleteTagRequest) ProtoMessage() {}
 
-func (x *DeleteTodoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_todo_proto_msgTypes[5]
+func (x *DeleteTagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -330,47 +2250,47 @@ func (x *DeleteTodoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteTodoRequest.ProtoReflect.Descriptor instead.
-func (*DeleteTodoRequest) Descriptor() ([]byte, []int) {
-	return file_todo_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use DeleteTagRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTagRequest) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *DeleteTodoRequest) GetId() string {
+func (x *DeleteTagRequest) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *DeleteTodoRequest) GetUserId() string {
+func (x *DeleteTagRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-type DeleteTodoResponse struct {
+type DeleteTagResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteTodoResponse) Reset() {
-	*x = DeleteTodoResponse{}
-	mi := &file_todo_proto_msgTypes[6]
+func (x *DeleteTagResponse) Reset() {
+	*x = DeleteTagResponse{}
+	mi := &file_todo_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteTodoResponse) String() string {
+func (x *DeleteTagResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteTodoResponse) ProtoMessage() {}
+func (*DeleteTagResponse) ProtoMessage() {}
 
-func (x *DeleteTodoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_todo_proto_msgTypes[6]
+func (x *DeleteTagResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todo_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -381,12 +2301,12 @@ func (x *DeleteTodoResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteTodoResponse.ProtoReflect.Descriptor instead.
-func (*DeleteTodoResponse) Descriptor() ([]byte, []int) {
-	return file_todo_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use DeleteTagResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTagResponse) Descriptor() ([]byte, []int) {
+	return file_todo_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *DeleteTodoResponse) GetMessage() string {
+func (x *DeleteTagResponse) GetMessage() string {
 	if x != nil {
 		return x.Message
 	}
@@ -399,37 +2319,222 @@ const file_todo_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
 	"todo.proto\x12\x04todo\x1a\n" +
-	"user.proto\"g\n" +
+	"user.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdc\x04\n" +
 	"\bTodoItem\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
 	"\x05title\x18\x03 \x01(\tR\x05title\x12\x1c\n" +
-	"\tcompleted\x18\x04 \x01(\bR\tcompleted\"B\n" +
+	"\tcompleted\x18\x04 \x01(\bR\tcompleted\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x04R\aversion\x121\n" +
+	"\x06due_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x05dueAt\x127\n" +
+	"\tremind_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\bremindAt\x12\x17\n" +
+	"\alist_id\x18\b \x01(\tR\x06listId\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\x12\x1b\n" +
+	"\tparent_id\x18\n" +
+	" \x01(\tR\bparentId\x12*\n" +
+	"\bchildren\x18\v \x03(\v2\x0e.todo.TodoItemR\bchildren\x12\x1e\n" +
+	"\n" +
+	"recurrence\x18\f \x01(\tR\n" +
+	"recurrence\x12\x1b\n" +
+	"\ttime_zone\x18\r \x01(\tR\btimeZone\x127\n" +
+	"\x0fnext_occurrence\x18\x0e \x01(\v2\x0e.todo.TodoItemR\x0enextOccurrence\x12*\n" +
+	"\bpriority\x18\x0f \x01(\x0e2\x0e.todo.PriorityR\bpriority\x12\x1a\n" +
+	"\bposition\x18\x10 \x01(\tR\bposition\x129\n" +
+	"\n" +
+	"deleted_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"B\n" +
+	"\x03Tag\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"b\n" +
+	"\bTodoList\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x19\n" +
+	"\bis_inbox\x18\x04 \x01(\bR\aisInbox\"\xe1\x02\n" +
 	"\x11CreateTodoRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
-	"\x05title\x18\x02 \x01(\tR\x05title\"*\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x121\n" +
+	"\x06due_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05dueAt\x127\n" +
+	"\tremind_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bremindAt\x12\x17\n" +
+	"\alist_id\x18\x05 \x01(\tR\x06listId\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12\x1b\n" +
+	"\tparent_id\x18\a \x01(\tR\bparentId\x12\x1e\n" +
+	"\n" +
+	"recurrence\x18\b \x01(\tR\n" +
+	"recurrence\x12\x1b\n" +
+	"\ttime_zone\x18\t \x01(\tR\btimeZone\x12*\n" +
+	"\bpriority\x18\n" +
+	" \x01(\x0e2\x0e.todo.PriorityR\bpriority\"9\n" +
+	"\x0eGetTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"\xe9\x03\n" +
 	"\x0fGetTodosRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\"8\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\x12!\n" +
+	"\tcompleted\x18\x04 \x01(\bH\x00R\tcompleted\x88\x01\x01\x12%\n" +
+	"\x0etitle_contains\x18\x05 \x01(\tR\rtitleContains\x12,\n" +
+	"\asort_by\x18\x06 \x01(\x0e2\x13.todo.TodoSortFieldR\x06sortBy\x12\x1e\n" +
+	"\n" +
+	"descending\x18\a \x01(\bR\n" +
+	"descending\x12.\n" +
+	"\n" +
+	"due_filter\x18\b \x01(\x0e2\x0f.todo.DueFilterR\tdueFilter\x12&\n" +
+	"\x0fdue_within_days\x18\t \x01(\x05R\rdueWithinDays\x12\x1b\n" +
+	"\ttime_zone\x18\n" +
+	" \x01(\tR\btimeZone\x12\x17\n" +
+	"\alist_id\x18\v \x01(\tR\x06listId\x12\x19\n" +
+	"\bany_tags\x18\f \x03(\tR\aanyTags\x12\x19\n" +
+	"\ball_tags\x18\r \x03(\tR\aallTags\x12\x17\n" +
+	"\aas_tree\x18\x0e \x01(\bR\x06asTreeB\f\n" +
+	"\n" +
+	"_completed\"\x81\x01\n" +
 	"\x10GetTodosResponse\x12$\n" +
-	"\x05todos\x18\x01 \x03(\v2\x0e.todo.TodoItemR\x05todos\"p\n" +
+	"\x05todos\x18\x01 \x03(\v2\x0e.todo.TodoItemR\x05todos\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x03R\n" +
+	"totalCount\"\x8b\x04\n" +
 	"\x11UpdateTodoRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
 	"\x05title\x18\x03 \x01(\tR\x05title\x12\x1c\n" +
-	"\tcompleted\x18\x04 \x01(\bR\tcompleted\"<\n" +
+	"\tcompleted\x18\x04 \x01(\bR\tcompleted\x12;\n" +
+	"\vupdate_mask\x18\x05 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12)\n" +
+	"\x10expected_version\x18\x06 \x01(\x04R\x0fexpectedVersion\x121\n" +
+	"\x06due_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x05dueAt\x127\n" +
+	"\tremind_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\bremindAt\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\x12\x1b\n" +
+	"\tparent_id\x18\n" +
+	" \x01(\tR\bparentId\x12+\n" +
+	"\x11complete_subtasks\x18\v \x01(\bR\x10completeSubtasks\x12\x1e\n" +
+	"\n" +
+	"recurrence\x18\f \x01(\tR\n" +
+	"recurrence\x12\x1b\n" +
+	"\ttime_zone\x18\r \x01(\tR\btimeZone\x12*\n" +
+	"\bpriority\x18\x0e \x01(\x0e2\x0e.todo.PriorityR\bpriority\"g\n" +
 	"\x11DeleteTodoRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\".\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x04R\x0fexpectedVersion\"R\n" +
 	"\x12DeleteTodoResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage2\xf7\x01\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\"\n" +
+	"\x04todo\x18\x02 \x01(\v2\x0e.todo.TodoItemR\x04todo\"`\n" +
+	"\x12SearchTodosRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\":\n" +
+	"\x13SearchTodosResponse\x12#\n" +
+	"\x04hits\x18\x01 \x03(\v2\x0f.todo.SearchHitR\x04hits\"]\n" +
+	"\tSearchHit\x12\"\n" +
+	"\x04todo\x18\x01 \x01(\v2\x0e.todo.TodoItemR\x04todo\x12\x12\n" +
+	"\x04rank\x18\x02 \x01(\x01R\x04rank\x12\x18\n" +
+	"\asnippet\x18\x03 \x01(\tR\asnippet\"g\n" +
+	"\x10ListTrashRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"a\n" +
+	"\x11ListTrashResponse\x12$\n" +
+	"\x05todos\x18\x01 \x03(\v2\x0e.todo.TodoItemR\x05todos\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"=\n" +
+	"\x12RestoreTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\";\n" +
+	"\x10PurgeTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"-\n" +
+	"\x11PurgeTodoResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"@\n" +
+	"\x11CreateListRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"*\n" +
+	"\x0fGetListsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"8\n" +
+	"\x10GetListsResponse\x12$\n" +
+	"\x05lists\x18\x01 \x03(\v2\x0e.todo.TodoListR\x05lists\"P\n" +
+	"\x11UpdateListRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"V\n" +
+	"\x11DeleteListRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x18\n" +
+	"\acascade\x18\x03 \x01(\bR\acascade\".\n" +
+	"\x12DeleteListResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xa0\x01\n" +
+	"\x12ReorderTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x19\n" +
+	"\bafter_id\x18\x03 \x01(\tR\aafterId\x12\x1b\n" +
+	"\tbefore_id\x18\x04 \x01(\tR\bbeforeId\x12)\n" +
+	"\x10expected_version\x18\x05 \x01(\x04R\x0fexpectedVersion\"~\n" +
+	"\x0fMoveTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
+	"\alist_id\x18\x03 \x01(\tR\x06listId\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\x04R\x0fexpectedVersion\"?\n" +
+	"\x10CreateTagRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\")\n" +
+	"\x0eGetTagsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"0\n" +
+	"\x0fGetTagsResponse\x12\x1d\n" +
+	"\x04tags\x18\x01 \x03(\v2\t.todo.TagR\x04tags\"O\n" +
+	"\x10UpdateTagRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\";\n" +
+	"\x10DeleteTagRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"-\n" +
+	"\x11DeleteTagResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage*l\n" +
+	"\bPriority\x12\x11\n" +
+	"\rPRIORITY_NONE\x10\x00\x12\x10\n" +
+	"\fPRIORITY_LOW\x10\x01\x12\x13\n" +
+	"\x0fPRIORITY_MEDIUM\x10\x02\x12\x11\n" +
+	"\rPRIORITY_HIGH\x10\x03\x12\x13\n" +
+	"\x0fPRIORITY_URGENT\x10\x04*\xc1\x01\n" +
+	"\rTodoSortField\x12\x1f\n" +
+	"\x1bTODO_SORT_FIELD_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17TODO_SORT_FIELD_CREATED\x10\x01\x12\x1b\n" +
+	"\x17TODO_SORT_FIELD_UPDATED\x10\x02\x12\x19\n" +
+	"\x15TODO_SORT_FIELD_TITLE\x10\x03\x12\x1c\n" +
+	"\x18TODO_SORT_FIELD_PRIORITY\x10\x04\x12\x1c\n" +
+	"\x18TODO_SORT_FIELD_POSITION\x10\x05*t\n" +
+	"\tDueFilter\x12\x1a\n" +
+	"\x16DUE_FILTER_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12DUE_FILTER_OVERDUE\x10\x01\x12\x18\n" +
+	"\x14DUE_FILTER_DUE_TODAY\x10\x02\x12\x19\n" +
+	"\x15DUE_FILTER_DUE_WITHIN\x10\x032\xcd\b\n" +
 	"\vTodoService\x125\n" +
 	"\n" +
 	"CreateTodo\x12\x17.todo.CreateTodoRequest\x1a\x0e.todo.TodoItem\x129\n" +
-	"\bGetTodos\x12\x15.todo.GetTodosRequest\x1a\x16.todo.GetTodosResponse\x125\n" +
+	"\bGetTodos\x12\x15.todo.GetTodosRequest\x1a\x16.todo.GetTodosResponse\x12/\n" +
+	"\aGetTodo\x12\x14.todo.GetTodoRequest\x1a\x0e.todo.TodoItem\x125\n" +
 	"\n" +
 	"UpdateTodo\x12\x17.todo.UpdateTodoRequest\x1a\x0e.todo.TodoItem\x12?\n" +
 	"\n" +
-	"DeleteTodo\x12\x17.todo.DeleteTodoRequest\x1a\x18.todo.DeleteTodoResponseB\tZ\a.;protob\x06proto3"
+	"DeleteTodo\x12\x17.todo.DeleteTodoRequest\x1a\x18.todo.DeleteTodoResponse\x12B\n" +
+	"\vSearchTodos\x12\x18.todo.SearchTodosRequest\x1a\x19.todo.SearchTodosResponse\x12<\n" +
+	"\tListTrash\x12\x16.todo.ListTrashRequest\x1a\x17.todo.ListTrashResponse\x127\n" +
+	"\vRestoreTodo\x12\x18.todo.RestoreTodoRequest\x1a\x0e.todo.TodoItem\x12<\n" +
+	"\tPurgeTodo\x12\x16.todo.PurgeTodoRequest\x1a\x17.todo.PurgeTodoResponse\x125\n" +
+	"\n" +
+	"CreateList\x12\x17.todo.CreateListRequest\x1a\x0e.todo.TodoList\x129\n" +
+	"\bGetLists\x12\x15.todo.GetListsRequest\x1a\x16.todo.GetListsResponse\x125\n" +
+	"\n" +
+	"UpdateList\x12\x17.todo.UpdateListRequest\x1a\x0e.todo.TodoList\x12?\n" +
+	"\n" +
+	"DeleteList\x12\x17.todo.DeleteListRequest\x1a\x18.todo.DeleteListResponse\x121\n" +
+	"\bMoveTodo\x12\x15.todo.MoveTodoRequest\x1a\x0e.todo.TodoItem\x127\n" +
+	"\vReorderTodo\x12\x18.todo.ReorderTodoRequest\x1a\x0e.todo.TodoItem\x12.\n" +
+	"\tCreateTag\x12\x16.todo.CreateTagRequest\x1a\t.todo.Tag\x126\n" +
+	"\aGetTags\x12\x14.todo.GetTagsRequest\x1a\x15.todo.GetTagsResponse\x12.\n" +
+	"\tUpdateTag\x12\x16.todo.UpdateTagRequest\x1a\t.todo.Tag\x12<\n" +
+	"\tDeleteTag\x12\x16.todo.DeleteTagRequest\x1a\x17.todo.DeleteTagResponseB\tZ\a.;protob\x06proto3"
 
 var (
 	file_todo_proto_rawDescOnce sync.Once
@@ -443,31 +2548,113 @@ func file_todo_proto_rawDescGZIP() []byte {
 	return file_todo_proto_rawDescData
 }
 
-var file_todo_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_todo_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_todo_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_todo_proto_goTypes = []any{
-	(*TodoItem)(nil),           // 0: todo.TodoItem
-	(*CreateTodoRequest)(nil),  // 1: todo.CreateTodoRequest
-	(*GetTodosRequest)(nil),    // 2: todo.GetTodosRequest
-	(*GetTodosResponse)(nil),   // 3: todo.GetTodosResponse
-	(*UpdateTodoRequest)(nil),  // 4: todo.UpdateTodoRequest
-	(*DeleteTodoRequest)(nil),  // 5: todo.DeleteTodoRequest
-	(*DeleteTodoResponse)(nil), // 6: todo.DeleteTodoResponse
+	(Priority)(0),                 // 0: todo.Priority
+	(TodoSortField)(0),            // 1: todo.TodoSortField
+	(DueFilter)(0),                // 2: todo.DueFilter
+	(*TodoItem)(nil),              // 3: todo.TodoItem
+	(*Tag)(nil),                   // 4: todo.Tag
+	(*TodoList)(nil),              // 5: todo.TodoList
+	(*CreateTodoRequest)(nil),     // 6: todo.CreateTodoRequest
+	(*GetTodoRequest)(nil),        // 7: todo.GetTodoRequest
+	(*GetTodosRequest)(nil),       // 8: todo.GetTodosRequest
+	(*GetTodosResponse)(nil),      // 9: todo.GetTodosResponse
+	(*UpdateTodoRequest)(nil),     // 10: todo.UpdateTodoRequest
+	(*DeleteTodoRequest)(nil),     // 11: todo.DeleteTodoRequest
+	(*DeleteTodoResponse)(nil),    // 12: todo.DeleteTodoResponse
+	(*SearchTodosRequest)(nil),    // 13: todo.SearchTodosRequest
+	(*SearchTodosResponse)(nil),   // 14: todo.SearchTodosResponse
+	(*SearchHit)(nil),             // 15: todo.SearchHit
+	(*ListTrashRequest)(nil),      // 16: todo.ListTrashRequest
+	(*ListTrashResponse)(nil),     // 17: todo.ListTrashResponse
+	(*RestoreTodoRequest)(nil),    // 18: todo.RestoreTodoRequest
+	(*PurgeTodoRequest)(nil),      // 19: todo.PurgeTodoRequest
+	(*PurgeTodoResponse)(nil),     // 20: todo.PurgeTodoResponse
+	(*CreateListRequest)(nil),     // 21: todo.CreateListRequest
+	(*GetListsRequest)(nil),       // 22: todo.GetListsRequest
+	(*GetListsResponse)(nil),      // 23: todo.GetListsResponse
+	(*UpdateListRequest)(nil),     // 24: todo.UpdateListRequest
+	(*DeleteListRequest)(nil),     // 25: todo.DeleteListRequest
+	(*DeleteListResponse)(nil),    // 26: todo.DeleteListResponse
+	(*ReorderTodoRequest)(nil),    // 27: todo.ReorderTodoRequest
+	(*MoveTodoRequest)(nil),       // 28: todo.MoveTodoRequest
+	(*CreateTagRequest)(nil),      // 29: todo.CreateTagRequest
+	(*GetTagsRequest)(nil),        // 30: todo.GetTagsRequest
+	(*GetTagsResponse)(nil),       // 31: todo.GetTagsResponse
+	(*UpdateTagRequest)(nil),      // 32: todo.UpdateTagRequest
+	(*DeleteTagRequest)(nil),      // 33: todo.DeleteTagRequest
+	(*DeleteTagResponse)(nil),     // 34: todo.DeleteTagResponse
+	(*timestamppb.Timestamp)(nil), // 35: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil), // 36: google.protobuf.FieldMask
 }
 var file_todo_proto_depIdxs = []int32{
-	0, // 0: todo.GetTodosResponse.todos:type_name -> todo.TodoItem
-	1, // 1: todo.TodoService.CreateTodo:input_type -> todo.CreateTodoRequest
-	2, // 2: todo.TodoService.GetTodos:input_type -> todo.GetTodosRequest
-	4, // 3: todo.TodoService.UpdateTodo:input_type -> todo.UpdateTodoRequest
-	5, // 4: todo.TodoService.DeleteTodo:input_type -> todo.DeleteTodoRequest
-	0, // 5: todo.TodoService.CreateTodo:output_type -> todo.TodoItem
-	3, // 6: todo.TodoService.GetTodos:output_type -> todo.GetTodosResponse
-	0, // 7: todo.TodoService.UpdateTodo:output_type -> todo.TodoItem
-	6, // 8: todo.TodoService.DeleteTodo:output_type -> todo.DeleteTodoResponse
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	35, // 0: todo.TodoItem.due_at:type_name -> google.protobuf.Timestamp
+	35, // 1: todo.TodoItem.remind_at:type_name -> google.protobuf.Timestamp
+	3,  // 2: todo.TodoItem.children:type_name -> todo.TodoItem
+	3,  // 3: todo.TodoItem.next_occurrence:type_name -> todo.TodoItem
+	0,  // 4: todo.TodoItem.priority:type_name -> todo.Priority
+	35, // 5: todo.TodoItem.deleted_at:type_name -> google.protobuf.Timestamp
+	35, // 6: todo.CreateTodoRequest.due_at:type_name -> google.protobuf.Timestamp
+	35, // 7: todo.CreateTodoRequest.remind_at:type_name -> google.protobuf.Timestamp
+	0,  // 8: todo.CreateTodoRequest.priority:type_name -> todo.Priority
+	1,  // 9: todo.GetTodosRequest.sort_by:type_name -> todo.TodoSortField
+	2,  // 10: todo.GetTodosRequest.due_filter:type_name -> todo.DueFilter
+	3,  // 11: todo.GetTodosResponse.todos:type_name -> todo.TodoItem
+	36, // 12: todo.UpdateTodoRequest.update_mask:type_name -> google.protobuf.FieldMask
+	35, // 13: todo.UpdateTodoRequest.due_at:type_name -> google.protobuf.Timestamp
+	35, // 14: todo.UpdateTodoRequest.remind_at:type_name -> google.protobuf.Timestamp
+	0,  // 15: todo.UpdateTodoRequest.priority:type_name -> todo.Priority
+	3,  // 16: todo.DeleteTodoResponse.todo:type_name -> todo.TodoItem
+	15, // 17: todo.SearchTodosResponse.hits:type_name -> todo.SearchHit
+	3,  // 18: todo.SearchHit.todo:type_name -> todo.TodoItem
+	3,  // 19: todo.ListTrashResponse.todos:type_name -> todo.TodoItem
+	5,  // 20: todo.GetListsResponse.lists:type_name -> todo.TodoList
+	4,  // 21: todo.GetTagsResponse.tags:type_name -> todo.Tag
+	6,  // 22: todo.TodoService.CreateTodo:input_type -> todo.CreateTodoRequest
+	8,  // 23: todo.TodoService.GetTodos:input_type -> todo.GetTodosRequest
+	7,  // 24: todo.TodoService.GetTodo:input_type -> todo.GetTodoRequest
+	10, // 25: todo.TodoService.UpdateTodo:input_type -> todo.UpdateTodoRequest
+	11, // 26: todo.TodoService.DeleteTodo:input_type -> todo.DeleteTodoRequest
+	13, // 27: todo.TodoService.SearchTodos:input_type -> todo.SearchTodosRequest
+	16, // 28: todo.TodoService.ListTrash:input_type -> todo.ListTrashRequest
+	18, // 29: todo.TodoService.RestoreTodo:input_type -> todo.RestoreTodoRequest
+	19, // 30: todo.TodoService.PurgeTodo:input_type -> todo.PurgeTodoRequest
+	21, // 31: todo.TodoService.CreateList:input_type -> todo.CreateListRequest
+	22, // 32: todo.TodoService.GetLists:input_type -> todo.GetListsRequest
+	24, // 33: todo.TodoService.UpdateList:input_type -> todo.UpdateListRequest
+	25, // 34: todo.TodoService.DeleteList:input_type -> todo.DeleteListRequest
+	28, // 35: todo.TodoService.MoveTodo:input_type -> todo.MoveTodoRequest
+	27, // 36: todo.TodoService.ReorderTodo:input_type -> todo.ReorderTodoRequest
+	29, // 37: todo.TodoService.CreateTag:input_type -> todo.CreateTagRequest
+	30, // 38: todo.TodoService.GetTags:input_type -> todo.GetTagsRequest
+	32, // 39: todo.TodoService.UpdateTag:input_type -> todo.UpdateTagRequest
+	33, // 40: todo.TodoService.DeleteTag:input_type -> todo.DeleteTagRequest
+	3,  // 41: todo.TodoService.CreateTodo:output_type -> todo.TodoItem
+	9,  // 42: todo.TodoService.GetTodos:output_type -> todo.GetTodosResponse
+	3,  // 43: todo.TodoService.GetTodo:output_type -> todo.TodoItem
+	3,  // 44: todo.TodoService.UpdateTodo:output_type -> todo.TodoItem
+	12, // 45: todo.TodoService.DeleteTodo:output_type -> todo.DeleteTodoResponse
+	14, // 46: todo.TodoService.SearchTodos:output_type -> todo.SearchTodosResponse
+	17, // 47: todo.TodoService.ListTrash:output_type -> todo.ListTrashResponse
+	3,  // 48: todo.TodoService.RestoreTodo:output_type -> todo.TodoItem
+	20, // 49: todo.TodoService.PurgeTodo:output_type -> todo.PurgeTodoResponse
+	5,  // 50: todo.TodoService.CreateList:output_type -> todo.TodoList
+	23, // 51: todo.TodoService.GetLists:output_type -> todo.GetListsResponse
+	5,  // 52: todo.TodoService.UpdateList:output_type -> todo.TodoList
+	26, // 53: todo.TodoService.DeleteList:output_type -> todo.DeleteListResponse
+	3,  // 54: todo.TodoService.MoveTodo:output_type -> todo.TodoItem
+	3,  // 55: todo.TodoService.ReorderTodo:output_type -> todo.TodoItem
+	4,  // 56: todo.TodoService.CreateTag:output_type -> todo.Tag
+	31, // 57: todo.TodoService.GetTags:output_type -> todo.GetTagsResponse
+	4,  // 58: todo.TodoService.UpdateTag:output_type -> todo.Tag
+	34, // 59: todo.TodoService.DeleteTag:output_type -> todo.DeleteTagResponse
+	41, // [41:60] is the sub-list for method output_type
+	22, // [22:41] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_todo_proto_init() }
@@ -476,18 +2663,20 @@ func file_todo_proto_init() {
 		return
 	}
 	file_user_proto_init()
+	file_todo_proto_msgTypes[5].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_todo_proto_rawDesc), len(file_todo_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   32,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_todo_proto_goTypes,
 		DependencyIndexes: file_todo_proto_depIdxs,
+		EnumInfos:         file_todo_proto_enumTypes,
 		MessageInfos:      file_todo_proto_msgTypes,
 	}.Build()
 	File_todo_proto = out.File
@@ -19,10 +19,25 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TodoService_CreateTodo_FullMethodName = "/todo.TodoService/CreateTodo"
-	TodoService_GetTodos_FullMethodName   = "/todo.TodoService/GetTodos"
-	TodoService_UpdateTodo_FullMethodName = "/todo.TodoService/UpdateTodo"
-	TodoService_DeleteTodo_FullMethodName = "/todo.TodoService/DeleteTodo"
+	TodoService_CreateTodo_FullMethodName  = "/todo.TodoService/CreateTodo"
+	TodoService_GetTodos_FullMethodName    = "/todo.TodoService/GetTodos"
+	TodoService_GetTodo_FullMethodName     = "/todo.TodoService/GetTodo"
+	TodoService_UpdateTodo_FullMethodName  = "/todo.TodoService/UpdateTodo"
+	TodoService_DeleteTodo_FullMethodName  = "/todo.TodoService/DeleteTodo"
+	TodoService_SearchTodos_FullMethodName = "/todo.TodoService/SearchTodos"
+	TodoService_ListTrash_FullMethodName   = "/todo.TodoService/ListTrash"
+	TodoService_RestoreTodo_FullMethodName = "/todo.TodoService/RestoreTodo"
+	TodoService_PurgeTodo_FullMethodName   = "/todo.TodoService/PurgeTodo"
+	TodoService_CreateList_FullMethodName  = "/todo.TodoService/CreateList"
+	TodoService_GetLists_FullMethodName    = "/todo.TodoService/GetLists"
+	TodoService_UpdateList_FullMethodName  = "/todo.TodoService/UpdateList"
+	TodoService_DeleteList_FullMethodName  = "/todo.TodoService/DeleteList"
+	TodoService_MoveTodo_FullMethodName    = "/todo.TodoService/MoveTodo"
+	TodoService_ReorderTodo_FullMethodName = "/todo.TodoService/ReorderTodo"
+	TodoService_CreateTag_FullMethodName   = "/todo.TodoService/CreateTag"
+	TodoService_GetTags_FullMethodName     = "/todo.TodoService/GetTags"
+	TodoService_UpdateTag_FullMethodName   = "/todo.TodoService/UpdateTag"
+	TodoService_DeleteTag_FullMethodName   = "/todo.TodoService/DeleteTag"
 )
 
 // TodoServiceClient is the client API for TodoService service.
@@ -31,8 +46,24 @@ const (
 type TodoServiceClient interface {
 	CreateTodo(ctx context.Context, in *CreateTodoRequest, opts ...grpc.CallOption) (*TodoItem, error)
 	GetTodos(ctx context.Context, in *GetTodosRequest, opts ...grpc.CallOption) (*GetTodosResponse, error)
+	GetTodo(ctx context.Context, in *GetTodoRequest, opts ...grpc.CallOption) (*TodoItem, error)
 	UpdateTodo(ctx context.Context, in *UpdateTodoRequest, opts ...grpc.CallOption) (*TodoItem, error)
 	DeleteTodo(ctx context.Context, in *DeleteTodoRequest, opts ...grpc.CallOption) (*DeleteTodoResponse, error)
+	SearchTodos(ctx context.Context, in *SearchTodosRequest, opts ...grpc.CallOption) (*SearchTodosResponse, error)
+	// Корзина: удаленные задачи хранятся до истечения срока хранения
+	ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error)
+	RestoreTodo(ctx context.Context, in *RestoreTodoRequest, opts ...grpc.CallOption) (*TodoItem, error)
+	PurgeTodo(ctx context.Context, in *PurgeTodoRequest, opts ...grpc.CallOption) (*PurgeTodoResponse, error)
+	CreateList(ctx context.Context, in *CreateListRequest, opts ...grpc.CallOption) (*TodoList, error)
+	GetLists(ctx context.Context, in *GetListsRequest, opts ...grpc.CallOption) (*GetListsResponse, error)
+	UpdateList(ctx context.Context, in *UpdateListRequest, opts ...grpc.CallOption) (*TodoList, error)
+	DeleteList(ctx context.Context, in *DeleteListRequest, opts ...grpc.CallOption) (*DeleteListResponse, error)
+	MoveTodo(ctx context.Context, in *MoveTodoRequest, opts ...grpc.CallOption) (*TodoItem, error)
+	ReorderTodo(ctx context.Context, in *ReorderTodoRequest, opts ...grpc.CallOption) (*TodoItem, error)
+	CreateTag(ctx context.Context, in *CreateTagRequest, opts ...grpc.CallOption) (*Tag, error)
+	GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*GetTagsResponse, error)
+	UpdateTag(ctx context.Context, in *UpdateTagRequest, opts ...grpc.CallOption) (*Tag, error)
+	DeleteTag(ctx context.Context, in *DeleteTagRequest, opts ...grpc.CallOption) (*DeleteTagResponse, error)
 }
 
 type todoServiceClient struct {
@@ -63,6 +94,16 @@ func (c *todoServiceClient) GetTodos(ctx context.Context, in *GetTodosRequest, o
 	return out, nil
 }
 
+func (c *todoServiceClient) GetTodo(ctx context.Context, in *GetTodoRequest, opts ...grpc.CallOption) (*TodoItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TodoItem)
+	err := c.cc.Invoke(ctx, TodoService_GetTodo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *todoServiceClient) UpdateTodo(ctx context.Context, in *UpdateTodoRequest, opts ...grpc.CallOption) (*TodoItem, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(TodoItem)
@@ -83,14 +124,170 @@ func (c *todoServiceClient) DeleteTodo(ctx context.Context, in *DeleteTodoReques
 	return out, nil
 }
 
+func (c *todoServiceClient) SearchTodos(ctx context.Context, in *SearchTodosRequest, opts ...grpc.CallOption) (*SearchTodosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchTodosResponse)
+	err := c.cc.Invoke(ctx, TodoService_SearchTodos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTrashResponse)
+	err := c.cc.Invoke(ctx, TodoService_ListTrash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) RestoreTodo(ctx context.Context, in *RestoreTodoRequest, opts ...grpc.CallOption) (*TodoItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TodoItem)
+	err := c.cc.Invoke(ctx, TodoService_RestoreTodo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) PurgeTodo(ctx context.Context, in *PurgeTodoRequest, opts ...grpc.CallOption) (*PurgeTodoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeTodoResponse)
+	err := c.cc.Invoke(ctx, TodoService_PurgeTodo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) CreateList(ctx context.Context, in *CreateListRequest, opts ...grpc.CallOption) (*TodoList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TodoList)
+	err := c.cc.Invoke(ctx, TodoService_CreateList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) GetLists(ctx context.Context, in *GetListsRequest, opts ...grpc.CallOption) (*GetListsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetListsResponse)
+	err := c.cc.Invoke(ctx, TodoService_GetLists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) UpdateList(ctx context.Context, in *UpdateListRequest, opts ...grpc.CallOption) (*TodoList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TodoList)
+	err := c.cc.Invoke(ctx, TodoService_UpdateList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) DeleteList(ctx context.Context, in *DeleteListRequest, opts ...grpc.CallOption) (*DeleteListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteListResponse)
+	err := c.cc.Invoke(ctx, TodoService_DeleteList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) MoveTodo(ctx context.Context, in *MoveTodoRequest, opts ...grpc.CallOption) (*TodoItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TodoItem)
+	err := c.cc.Invoke(ctx, TodoService_MoveTodo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReorderTodo(ctx context.Context, in *ReorderTodoRequest, opts ...grpc.CallOption) (*TodoItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TodoItem)
+	err := c.cc.Invoke(ctx, TodoService_ReorderTodo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) CreateTag(ctx context.Context, in *CreateTagRequest, opts ...grpc.CallOption) (*Tag, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tag)
+	err := c.cc.Invoke(ctx, TodoService_CreateTag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*GetTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTagsResponse)
+	err := c.cc.Invoke(ctx, TodoService_GetTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) UpdateTag(ctx context.Context, in *UpdateTagRequest, opts ...grpc.CallOption) (*Tag, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tag)
+	err := c.cc.Invoke(ctx, TodoService_UpdateTag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) DeleteTag(ctx context.Context, in *DeleteTagRequest, opts ...grpc.CallOption) (*DeleteTagResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteTagResponse)
+	err := c.cc.Invoke(ctx, TodoService_DeleteTag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TodoServiceServer is the server API for TodoService service.
 // All implementations must embed UnimplementedTodoServiceServer
 // for forward compatibility.
 type TodoServiceServer interface {
 	CreateTodo(context.Context, *CreateTodoRequest) (*TodoItem, error)
 	GetTodos(context.Context, *GetTodosRequest) (*GetTodosResponse, error)
+	GetTodo(context.Context, *GetTodoRequest) (*TodoItem, error)
 	UpdateTodo(context.Context, *UpdateTodoRequest) (*TodoItem, error)
 	DeleteTodo(context.Context, *DeleteTodoRequest) (*DeleteTodoResponse, error)
+	SearchTodos(context.Context, *SearchTodosRequest) (*SearchTodosResponse, error)
+	// Корзина: удаленные задачи хранятся до истечения срока хранения
+	ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error)
+	RestoreTodo(context.Context, *RestoreTodoRequest) (*TodoItem, error)
+	PurgeTodo(context.Context, *PurgeTodoRequest) (*PurgeTodoResponse, error)
+	CreateList(context.Context, *CreateListRequest) (*TodoList, error)
+	GetLists(context.Context, *GetListsRequest) (*GetListsResponse, error)
+	UpdateList(context.Context, *UpdateListRequest) (*TodoList, error)
+	DeleteList(context.Context, *DeleteListRequest) (*DeleteListResponse, error)
+	MoveTodo(context.Context, *MoveTodoRequest) (*TodoItem, error)
+	ReorderTodo(context.Context, *ReorderTodoRequest) (*TodoItem, error)
+	CreateTag(context.Context, *CreateTagRequest) (*Tag, error)
+	GetTags(context.Context, *GetTagsRequest) (*GetTagsResponse, error)
+	UpdateTag(context.Context, *UpdateTagRequest) (*Tag, error)
+	DeleteTag(context.Context, *DeleteTagRequest) (*DeleteTagResponse, error)
 	mustEmbedUnimplementedTodoServiceServer()
 }
 
@@ -107,12 +304,57 @@ func (UnimplementedTodoServiceServer) CreateTodo(context.Context, *CreateTodoReq
 func (UnimplementedTodoServiceServer) GetTodos(context.Context, *GetTodosRequest) (*GetTodosResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTodos not implemented")
 }
+func (UnimplementedTodoServiceServer) GetTodo(context.Context, *GetTodoRequest) (*TodoItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTodo not implemented")
+}
 func (UnimplementedTodoServiceServer) UpdateTodo(context.Context, *UpdateTodoRequest) (*TodoItem, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateTodo not implemented")
 }
 func (UnimplementedTodoServiceServer) DeleteTodo(context.Context, *DeleteTodoRequest) (*DeleteTodoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteTodo not implemented")
 }
+func (UnimplementedTodoServiceServer) SearchTodos(context.Context, *SearchTodosRequest) (*SearchTodosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchTodos not implemented")
+}
+func (UnimplementedTodoServiceServer) ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedTodoServiceServer) RestoreTodo(context.Context, *RestoreTodoRequest) (*TodoItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreTodo not implemented")
+}
+func (UnimplementedTodoServiceServer) PurgeTodo(context.Context, *PurgeTodoRequest) (*PurgeTodoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeTodo not implemented")
+}
+func (UnimplementedTodoServiceServer) CreateList(context.Context, *CreateListRequest) (*TodoList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateList not implemented")
+}
+func (UnimplementedTodoServiceServer) GetLists(context.Context, *GetListsRequest) (*GetListsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLists not implemented")
+}
+func (UnimplementedTodoServiceServer) UpdateList(context.Context, *UpdateListRequest) (*TodoList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateList not implemented")
+}
+func (UnimplementedTodoServiceServer) DeleteList(context.Context, *DeleteListRequest) (*DeleteListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteList not implemented")
+}
+func (UnimplementedTodoServiceServer) MoveTodo(context.Context, *MoveTodoRequest) (*TodoItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveTodo not implemented")
+}
+func (UnimplementedTodoServiceServer) ReorderTodo(context.Context, *ReorderTodoRequest) (*TodoItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReorderTodo not implemented")
+}
+func (UnimplementedTodoServiceServer) CreateTag(context.Context, *CreateTagRequest) (*Tag, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTag not implemented")
+}
+func (UnimplementedTodoServiceServer) GetTags(context.Context, *GetTagsRequest) (*GetTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTags not implemented")
+}
+func (UnimplementedTodoServiceServer) UpdateTag(context.Context, *UpdateTagRequest) (*Tag, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTag not implemented")
+}
+func (UnimplementedTodoServiceServer) DeleteTag(context.Context, *DeleteTagRequest) (*DeleteTagResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTag not implemented")
+}
 func (UnimplementedTodoServiceServer) mustEmbedUnimplementedTodoServiceServer() {}
 func (UnimplementedTodoServiceServer) testEmbeddedByValue()                     {}
 
@@ -170,6 +412,24 @@ func _TodoService_GetTodos_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TodoService_GetTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTodoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).GetTodo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_GetTodo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).GetTodo(ctx, req.(*GetTodoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TodoService_UpdateTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateTodoRequest)
 	if err := dec(in); err != nil {
@@ -206,6 +466,258 @@ func _TodoService_DeleteTodo_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TodoService_SearchTodos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchTodosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).SearchTodos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_SearchTodos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).SearchTodos(ctx, req.(*SearchTodosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ListTrash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ListTrash(ctx, req.(*ListTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_RestoreTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreTodoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).RestoreTodo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_RestoreTodo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).RestoreTodo(ctx, req.(*RestoreTodoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_PurgeTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeTodoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).PurgeTodo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_PurgeTodo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).PurgeTodo(ctx, req.(*PurgeTodoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_CreateList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).CreateList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_CreateList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).CreateList(ctx, req.(*CreateListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_GetLists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetListsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).GetLists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_GetLists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).GetLists(ctx, req.(*GetListsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_UpdateList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).UpdateList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_UpdateList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).UpdateList(ctx, req.(*UpdateListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_DeleteList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).DeleteList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_DeleteList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).DeleteList(ctx, req.(*DeleteListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_MoveTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveTodoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).MoveTodo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_MoveTodo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).MoveTodo(ctx, req.(*MoveTodoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReorderTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReorderTodoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReorderTodo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReorderTodo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReorderTodo(ctx, req.(*ReorderTodoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_CreateTag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).CreateTag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_CreateTag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).CreateTag(ctx, req.(*CreateTagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_GetTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).GetTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_GetTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).GetTags(ctx, req.(*GetTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_UpdateTag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).UpdateTag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_UpdateTag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).UpdateTag(ctx, req.(*UpdateTagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_DeleteTag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).DeleteTag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_DeleteTag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).DeleteTag(ctx, req.(*DeleteTagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TodoService_ServiceDesc is the grpc.ServiceDesc for TodoService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -221,6 +733,10 @@ var TodoService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTodos",
 			Handler:    _TodoService_GetTodos_Handler,
 		},
+		{
+			MethodName: "GetTodo",
+			Handler:    _TodoService_GetTodo_Handler,
+		},
 		{
 			MethodName: "UpdateTodo",
 			Handler:    _TodoService_UpdateTodo_Handler,
@@ -229,6 +745,62 @@ var TodoService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteTodo",
 			Handler:    _TodoService_DeleteTodo_Handler,
 		},
+		{
+			MethodName: "SearchTodos",
+			Handler:    _TodoService_SearchTodos_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _TodoService_ListTrash_Handler,
+		},
+		{
+			MethodName: "RestoreTodo",
+			Handler:    _TodoService_RestoreTodo_Handler,
+		},
+		{
+			MethodName: "PurgeTodo",
+			Handler:    _TodoService_PurgeTodo_Handler,
+		},
+		{
+			MethodName: "CreateList",
+			Handler:    _TodoService_CreateList_Handler,
+		},
+		{
+			MethodName: "GetLists",
+			Handler:    _TodoService_GetLists_Handler,
+		},
+		{
+			MethodName: "UpdateList",
+			Handler:    _TodoService_UpdateList_Handler,
+		},
+		{
+			MethodName: "DeleteList",
+			Handler:    _TodoService_DeleteList_Handler,
+		},
+		{
+			MethodName: "MoveTodo",
+			Handler:    _TodoService_MoveTodo_Handler,
+		},
+		{
+			MethodName: "ReorderTodo",
+			Handler:    _TodoService_ReorderTodo_Handler,
+		},
+		{
+			MethodName: "CreateTag",
+			Handler:    _TodoService_CreateTag_Handler,
+		},
+		{
+			MethodName: "GetTags",
+			Handler:    _TodoService_GetTags_Handler,
+		},
+		{
+			MethodName: "UpdateTag",
+			Handler:    _TodoService_UpdateTag_Handler,
+		},
+		{
+			MethodName: "DeleteTag",
+			Handler:    _TodoService_DeleteTag_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "todo.proto",
@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NoPermission отмечает в карте методов UnaryServerInterceptor методы, которым
+// особые права не нужны: вход, регистрацию, действия со своей учетной записью
+const NoPermission Permission = ""
+
+// RoleLookup возвращает текущую роль пользователя. Ошибка со статусом gRPC
+// возвращается клиенту как есть, остальные — как codes.Internal.
+type RoleLookup func(ctx context.Context, userID uint) (string, error)
+
+// userIDRequest — запрос, в котором шлюз передает id вызывающего пользователя
+type userIDRequest interface {
+	GetUserId() string
+}
+
+// UnaryServerInterceptor требует для методов из required соответствующее
+// право. Вызывающий определяется по полю user_id запроса, его роль — через
+// lookup. Методы, которых нет в required, отклоняются: забытый в карте метод
+// должен быть закрыт, а не открыт.
+func UnaryServerInterceptor(required map[string]Permission, lookup RoleLookup) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		permission, ok := required[info.FullMethod]
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "method %s is not allowed", info.FullMethod)
+		}
+		if permission == NoPermission {
+			return handler(ctx, req)
+		}
+
+		r, ok := req.(userIDRequest)
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "method %s has no user ID to check permissions", info.FullMethod)
+		}
+		userID, err := strconv.ParseUint(r.GetUserId(), 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+		}
+
+		role, err := lookup(ctx, uint(userID))
+		if err != nil {
+			if _, ok := status.FromError(err); ok {
+				return nil, err
+			}
+			return nil, status.Errorf(codes.Internal, "failed to get user role: %v", err)
+		}
+		if !HasPermission(role, permission) {
+			return nil, status.Errorf(codes.PermissionDenied, "permission %s is required", permission)
+		}
+		return handler(ctx, req)
+	}
+}
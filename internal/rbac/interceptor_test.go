@@ -0,0 +1,105 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testRequest struct {
+	userID string
+}
+
+func (r testRequest) GetUserId() string { return r.userID }
+
+var testMethods = map[string]Permission{
+	"/test.Service/Login":      NoPermission,
+	"/test.Service/ListTodos":  TodosRead,
+	"/test.Service/DeleteUser": UsersManage,
+}
+
+// call вызывает метод через перехватчик и сообщает, дошел ли вызов до обработчика
+func call(t *testing.T, lookup RoleLookup, method string, req interface{}) (bool, error) {
+	t.Helper()
+	interceptor := UnaryServerInterceptor(testMethods, lookup)
+	handled := false
+	_, err := interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			handled = true
+			return nil, nil
+		})
+	return handled, err
+}
+
+func roles(byID map[uint]string) RoleLookup {
+	return func(ctx context.Context, userID uint) (string, error) {
+		role, ok := byID[userID]
+		if !ok {
+			return "", status.Errorf(codes.PermissionDenied, "user not found")
+		}
+		return role, nil
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	lookup := roles(map[uint]string{1: RoleUser, 2: RoleAdmin, 3: RoleUnverified})
+	for _, tc := range []struct {
+		name    string
+		method  string
+		req     interface{}
+		handled bool
+		code    codes.Code
+	}{
+		{"unmapped method", "/test.Service/Unknown", testRequest{"2"}, false, codes.PermissionDenied},
+		{"no permission needed", "/test.Service/Login", struct{}{}, true, codes.OK},
+		{"user reads", "/test.Service/ListTodos", testRequest{"1"}, true, codes.OK},
+		{"unverified reads", "/test.Service/ListTodos", testRequest{"3"}, true, codes.OK},
+		{"user manages users", "/test.Service/DeleteUser", testRequest{"1"}, false, codes.PermissionDenied},
+		{"admin manages users", "/test.Service/DeleteUser", testRequest{"2"}, true, codes.OK},
+		{"unknown user", "/test.Service/DeleteUser", testRequest{"9"}, false, codes.PermissionDenied},
+		{"invalid user ID", "/test.Service/DeleteUser", testRequest{"admin"}, false, codes.InvalidArgument},
+		{"request without user ID", "/test.Service/DeleteUser", struct{}{}, false, codes.Internal},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handled, err := call(t, lookup, tc.method, tc.req)
+			if handled != tc.handled || status.Code(err) != tc.code {
+				t.Fatalf("handled = %v, err = %v; want handled = %v, code %v", handled, err, tc.handled, tc.code)
+			}
+		})
+	}
+}
+
+func TestInterceptorLookupError(t *testing.T) {
+	lookup := func(ctx context.Context, userID uint) (string, error) {
+		return "", errors.New("connection refused")
+	}
+	handled, err := call(t, lookup, "/test.Service/ListTodos", testRequest{"1"})
+	if handled || status.Code(err) != codes.Internal {
+		t.Fatalf("handled = %v, err = %v; want Internal", handled, err)
+	}
+}
+
+func TestRoleFromIncomingContext(t *testing.T) {
+	// Роль, переданная шлюзом, доходит до сервиса
+	outgoing := WithRole(context.Background(), RoleAdmin)
+	md, _ := metadata.FromOutgoingContext(outgoing)
+	role, err := RoleFromIncomingContext(metadata.NewIncomingContext(context.Background(), md), 1)
+	if err != nil || role != RoleAdmin {
+		t.Fatalf("role = %q, err = %v; want admin", role, err)
+	}
+
+	for _, ctx := range []context.Context{
+		context.Background(),
+		WithRole(context.Background(), ""),
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-client-ip", "127.0.0.1")),
+	} {
+		if _, err := RoleFromIncomingContext(ctx, 1); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("missing role: err = %v, want PermissionDenied", err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package rbac
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RoleMetadataKey — ключ метаданных с ролью вызывающего пользователя из его
+// токена. Как и user_id в запросе, значению можно доверять, только если
+// сервис доступен лишь через шлюз.
+const RoleMetadataKey = "x-user-role"
+
+// WithRole добавляет роль пользователя к исходящему вызову
+func WithRole(ctx context.Context, role string) context.Context {
+	if role == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RoleMetadataKey, role)
+}
+
+// RoleFromIncomingContext — RoleLookup для сервисов, которые не хранят
+// пользователей: роль берется из метаданных, переданных шлюзом
+func RoleFromIncomingContext(ctx context.Context, userID uint) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if values := md.Get(RoleMetadataKey); len(values) > 0 && values[0] != "" {
+			return values[0], nil
+		}
+	}
+	return "", status.Errorf(codes.PermissionDenied, "caller role is missing")
+}
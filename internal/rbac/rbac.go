@@ -0,0 +1,83 @@
+// Package rbac описывает роли пользователей и права, которые они дают.
+// Права проверяются и в шлюзе (middleware.RequirePermission), и в самих
+// сервисах (UnaryServerInterceptor): шлюз отсекает запросы по роли из
+// токена, а сервис — по роли из базы (UserService) или переданной шлюзом
+// (TodoService).
+package rbac
+
+import "sort"
+
+// Permission — право на действие вида "ресурс:действие"
+type Permission string
+
+const (
+	// Свои задачи, списки и метки
+	TodosRead  Permission = "todos:read"
+	TodosWrite Permission = "todos:write"
+	// Управление пользователями и их ролями
+	UsersManage Permission = "users:manage"
+	// Ротация ключей подписи токенов
+	KeysRotate Permission = "keys:rotate"
+	// Регистрация приложений, использующих вход через OAuth
+	OAuthClientsManage Permission = "oauth_clients:manage"
+)
+
+// Роли
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+	// RoleUnverified не назначается пользователям: она попадает в токен
+	// вместо настоящей роли, пока email не подтвержден
+	RoleUnverified = "unverified"
+)
+
+// DefaultRole назначается при регистрации; выбрать роль самому нельзя
+const DefaultRole = RoleUser
+
+var rolePermissions = map[string][]Permission{
+	RoleUnverified: {TodosRead},
+	RoleUser:       {TodosRead, TodosWrite},
+	RoleAdmin: {
+		TodosRead, TodosWrite,
+		UsersManage,
+		KeysRotate,
+		OAuthClientsManage,
+	},
+}
+
+// ValidRole сообщает, можно ли назначить роль пользователю
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok && role != RoleUnverified
+}
+
+// Roles возвращает роли, которые можно назначить, по алфавиту
+func Roles() []string {
+	roles := make([]string, 0, len(rolePermissions))
+	for role := range rolePermissions {
+		if ValidRole(role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// HasPermission сообщает, дает ли роль право. У неизвестной роли прав нет.
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidPermission сообщает, существует ли такое право
+func ValidPermission(permission Permission) bool {
+	switch permission {
+	case TodosRead, TodosWrite, UsersManage, KeysRotate, OAuthClientsManage:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHasPermission(t *testing.T) {
+	for _, tc := range []struct {
+		role       string
+		permission Permission
+		want       bool
+	}{
+		{RoleUser, TodosRead, true},
+		{RoleUser, TodosWrite, true},
+		{RoleUser, UsersManage, false},
+		{RoleUser, KeysRotate, false},
+		{RoleUser, OAuthClientsManage, false},
+		{RoleUnverified, TodosRead, true},
+		{RoleUnverified, TodosWrite, false},
+		{RoleAdmin, TodosRead, true},
+		{RoleAdmin, TodosWrite, true},
+		{RoleAdmin, UsersManage, true},
+		{RoleAdmin, KeysRotate, true},
+		{RoleAdmin, OAuthClientsManage, true},
+		{"", TodosRead, false},
+		{"root", UsersManage, false},
+		{RoleAdmin, NoPermission, false},
+		{RoleAdmin, "todos:delete", false},
+	} {
+		if got := HasPermission(tc.role, tc.permission); got != tc.want {
+			t.Errorf("HasPermission(%q, %q) = %v, want %v", tc.role, tc.permission, got, tc.want)
+		}
+	}
+}
+
+func TestRoles(t *testing.T) {
+	if got, want := Roles(), []string{RoleAdmin, RoleUser}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Roles() = %v, want %v", got, want)
+	}
+	for _, tc := range []struct {
+		role string
+		want bool
+	}{
+		{RoleUser, true},
+		{RoleAdmin, true},
+		{RoleUnverified, false},
+		{"", false},
+		{"Admin", false},
+	} {
+		if got := ValidRole(tc.role); got != tc.want {
+			t.Errorf("ValidRole(%q) = %v, want %v", tc.role, got, tc.want)
+		}
+	}
+	if !ValidRole(DefaultRole) || HasPermission(DefaultRole, UsersManage) {
+		t.Errorf("default role %q must be assignable and unprivileged", DefaultRole)
+	}
+}
+
+func TestValidPermission(t *testing.T) {
+	// Каждое право, которое дает какая-либо роль, существует
+	for role, permissions := range rolePermissions {
+		for _, permission := range permissions {
+			if !ValidPermission(permission) {
+				t.Errorf("role %q grants unknown permission %q", role, permission)
+			}
+		}
+	}
+	for _, permission := range []Permission{NoPermission, "todos", "todos:read:any", "users:manage "} {
+		if ValidPermission(permission) {
+			t.Errorf("ValidPermission(%q) = true", permission)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	// ListAPIKeys возвращает неотозванные ключи пользователя, новые первыми
+	ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// RevokeAPIKey отзывает ключ пользователя; gorm.ErrRecordNotFound, если
+	// такого неотозванного ключа у него нет
+	RevokeAPIKey(ctx context.Context, userID, id uint, at time.Time) error
+	// TouchAPIKey обновляет время последнего использования, если с прошлого
+	// обновления прошло больше minInterval: ключ проверяется на каждый
+	// запрос, и писать в базу каждый раз незачем
+	TouchAPIKey(ctx context.Context, id uint, at time.Time, minInterval time.Duration) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, userID, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id uint, at time.Time, minInterval time.Duration) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-minInterval)).
+		Update("last_used_at", at).Error
+}
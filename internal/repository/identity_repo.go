@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+type IdentityRepository interface {
+	CreateIdentity(ctx context.Context, identity *models.Identity) error
+	// CreateUserWithIdentity создает пользователя, вошедшего через провайдера
+	// впервые, вместе с его учетной записью
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.Identity) error
+	GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, error)
+	// ListIdentities возвращает учетные записи пользователя в порядке привязки
+	ListIdentities(ctx context.Context, userID uint) ([]models.Identity, error)
+	// DeleteIdentity отвязывает учетную запись провайдера; gorm.ErrRecordNotFound,
+	// если ее нет
+	DeleteIdentity(ctx context.Context, userID uint, provider string) error
+	// TouchIdentity запоминает вход: время и email у провайдера
+	TouchIdentity(ctx context.Context, id uint, email string, at time.Time) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) CreateIdentity(ctx context.Context, identity *models.Identity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *identityRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.Identity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+func (r *identityRepository) GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) ListIdentities(ctx context.Context, userID uint) ([]models.Identity, error) {
+	var identities []models.Identity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+func (r *identityRepository) DeleteIdentity(ctx context.Context, userID uint, provider string) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.Identity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *identityRepository) TouchIdentity(ctx context.Context, id uint, email string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Identity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_login_at": at}).Error
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+	"server/internal/position"
+)
+
+type ListRepository interface {
+	CreateList(ctx context.Context, list *models.List) error
+	GetListsByUserID(ctx context.Context, userID uint) ([]*models.List, error)
+	GetListByID(ctx context.Context, id uint) (*models.List, error)
+	GetOrCreateInbox(ctx context.Context, userID uint) (*models.List, error)
+	UpdateList(ctx context.Context, list *models.List) error
+	// DeleteList удаляет список. При cascade его задачи удаляются,
+	// иначе переносятся в список inboxID.
+	DeleteList(ctx context.Context, list *models.List, inboxID uint, cascade bool) error
+}
+
+type listRepository struct {
+	db *gorm.DB
+}
+
+func NewListRepository(db *gorm.DB) ListRepository {
+	return &listRepository{db: db}
+}
+
+func (r *listRepository) CreateList(ctx context.Context, list *models.List) error {
+	return r.db.WithContext(ctx).Create(list).Error
+}
+
+func (r *listRepository) GetListsByUserID(ctx context.Context, userID uint) ([]*models.List, error) {
+	var lists []*models.List
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("is_inbox DESC").Order("id").Find(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (r *listRepository) GetListByID(ctx context.Context, id uint) (*models.List, error) {
+	var list models.List
+	if err := r.db.WithContext(ctx).First(&list, id).Error; err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetOrCreateInbox возвращает Inbox пользователя, создавая его при первом
+// обращении. Задачи, созданные до появления списков (list_id = 0),
+// при этом переносятся в Inbox.
+func (r *listRepository) GetOrCreateInbox(ctx context.Context, userID uint) (*models.List, error) {
+	var inbox models.List
+	err := r.db.WithContext(ctx).Where("user_id = ? AND is_inbox = ?", userID, true).First(&inbox).Error
+	if err == nil {
+		return &inbox, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		inbox = models.List{UserID: userID, Name: models.InboxListName, IsInbox: true, InboxOwner: &userID}
+		if err := tx.Create(&inbox).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Todo{}).
+			Where("user_id = ? AND list_id = ?", userID, 0).
+			UpdateColumn("list_id", inbox.ID).Error
+	})
+	if err != nil {
+		// Inbox мог создать параллельный запрос — тогда вернем его
+		inbox = models.List{}
+		if findErr := r.db.WithContext(ctx).Where("user_id = ? AND is_inbox = ?", userID, true).First(&inbox).Error; findErr == nil {
+			return &inbox, nil
+		}
+		return nil, err
+	}
+	return &inbox, nil
+}
+
+func (r *listRepository) UpdateList(ctx context.Context, list *models.List) error {
+	return r.db.WithContext(ctx).Save(list).Error
+}
+
+func (r *listRepository) DeleteList(ctx context.Context, list *models.List, inboxID uint, cascade bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if cascade {
+			if err := tx.Where("list_id = ?", list.ID).Delete(&models.Todo{}).Error; err != nil {
+				return err
+			}
+		} else if err := moveTodosToEnd(tx, list, inboxID); err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+}
+
+// moveTodosToEnd переносит задачи списка в конец списка listID. Ключи
+// порядка из разных списков между собой не согласованы, поэтому задачи
+// получают новые ключи после последней задачи listID в прежнем порядке.
+func moveTodosToEnd(tx *gorm.DB, list *models.List, listID uint) error {
+	var last *string
+	err := tx.Model(&models.Todo{}).
+		Where("user_id = ? AND list_id = ?", list.UserID, listID).
+		Select("MAX(" + positionColumn + ")").
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	key := ""
+	if last != nil {
+		key = *last
+	}
+
+	var todos []*models.Todo
+	err = tx.Select("id").
+		Where("list_id = ?", list.ID).
+		Order(positionColumn).Order("created_at").Order("id").
+		Find(&todos).Error
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		if key, err = position.Between(key, ""); err != nil {
+			return err
+		}
+		err := tx.Model(&models.Todo{}).Where("id = ?", todo.ID).Updates(map[string]interface{}{
+			"list_id":  listID,
+			"position": key,
+			"version":  gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+// ErrOAuthCodeUsed возвращается при повторном предъявлении кода авторизации
+var ErrOAuthCodeUsed = errors.New("authorization code was already used")
+
+type OAuthRepository interface {
+	CreateOAuthClient(ctx context.Context, client *models.OAuthClient) error
+	ListOAuthClients(ctx context.Context) ([]models.OAuthClient, error)
+	GetOAuthClient(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	// DeleteOAuthClient удаляет клиента вместе с его кодами и токенами
+	DeleteOAuthClient(ctx context.Context, clientID string) error
+
+	CreateOAuthCode(ctx context.Context, code *models.OAuthCode) error
+	GetOAuthCode(ctx context.Context, hash string) (*models.OAuthCode, error)
+	// ConsumeOAuthCode отмечает код использованным. Если он уже был
+	// использован, отзывает выданные по нему токены и возвращает
+	// ErrOAuthCodeUsed: код, скорее всего, перехвачен.
+	ConsumeOAuthCode(ctx context.Context, id uint, at time.Time) error
+
+	CreateOAuthToken(ctx context.Context, token *models.OAuthToken) error
+	GetOAuthToken(ctx context.Context, hash string) (*models.OAuthToken, error)
+	// DeleteExpiredOAuthGrants удаляет коды и токены, истекшие раньше before.
+	// Использованный код нужен, пока живы выданные по нему токены, так что
+	// before стоит брать с запасом на срок токена.
+	DeleteExpiredOAuthGrants(ctx context.Context, before time.Time) error
+}
+
+type oauthRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepository(db *gorm.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+func (r *oauthRepository) CreateOAuthClient(ctx context.Context, client *models.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *oauthRepository) ListOAuthClients(ctx context.Context) ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+	err := r.db.WithContext(ctx).Order("id").Find(&clients).Error
+	return clients, err
+}
+
+func (r *oauthRepository) GetOAuthClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *oauthRepository) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.OAuthCode{}, &models.OAuthToken{}} {
+			if err := tx.Where("client_id = ?", clientID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Where("client_id = ?", clientID).Delete(&models.OAuthClient{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *oauthRepository) CreateOAuthCode(ctx context.Context, code *models.OAuthCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+func (r *oauthRepository) GetOAuthCode(ctx context.Context, hash string) (*models.OAuthCode, error) {
+	var code models.OAuthCode
+	if err := r.db.WithContext(ctx).Where("code_hash = ?", hash).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *oauthRepository) ConsumeOAuthCode(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Условное обновление: из двух параллельных обменов успешен один
+		res := tx.Model(&models.OAuthCode{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", at)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil
+		}
+		err := tx.Model(&models.OAuthToken{}).
+			Where("code_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", at).Error
+		if err != nil {
+			return err
+		}
+		return ErrOAuthCodeUsed
+	})
+}
+
+func (r *oauthRepository) CreateOAuthToken(ctx context.Context, token *models.OAuthToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *oauthRepository) GetOAuthToken(ctx context.Context, hash string) (*models.OAuthToken, error) {
+	var token models.OAuthToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *oauthRepository) DeleteExpiredOAuthGrants(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.OAuthCode{}, &models.OAuthToken{}} {
+			if err := tx.Where("expires_at < ?", before).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+// ErrRefreshTokenUsed возвращается, если токен уже обменяли или отозвали
+var ErrRefreshTokenUsed = errors.New("refresh token was already used")
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RotateRefreshToken отмечает old использованным и сохраняет next.
+	// Если old уже использован или отозван, возвращает ErrRefreshTokenUsed.
+	RotateRefreshToken(ctx context.Context, old *models.RefreshToken, next *models.RefreshToken) error
+	// RevokeFamily отзывает все еще действующие токены семейства
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeUserTokens отзывает все действующие токены пользователя
+	RevokeUserTokens(ctx context.Context, userID uint, at time.Time) error
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, old *models.RefreshToken, next *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Условное обновление: из двух параллельных обменов одного токена
+		// успешен только один
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", old.ID).
+			Update("used_at", next.CreatedAt)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRefreshTokenUsed
+		}
+		return tx.Create(next).Error
+	})
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+func (r *refreshTokenRepository) RevokeUserTokens(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
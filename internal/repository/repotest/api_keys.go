@@ -0,0 +1,86 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.APIKeyRepository = (*APIKeys)(nil)
+
+// APIKeys — repository.APIKeyRepository в памяти
+type APIKeys struct {
+	mu     sync.Mutex
+	nextID uint
+	byID   map[uint]*models.APIKey
+}
+
+func NewAPIKeys() *APIKeys {
+	return &APIKeys{byID: make(map[uint]*models.APIKey)}
+}
+
+func (r *APIKeys) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.byID {
+		if existing.TokenHash == key.TokenHash {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	r.nextID++
+	key.ID = r.nextID
+	stored := *key
+	r.byID[key.ID] = &stored
+	return nil
+}
+
+func (r *APIKeys) ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []models.APIKey
+	for _, key := range r.byID {
+		if key.UserID == userID && key.RevokedAt == nil {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID > keys[j].ID })
+	return keys, nil
+}
+
+func (r *APIKeys) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.byID {
+		if key.TokenHash == hash {
+			found := *key
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *APIKeys) RevokeAPIKey(ctx context.Context, userID, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.byID[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	key.RevokedAt = &at
+	return nil
+}
+
+func (r *APIKeys) TouchAPIKey(ctx context.Context, id uint, at time.Time, minInterval time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key, ok := r.byID[id]; ok && (key.LastUsedAt == nil || key.LastUsedAt.Before(at.Add(-minInterval))) {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
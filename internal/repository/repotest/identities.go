@@ -0,0 +1,120 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.IdentityRepository = (*Identities)(nil)
+
+// Identities — repository.IdentityRepository в памяти; пользователей
+// создает в users
+type Identities struct {
+	users *Users
+
+	mu         sync.Mutex
+	identities []*models.Identity
+	nextID     uint
+}
+
+func NewIdentities(users *Users) *Identities {
+	return &Identities{users: users}
+}
+
+func (r *Identities) CreateIdentity(ctx context.Context, identity *models.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conflicts(identity) {
+		return gorm.ErrDuplicatedKey
+	}
+	r.add(identity)
+	return nil
+}
+
+func (r *Identities) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Как и в транзакции, при конфликте пользователь не создается
+	if r.conflicts(identity) {
+		return gorm.ErrDuplicatedKey
+	}
+	if err := r.users.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	identity.UserID = user.ID
+	r.add(identity)
+	return nil
+}
+
+func (r *Identities) GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			found := *identity
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *Identities) ListIdentities(ctx context.Context, userID uint) ([]models.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var identities []models.Identity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, *identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *Identities) DeleteIdentity(ctx context.Context, userID uint, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, identity := range r.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			r.identities = append(r.identities[:i], r.identities[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *Identities) TouchIdentity(ctx context.Context, id uint, email string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.ID == id {
+			identity.Email, identity.LastLoginAt = email, &at
+		}
+	}
+	return nil
+}
+
+// conflicts повторяет уникальные индексы: (provider, subject) и (provider, user_id)
+func (r *Identities) conflicts(identity *models.Identity) bool {
+	for _, existing := range r.identities {
+		if existing.Provider != identity.Provider {
+			continue
+		}
+		if existing.Subject == identity.Subject || (identity.UserID != 0 && existing.UserID == identity.UserID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Identities) add(identity *models.Identity) {
+	r.nextID++
+	identity.ID = r.nextID
+	stored := *identity
+	r.identities = append(r.identities, &stored)
+}
@@ -0,0 +1,92 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+// Lists — repository.ListRepository в памяти. Задачи хранятся отдельно,
+// поэтому DeleteList не реализован: его вызов паникует.
+type Lists struct {
+	repository.ListRepository
+
+	mu     sync.Mutex
+	byID   map[uint]*models.List
+	nextID uint
+}
+
+func NewLists() *Lists {
+	return &Lists{byID: make(map[uint]*models.List)}
+}
+
+func (r *Lists) CreateList(ctx context.Context, list *models.List) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(list)
+	return nil
+}
+
+func (r *Lists) GetListsByUserID(ctx context.Context, userID uint) ([]*models.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lists []*models.List
+	for _, list := range r.byID {
+		if list.UserID == userID {
+			found := *list
+			lists = append(lists, &found)
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].IsInbox != lists[j].IsInbox {
+			return lists[i].IsInbox
+		}
+		return lists[i].ID < lists[j].ID
+	})
+	return lists, nil
+}
+
+func (r *Lists) GetListByID(ctx context.Context, id uint) (*models.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list, ok := r.byID[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *list
+	return &found, nil
+}
+
+func (r *Lists) GetOrCreateInbox(ctx context.Context, userID uint) (*models.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, list := range r.byID {
+		if list.UserID == userID && list.IsInbox {
+			found := *list
+			return &found, nil
+		}
+	}
+	inbox := &models.List{UserID: userID, Name: models.InboxListName, IsInbox: true, InboxOwner: &userID}
+	r.add(inbox)
+	return inbox, nil
+}
+
+func (r *Lists) UpdateList(ctx context.Context, list *models.List) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *list
+	r.byID[list.ID] = &stored
+	return nil
+}
+
+func (r *Lists) add(list *models.List) {
+	r.nextID++
+	list.ID = r.nextID
+	stored := *list
+	r.byID[list.ID] = &stored
+}
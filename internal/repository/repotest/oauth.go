@@ -0,0 +1,166 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.OAuthRepository = (*OAuth)(nil)
+
+// OAuth — repository.OAuthRepository в памяти
+type OAuth struct {
+	mu      sync.Mutex
+	clients map[string]*models.OAuthClient
+	codes   map[string]*models.OAuthCode // по хэшу
+	tokens  map[string]*models.OAuthToken
+	nextID  uint
+}
+
+func NewOAuth() *OAuth {
+	return &OAuth{
+		clients: make(map[string]*models.OAuthClient),
+		codes:   make(map[string]*models.OAuthCode),
+		tokens:  make(map[string]*models.OAuthToken),
+	}
+}
+
+func (r *OAuth) CreateOAuthClient(ctx context.Context, client *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clients[client.ClientID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.nextID++
+	client.ID = r.nextID
+	stored := *client
+	r.clients[client.ClientID] = &stored
+	return nil
+}
+
+func (r *OAuth) ListOAuthClients(ctx context.Context) ([]models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var clients []models.OAuthClient
+	for _, client := range r.clients {
+		clients = append(clients, *client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients, nil
+}
+
+func (r *OAuth) GetOAuthClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	client, ok := r.clients[clientID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *client
+	return &found, nil
+}
+
+func (r *OAuth) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clients[clientID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.clients, clientID)
+	for hash, code := range r.codes {
+		if code.ClientID == clientID {
+			delete(r.codes, hash)
+		}
+	}
+	for hash, token := range r.tokens {
+		if token.ClientID == clientID {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (r *OAuth) CreateOAuthCode(ctx context.Context, code *models.OAuthCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	code.ID = r.nextID
+	stored := *code
+	r.codes[code.CodeHash] = &stored
+	return nil
+}
+
+func (r *OAuth) GetOAuthCode(ctx context.Context, hash string) (*models.OAuthCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code, ok := r.codes[hash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *code
+	return &found, nil
+}
+
+func (r *OAuth) ConsumeOAuthCode(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, code := range r.codes {
+		if code.ID != id {
+			continue
+		}
+		if code.UsedAt == nil {
+			code.UsedAt = &at
+			return nil
+		}
+		for _, token := range r.tokens {
+			if token.CodeID == id && token.RevokedAt == nil {
+				token.RevokedAt = &at
+			}
+		}
+		return repository.ErrOAuthCodeUsed
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *OAuth) CreateOAuthToken(ctx context.Context, token *models.OAuthToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	token.ID = r.nextID
+	stored := *token
+	r.tokens[token.TokenHash] = &stored
+	return nil
+}
+
+func (r *OAuth) GetOAuthToken(ctx context.Context, hash string) (*models.OAuthToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+func (r *OAuth) DeleteExpiredOAuthGrants(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, code := range r.codes {
+		if code.ExpiresAt.Before(before) {
+			delete(r.codes, hash)
+		}
+	}
+	for hash, token := range r.tokens {
+		if token.ExpiresAt.Before(before) {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.RefreshTokenRepository = (*RefreshTokens)(nil)
+
+// RefreshTokens — repository.RefreshTokenRepository в памяти
+type RefreshTokens struct {
+	mu     sync.Mutex
+	tokens []*models.RefreshToken
+}
+
+func NewRefreshTokens() *RefreshTokens {
+	return &RefreshTokens{}
+}
+
+func (r *RefreshTokens) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *token
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+func (r *RefreshTokens) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *RefreshTokens) RotateRefreshToken(ctx context.Context, old *models.RefreshToken, next *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == old.TokenHash {
+			if token.UsedAt != nil || token.RevokedAt != nil {
+				return repository.ErrRefreshTokenUsed
+			}
+			now := time.Now()
+			token.UsedAt = &now
+		}
+	}
+	stored := *next
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+func (r *RefreshTokens) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *RefreshTokens) RevokeUserTokens(ctx context.Context, userID uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+// Package repotest содержит реализации интерфейсов repository в памяти
+// для тестов сервисов и шлюза. Они повторяют поведение PostgreSQL-реализаций
+// там, где на него опираются сервисы: gorm.ErrRecordNotFound,
+// gorm.ErrDuplicatedKey и ошибки-маркеры пакета repository.
+package repotest
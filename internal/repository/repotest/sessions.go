@@ -0,0 +1,131 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.SessionRepository = (*Sessions)(nil)
+
+// Sessions — repository.SessionRepository в памяти. Refresh-токены
+// отозванной сессии отзывает в refreshTokens; считает обращения
+// TouchSession, чтобы тесты могли проверить ограничение записей.
+type Sessions struct {
+	refreshTokens *RefreshTokens
+
+	mu      sync.Mutex
+	byID    map[string]*models.Session
+	touches map[string]int
+}
+
+func NewSessions(refreshTokens *RefreshTokens) *Sessions {
+	return &Sessions{
+		refreshTokens: refreshTokens,
+		byID:          make(map[string]*models.Session),
+		touches:       make(map[string]int),
+	}
+}
+
+func (r *Sessions) CreateSession(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[session.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	stored := *session
+	r.byID[session.ID] = &stored
+	return nil
+}
+
+func (r *Sessions) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.byID[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *session
+	return &found, nil
+}
+
+func (r *Sessions) ListSessions(ctx context.Context, userID uint, now time.Time) ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []models.Session
+	for _, session := range r.byID {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, *session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *Sessions) TouchSession(ctx context.Context, id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touches[id]++
+	session, ok := r.byID[id]
+	if !ok || session.RevokedAt != nil {
+		return false, nil
+	}
+	session.LastSeenAt = at
+	return true, nil
+}
+
+func (r *Sessions) ExtendSession(ctx context.Context, id string, at, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.byID[id]; ok && session.RevokedAt == nil {
+		session.LastSeenAt, session.ExpiresAt = at, expiresAt
+	}
+	return nil
+}
+
+func (r *Sessions) RevokeSession(ctx context.Context, userID uint, id string, at time.Time) error {
+	r.mu.Lock()
+	session, ok := r.byID[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		r.mu.Unlock()
+		return gorm.ErrRecordNotFound
+	}
+	session.RevokedAt = &at
+	r.mu.Unlock()
+	return r.refreshTokens.RevokeFamily(ctx, id, at)
+}
+
+func (r *Sessions) RevokeUserSessions(ctx context.Context, userID uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.byID {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *Sessions) DeleteEndedSessions(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.byID {
+		if session.ExpiresAt.Before(before) || (session.RevokedAt != nil && session.RevokedAt.Before(before)) {
+			delete(r.byID, id)
+		}
+	}
+	return nil
+}
+
+// TouchCount — сколько раз TouchSession обращался к сессии id
+func (r *Sessions) TouchCount(id string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.touches[id]
+}
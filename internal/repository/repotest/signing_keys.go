@@ -0,0 +1,63 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.SigningKeyRepository = (*SigningKeys)(nil)
+
+// SigningKeys — repository.SigningKeyRepository в памяти
+type SigningKeys struct {
+	mu   sync.Mutex
+	keys []*models.SigningKey
+}
+
+func NewSigningKeys() *SigningKeys {
+	return &SigningKeys{}
+}
+
+func (r *SigningKeys) ListSigningKeys(ctx context.Context) ([]*models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Ключи с одинаковым CreatedAt идут от последнего добавленного
+	keys := make([]*models.SigningKey, len(r.keys))
+	for i, key := range r.keys {
+		found := *key
+		keys[len(keys)-1-i] = &found
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (r *SigningKeys) AddSigningKey(ctx context.Context, key *models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.keys {
+		if existing.RetiredAt == nil {
+			retiredAt := key.CreatedAt
+			existing.RetiredAt = &retiredAt
+		}
+	}
+	stored := *key
+	r.keys = append(r.keys, &stored)
+	return nil
+}
+
+func (r *SigningKeys) DeleteRetiredBefore(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.keys[:0]
+	for _, key := range r.keys {
+		if key.RetiredAt == nil || !key.RetiredAt.Before(before) {
+			kept = append(kept, key)
+		}
+	}
+	r.keys = kept
+	return nil
+}
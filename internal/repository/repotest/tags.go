@@ -0,0 +1,114 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.TagRepository = (*Tags)(nil)
+
+// Tags — repository.TagRepository в памяти
+type Tags struct {
+	mu     sync.Mutex
+	byID   map[uint]*models.Tag
+	nextID uint
+}
+
+func NewTags() *Tags {
+	return &Tags{byID: make(map[uint]*models.Tag)}
+}
+
+func (r *Tags) CreateTag(ctx context.Context, tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.find(tag.UserID, tag.Name) != nil {
+		return gorm.ErrDuplicatedKey
+	}
+	r.add(tag)
+	return nil
+}
+
+func (r *Tags) GetTagsByUserID(ctx context.Context, userID uint) ([]*models.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tags []*models.Tag
+	for _, tag := range r.byID {
+		if tag.UserID == userID {
+			found := *tag
+			tags = append(tags, &found)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (r *Tags) GetTagByID(ctx context.Context, id uint) (*models.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tag, ok := r.byID[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *tag
+	return &found, nil
+}
+
+func (r *Tags) UpsertTags(ctx context.Context, userID uint, names []string) ([]models.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tags := []models.Tag{}
+	for _, name := range names {
+		tag := r.find(userID, name)
+		if tag == nil {
+			tag = &models.Tag{UserID: userID, Name: name}
+			r.add(tag)
+		}
+		tags = append(tags, *tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (r *Tags) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing := r.find(tag.UserID, tag.Name); existing != nil && existing.ID != tag.ID {
+		return gorm.ErrDuplicatedKey
+	}
+	tag.UpdatedAt = time.Now()
+	stored := *tag
+	r.byID[tag.ID] = &stored
+	return nil
+}
+
+func (r *Tags) DeleteTag(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byID, id)
+	return nil
+}
+
+func (r *Tags) find(userID uint, name string) *models.Tag {
+	for _, tag := range r.byID {
+		if tag.UserID == userID && tag.Name == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+func (r *Tags) add(tag *models.Tag) {
+	r.nextID++
+	tag.ID = r.nextID
+	now := time.Now()
+	tag.CreatedAt, tag.UpdatedAt = now, now
+	stored := *tag
+	r.byID[tag.ID] = &stored
+}
@@ -0,0 +1,221 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+// Todos — repository.TodoRepository в памяти для тестов записи задач:
+// создание, изменение, подзадачи, порядок и напоминания. Выборки
+// (ListTodos, SearchTodos, корзина) завязаны на SQL и здесь не реализованы:
+// их вызов паникует. Метки задач создаются в tags.
+type Todos struct {
+	repository.TodoRepository
+	tags *Tags
+
+	mu     sync.Mutex
+	byID   map[uint]*models.Todo
+	nextID uint
+}
+
+func NewTodos(tags *Tags) *Todos {
+	return &Todos{tags: tags, byID: make(map[uint]*models.Todo)}
+}
+
+func (r *Todos) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.create(todo)
+	return nil
+}
+
+func (r *Todos) GetTodoByID(ctx context.Context, id uint) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	todo, ok := r.byID[id]
+	if !ok || todo.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return cloneTodo(todo), nil
+}
+
+func (r *Todos) UpdateTodo(ctx context.Context, update repository.TodoUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	todo := update.Todo
+	stored, ok := r.byID[todo.ID]
+	if !ok || stored.DeletedAt.Valid || stored.Version != todo.Version {
+		return repository.ErrVersionConflict
+	}
+	if update.ReplaceTags {
+		tags, err := r.tags.UpsertTags(ctx, todo.UserID, update.TagNames)
+		if err != nil {
+			return err
+		}
+		todo.Tags = tags
+	}
+	todo.Version++
+	todo.UpdatedAt = time.Now()
+	r.byID[todo.ID] = cloneTodo(todo)
+	r.updateSubtasks(update.SubtaskIDs, update.SubtaskColumns)
+	if update.Next != nil {
+		update.Next.Tags = todo.Tags
+		r.create(update.Next)
+	}
+	return nil
+}
+
+func (r *Todos) GetDescendants(ctx context.Context, rootIDs []uint) ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var todos []*models.Todo
+	parents := rootIDs
+	for len(parents) > 0 {
+		var children []uint
+		for _, todo := range r.byID {
+			if todo.ParentID != nil && !todo.DeletedAt.Valid && containsID(parents, *todo.ParentID) {
+				todos = append(todos, cloneTodo(todo))
+				children = append(children, todo.ID)
+			}
+		}
+		parents = children
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+	return todos, nil
+}
+
+func (r *Todos) GetDueReminders(ctx context.Context, now time.Time, limit int) ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var todos []*models.Todo
+	for _, todo := range r.byID {
+		if todo.RemindAt != nil && !todo.RemindAt.After(now) && todo.RemindedAt == nil && !todo.Completed && !todo.DeletedAt.Valid {
+			todos = append(todos, cloneTodo(todo))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].RemindAt.Before(*todos[j].RemindAt) })
+	if len(todos) > limit {
+		todos = todos[:limit]
+	}
+	return todos, nil
+}
+
+func (r *Todos) MarkReminded(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if todo, ok := r.byID[id]; ok {
+		todo.RemindedAt = &at
+	}
+	return nil
+}
+
+func (r *Todos) GetLastPosition(ctx context.Context, userID, listID uint) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var last string
+	for _, todo := range r.byID {
+		if todo.UserID == userID && todo.ListID == listID && !todo.DeletedAt.Valid && todo.Position > last {
+			last = todo.Position
+		}
+	}
+	return last, nil
+}
+
+func (r *Todos) GetNeighborPosition(ctx context.Context, userID, listID uint, key string, next bool, exclude []uint) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var neighbor string
+	found := false
+	for _, todo := range r.byID {
+		if todo.UserID != userID || todo.ListID != listID || todo.DeletedAt.Valid || containsID(exclude, todo.ID) {
+			continue
+		}
+		if next && todo.Position >= key && (!found || todo.Position < neighbor) ||
+			!next && todo.Position <= key && (!found || todo.Position > neighbor) {
+			neighbor, found = todo.Position, true
+		}
+	}
+	return neighbor, found, nil
+}
+
+// Todo возвращает сохраненную задачу как есть, в том числе удаленную;
+// nil, если ее нет
+func (r *Todos) Todo(id uint) *models.Todo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	todo, ok := r.byID[id]
+	if !ok {
+		return nil
+	}
+	return cloneTodo(todo)
+}
+
+// UserTodos возвращает все неудаленные задачи пользователя по возрастанию id
+func (r *Todos) UserTodos(userID uint) []*models.Todo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var todos []*models.Todo
+	for _, todo := range r.byID {
+		if todo.UserID == userID && !todo.DeletedAt.Valid {
+			todos = append(todos, cloneTodo(todo))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+	return todos
+}
+
+func (r *Todos) create(todo *models.Todo) {
+	r.nextID++
+	todo.ID = r.nextID
+	now := time.Now()
+	todo.CreatedAt, todo.UpdatedAt = now, now
+	if todo.Version == 0 {
+		todo.Version = 1
+	}
+	r.byID[todo.ID] = cloneTodo(todo)
+}
+
+func (r *Todos) updateSubtasks(ids []uint, columns map[string]interface{}) {
+	if len(columns) == 0 {
+		return
+	}
+	for _, id := range ids {
+		todo, ok := r.byID[id]
+		if !ok {
+			continue
+		}
+		todo.Version++
+		for column, value := range columns {
+			switch column {
+			case "list_id":
+				todo.ListID = value.(uint)
+			case "completed":
+				todo.Completed = value.(bool)
+			default:
+				panic("repotest: unsupported subtask column " + column)
+			}
+		}
+	}
+}
+
+func cloneTodo(todo *models.Todo) *models.Todo {
+	clone := *todo
+	clone.Tags = append([]models.Tag(nil), todo.Tags...)
+	return &clone
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
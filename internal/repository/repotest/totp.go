@@ -0,0 +1,100 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.TOTPRepository = (*TOTP)(nil)
+
+// TOTP — repository.TOTPRepository в памяти
+type TOTP struct {
+	mu          sync.Mutex
+	credentials map[uint]*models.TOTPCredential
+	codes       []*models.RecoveryCode
+}
+
+func NewTOTP() *TOTP {
+	return &TOTP{credentials: make(map[uint]*models.TOTPCredential)}
+}
+
+func (r *TOTP) GetTOTPCredential(ctx context.Context, userID uint) (*models.TOTPCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.credentials[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *credential
+	return &found, nil
+}
+
+func (r *TOTP) SaveTOTPCredential(ctx context.Context, credential *models.TOTPCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *credential
+	r.credentials[credential.UserID] = &stored
+	return nil
+}
+
+func (r *TOTP) ConfirmTOTPCredential(ctx context.Context, userID uint, counter uint64, at time.Time, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.credentials[userID]
+	if !ok || credential.ConfirmedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	credential.ConfirmedAt, credential.LastCounter = &at, counter
+	r.deleteCodes(userID)
+	for _, hash := range codeHashes {
+		r.codes = append(r.codes, &models.RecoveryCode{UserID: userID, CodeHash: hash, CreatedAt: at})
+	}
+	return nil
+}
+
+func (r *TOTP) AdvanceTOTPCounter(ctx context.Context, userID uint, counter uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.credentials[userID]
+	if !ok || credential.LastCounter >= counter {
+		return repository.ErrTOTPCodeUsed
+	}
+	credential.LastCounter = counter
+	return nil
+}
+
+func (r *TOTP) UseRecoveryCode(ctx context.Context, userID uint, codeHash string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, code := range r.codes {
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			code.UsedAt = &at
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *TOTP) DeleteTOTP(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteCodes(userID)
+	delete(r.credentials, userID)
+	return nil
+}
+
+func (r *TOTP) deleteCodes(userID uint) {
+	kept := r.codes[:0]
+	for _, code := range r.codes {
+		if code.UserID != userID {
+			kept = append(kept, code)
+		}
+	}
+	r.codes = kept
+}
@@ -0,0 +1,90 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.UserTokenRepository = (*UserTokens)(nil)
+
+// UserTokens — repository.UserTokenRepository в памяти
+type UserTokens struct {
+	mu     sync.Mutex
+	tokens []*models.UserToken
+}
+
+func NewUserTokens() *UserTokens {
+	return &UserTokens{}
+}
+
+func (r *UserTokens) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = uint(len(r.tokens) + 1)
+	stored := *token
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+func (r *UserTokens) ConsumeUserToken(ctx context.Context, purpose, hash string, at time.Time) (*models.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token := r.active(purpose, hash, at)
+	if token == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	token.UsedAt = &at
+	found := *token
+	return &found, nil
+}
+
+func (r *UserTokens) InvalidateUserTokens(ctx context.Context, userID uint, purpose string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *UserTokens) GetActiveUserToken(ctx context.Context, purpose, hash string, at time.Time) (*models.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token := r.active(purpose, hash, at)
+	if token == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+func (r *UserTokens) RecordUserTokenFailure(ctx context.Context, id uint, maxAttempts int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.ID == id && token.UsedAt == nil {
+			token.Attempts++
+			if token.Attempts >= maxAttempts {
+				token.UsedAt = &at
+			}
+		}
+	}
+	return nil
+}
+
+func (r *UserTokens) active(purpose, hash string, at time.Time) *models.UserToken {
+	for _, token := range r.tokens {
+		if token.TokenHash == hash && token.Purpose == purpose && token.UsedAt == nil && at.Before(token.ExpiresAt) {
+			return token
+		}
+	}
+	return nil
+}
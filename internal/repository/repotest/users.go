@@ -0,0 +1,128 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+var _ repository.UserRepository = (*Users)(nil)
+
+// Users — repository.UserRepository в памяти
+type Users struct {
+	mu     sync.Mutex
+	byID   map[uint]*models.User
+	nextID uint
+}
+
+func NewUsers() *Users {
+	return &Users{byID: make(map[uint]*models.User)}
+}
+
+func (r *Users) CreateUser(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.byID {
+		if existing.Email == user.Email {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt = time.Now()
+	stored := *user
+	r.byID[user.ID] = &stored
+	return nil
+}
+
+func (r *Users) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.byID {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *Users) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *Users) UpdateUserRole(ctx context.Context, id uint, role string) error {
+	return r.Update(id, func(user *models.User) { user.Role = role })
+}
+
+func (r *Users) ListUsers(ctx context.Context, q repository.UserQuery) ([]*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*models.User
+	for _, user := range r.byID {
+		if user.ID <= q.AfterID {
+			continue
+		}
+		if q.EmailContains != "" && !strings.Contains(strings.ToLower(user.Email), strings.ToLower(q.EmailContains)) {
+			continue
+		}
+		found := *user
+		users = append(users, &found)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	if q.Limit > 0 && len(users) > q.Limit {
+		users = users[:q.Limit]
+	}
+	return users, nil
+}
+
+func (r *Users) SetUserDisabled(ctx context.Context, id uint, disabledAt *time.Time) error {
+	return r.Update(id, func(user *models.User) { user.DisabledAt = disabledAt })
+}
+
+func (r *Users) UpdateUserPassword(ctx context.Context, id uint, passwordHash string) error {
+	return r.Update(id, func(user *models.User) { user.Password = passwordHash })
+}
+
+func (r *Users) MarkEmailVerified(ctx context.Context, id uint) error {
+	return r.Update(id, func(user *models.User) { user.EmailVerified = true })
+}
+
+// DeleteUser удаляет только самого пользователя: остальные хранилища
+// в памяти ничего о нем не знают
+func (r *Users) DeleteUser(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.byID, id)
+	return nil
+}
+
+// Update меняет сохраненного пользователя; тесты готовят через него
+// состояние, до которого не дойти через API
+func (r *Users) Update(id uint, change func(*models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.byID[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	change(user)
+	return nil
+}
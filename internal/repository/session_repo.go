@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSession(ctx context.Context, id string) (*models.Session, error)
+	// ListSessions возвращает неотозванные и неистекшие сессии пользователя,
+	// недавно активные первыми
+	ListSessions(ctx context.Context, userID uint, now time.Time) ([]models.Session, error)
+	// TouchSession отмечает активность сессии и сообщает, действует ли она.
+	// false — сессия отозвана или ее нет.
+	TouchSession(ctx context.Context, id string, at time.Time) (bool, error)
+	// ExtendSession продлевает сессию при обмене refresh-токена
+	ExtendSession(ctx context.Context, id string, at, expiresAt time.Time) error
+	// RevokeSession отзывает сессию пользователя вместе с ее refresh-токенами;
+	// gorm.ErrRecordNotFound, если такой действующей сессии у него нет
+	RevokeSession(ctx context.Context, userID uint, id string, at time.Time) error
+	// RevokeUserSessions отзывает все сессии пользователя. Refresh-токены
+	// отзываются отдельно (RefreshTokenRepository.RevokeUserTokens).
+	RevokeUserSessions(ctx context.Context, userID uint, at time.Time) error
+	// DeleteEndedSessions удаляет сессии, истекшие или отозванные раньше before
+	DeleteEndedSessions(ctx context.Context, before time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListSessions(ctx context.Context, userID uint, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) TouchSession(ctx context.Context, id string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("last_seen_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sessionRepository) ExtendSession(ctx context.Context, id string, at, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"last_seen_at": at, "expires_at": expiresAt}).Error
+}
+
+func (r *sessionRepository) RevokeSession(ctx context.Context, userID uint, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+			Update("revoked_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", at).Error
+	})
+}
+
+func (r *sessionRepository) RevokeUserSessions(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+func (r *sessionRepository) DeleteEndedSessions(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("expires_at < ? OR revoked_at < ?", before, before).
+		Delete(&models.Session{}).Error
+}
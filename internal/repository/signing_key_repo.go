@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+type SigningKeyRepository interface {
+	// ListSigningKeys возвращает все ключи, от новых к старым
+	ListSigningKeys(ctx context.Context) ([]*models.SigningKey, error)
+	// AddSigningKey сохраняет новый ключ и выводит из оборота все прежние
+	AddSigningKey(ctx context.Context, key *models.SigningKey) error
+	// DeleteRetiredBefore удаляет ключи, выведенные из оборота раньше before
+	DeleteRetiredBefore(ctx context.Context, before time.Time) error
+}
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+func (r *signingKeyRepository) ListSigningKeys(ctx context.Context) ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *signingKeyRepository) AddSigningKey(ctx context.Context, key *models.SigningKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.SigningKey{}).
+			Where("retired_at IS NULL").
+			Update("retired_at", key.CreatedAt).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+}
+
+func (r *signingKeyRepository) DeleteRetiredBefore(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("retired_at < ?", before).Delete(&models.SigningKey{}).Error
+}
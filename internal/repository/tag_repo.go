@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/internal/models"
+)
+
+type TagRepository interface {
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	GetTagsByUserID(ctx context.Context, userID uint) ([]*models.Tag, error)
+	GetTagByID(ctx context.Context, id uint) (*models.Tag, error)
+	// UpsertTags создает недостающие метки пользователя и возвращает все
+	// метки с указанными именами
+	UpsertTags(ctx context.Context, userID uint, names []string) ([]models.Tag, error)
+	UpdateTag(ctx context.Context, tag *models.Tag) error
+	DeleteTag(ctx context.Context, id uint) error
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) CreateTag(ctx context.Context, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Create(tag).Error
+}
+
+func (r *tagRepository) GetTagsByUserID(ctx context.Context, userID uint) ([]*models.Tag, error) {
+	var tags []*models.Tag
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) GetTagByID(ctx context.Context, id uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.WithContext(ctx).First(&tag, id).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) UpsertTags(ctx context.Context, userID uint, names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		tags, err = upsertTags(tx, userID, names)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// upsertTags создает недостающие метки в транзакции tx и возвращает все
+// метки с указанными именами
+func upsertTags(tx *gorm.DB, userID uint, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	newTags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		newTags = append(newTags, models.Tag{UserID: userID, Name: name})
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(&newTags).Error
+	if err != nil {
+		return nil, err
+	}
+	var tags []models.Tag
+	if err := tx.Where("user_id = ? AND name IN ?", userID, names).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Save(tag).Error
+}
+
+// DeleteTag удаляет метку и снимает ее со всех задач
+func (r *tagRepository) DeleteTag(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM todo_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, id).Error
+	})
+}
@@ -2,18 +2,113 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"server/internal/models"
+	"server/internal/position"
+)
+
+// TodoSortField — колонка, по которой сортируется выборка задач
+type TodoSortField string
+
+const (
+	TodoSortByCreated  TodoSortField = "created_at"
+	TodoSortByUpdated  TodoSortField = "updated_at"
+	TodoSortByTitle    TodoSortField = "title"
+	TodoSortByPriority TodoSortField = "priority"
+	TodoSortByPosition TodoSortField = "position"
+	// Только для корзины: от недавно удаленных к давним
+	TodoSortByDeleted TodoSortField = "deleted_at"
 )
 
+// TodoCursor указывает на последнюю задачу предыдущей страницы.
+// Заполняется только то поле, по которому идет сортировка.
+type TodoCursor struct {
+	Time time.Time // created_at, updated_at, deleted_at
+	Text string    // title, position
+	Int  int       // priority
+	ID   uint
+}
+
+// TodoQuery описывает фильтры, сортировку и окно выборки для ListTodos
+type TodoQuery struct {
+	UserID        uint
+	ListID        uint // 0 — все списки
+	RootsOnly     bool // только задачи без родителя
+	Completed     *bool
+	TitleContains string
+	DueFrom       *time.Time // due_at >= DueFrom
+	DueBefore     *time.Time // due_at < DueBefore
+	AnyTags       []string   // есть хотя бы одна из меток
+	AllTags       []string   // есть все метки
+	SortBy        TodoSortField
+	Descending    bool
+	After         *TodoCursor
+	Limit         int
+}
+
 type TodoRepository interface {
 	CreateTodo(ctx context.Context, todo *models.Todo) error
 	GetTodosByUserID(ctx context.Context, userID uint) ([]*models.Todo, error)
+	ListTodos(ctx context.Context, q TodoQuery) ([]*models.Todo, int64, error)
 	GetTodoByID(ctx context.Context, id uint) (*models.Todo, error)
-	UpdateTodo(ctx context.Context, todo *models.Todo) error
-	DeleteTodo(ctx context.Context, id uint) error
+	UpdateTodo(ctx context.Context, update TodoUpdate) error
+	// DeleteTodo переносит задачу вместе со всеми подзадачами в корзину
+	DeleteTodo(ctx context.Context, id uint, version uint64) error
+	// ListTrash возвращает задачи из корзины, удаленные самостоятельно, а не
+	// вместе с родителем, от недавно удаленных к давним
+	ListTrash(ctx context.Context, userID uint, after *TodoCursor, limit int) ([]*models.Todo, error)
+	// GetTrashedTodo ищет задачу только среди удаленных
+	GetTrashedTodo(ctx context.Context, id uint) (*models.Todo, error)
+	// RestoreTodo возвращает из корзины задачу и подзадачи, удаленные вместе
+	// с ней, в список listID
+	RestoreTodo(ctx context.Context, todo *models.Todo, listID uint) error
+	// PurgeTodo окончательно удаляет задачу из корзины вместе с подзадачами
+	PurgeTodo(ctx context.Context, id uint) error
+	// PurgeDeletedBefore окончательно удаляет до limit задач, попавших
+	// в корзину раньше before, и возвращает их количество
+	PurgeDeletedBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+	// SearchTodos ищет задачи пользователя по словам запроса, от самых
+	// релевантных к менее релевантным
+	SearchTodos(ctx context.Context, q TodoSearchQuery) ([]*TodoSearchResult, error)
+	// GetDescendants возвращает все подзадачи (на любой глубине) задач rootIDs
+	GetDescendants(ctx context.Context, rootIDs []uint) ([]*models.Todo, error)
+	GetDueReminders(ctx context.Context, now time.Time, limit int) ([]*models.Todo, error)
+	MarkReminded(ctx context.Context, id uint, at time.Time) error
+	// GetLastPosition возвращает наибольший ключ порядка в списке
+	GetLastPosition(ctx context.Context, userID, listID uint) (string, error)
+	// GetNeighborPosition возвращает ближайший к key ключ порядка в списке:
+	// следующий (>= key), если next, иначе предыдущий (<= key).
+	// Задачи exclude не учитываются. found == false, если соседа нет.
+	GetNeighborPosition(ctx context.Context, userID, listID uint, key string, next bool, exclude []uint) (neighbor string, found bool, err error)
+	// RebalancePositions заново раздает ключи порядка задачам списка,
+	// сохраняя их текущий порядок. Версии задач не меняются: порядок
+	// остается прежним, меняется только его представление.
+	RebalancePositions(ctx context.Context, userID, listID uint) error
+}
+
+// TodoUpdate — изменение задачи, которое UpdateTodo сохраняет одной транзакцией
+type TodoUpdate struct {
+	Todo *models.Todo
+	// ReplaceTags — заменить метки задачи метками TagNames, создав
+	// недостающие. Иначе сохраняются метки из Todo.Tags.
+	ReplaceTags bool
+	TagNames    []string
+	// SubtaskColumns меняются у подзадач SubtaskIDs, их версии увеличиваются
+	SubtaskIDs     []uint
+	SubtaskColumns map[string]interface{}
+	// Next — следующее вхождение повторяющейся задачи; создается с ее метками
+	Next *models.Todo
 }
 
+// ErrVersionConflict возвращается, если запись изменили после того,
+// как ее прочитал вызывающий код
+var ErrVersionConflict = errors.New("todo was modified concurrently")
+
 type todoRepository struct {
 	db *gorm.DB
 }
@@ -34,18 +129,398 @@ func (r *todoRepository) GetTodosByUserID(ctx context.Context, userID uint) ([]*
 	return todos, nil
 }
 
+// ListTodos возвращает одну страницу задач пользователя и общее количество
+// задач, подходящих под фильтры. Пагинация курсорная: следующая страница
+// начинается строго после (значение сортировки, id) из q.After.
+func (r *todoRepository) ListTodos(ctx context.Context, q TodoQuery) ([]*models.Todo, int64, error) {
+	base := r.db.WithContext(ctx).Model(&models.Todo{}).Where("user_id = ?", q.UserID)
+	if q.ListID != 0 {
+		base = base.Where("list_id = ?", q.ListID)
+	}
+	if q.RootsOnly {
+		base = base.Where("parent_id IS NULL")
+	}
+	if q.Completed != nil {
+		base = base.Where("completed = ?", *q.Completed)
+	}
+	if q.TitleContains != "" {
+		base = base.Where("LOWER(title) LIKE ?", "%"+escapeLike(strings.ToLower(q.TitleContains))+"%")
+	}
+	if q.DueFrom != nil {
+		base = base.Where("due_at >= ?", *q.DueFrom)
+	}
+	if q.DueBefore != nil {
+		base = base.Where("due_at < ?", *q.DueBefore)
+	}
+
+	// Фильтры по меткам — подзапросы по todo_tags в том же SQL-запросе
+	if len(q.AnyTags) > 0 {
+		base = base.Where("id IN (?)", r.taggedTodoIDs(q.UserID, q.AnyTags))
+	}
+	if len(q.AllTags) > 0 {
+		names := uniqueStrings(q.AllTags)
+		base = base.Where("id IN (?)", r.taggedTodoIDs(q.UserID, names).
+			Group("todo_tags.todo_id").
+			Having("COUNT(DISTINCT todo_tags.tag_id) = ?", len(names)))
+	}
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = TodoSortByCreated
+	}
+	dir, cmp := "ASC", ">"
+	if q.Descending {
+		dir, cmp = "DESC", "<"
+	}
+
+	column := string(sortBy)
+	if sortBy == TodoSortByPosition {
+		column = positionColumn
+	}
+
+	page := base.Session(&gorm.Session{})
+	if q.After != nil {
+		var value interface{} = q.After.Time
+		switch sortBy {
+		case TodoSortByTitle, TodoSortByPosition:
+			value = q.After.Text
+		case TodoSortByPriority:
+			value = q.After.Int
+		}
+		page = page.Where("("+column+", id) "+cmp+" (?, ?)", value, q.After.ID)
+	}
+
+	var todos []*models.Todo
+	err := page.Preload("Tags", orderTagsByName).
+		Order(column + " " + dir).Order("id " + dir).
+		Limit(q.Limit).
+		Find(&todos).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return todos, total, nil
+}
+
 func (r *todoRepository) GetTodoByID(ctx context.Context, id uint) (*models.Todo, error) {
 	var todo models.Todo
-	if err := r.db.WithContext(ctx).First(&todo, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Tags", orderTagsByName).First(&todo, id).Error; err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// UpdateTodo сохраняет задачу, только если ее версия в базе все еще равна
+// update.Todo.Version, и увеличивает версию на единицу. Метки задачи
+// заменяются на Todo.Tags или, при ReplaceTags, на метки TagNames.
+// Подзадачи и следующее вхождение сохраняются в той же транзакции.
+func (r *todoRepository) UpdateTodo(ctx context.Context, update TodoUpdate) error {
+	todo := update.Todo
+	expected := todo.Version
+	todo.Version++
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(todo).Where("version = ?", expected).Select("*").Omit(clause.Associations).Updates(todo)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		if update.ReplaceTags {
+			tags, err := upsertTags(tx, todo.UserID, update.TagNames)
+			if err != nil {
+				return err
+			}
+			todo.Tags = tags
+		}
+		if err := tx.Model(todo).Association("Tags").Replace(todo.Tags); err != nil {
+			return err
+		}
+		if err := updateSubtasks(tx, update.SubtaskIDs, update.SubtaskColumns); err != nil {
+			return err
+		}
+		if update.Next != nil {
+			update.Next.Tags = todo.Tags
+			return tx.Create(update.Next).Error
+		}
+		return nil
+	})
+	if err != nil {
+		todo.Version = expected
+		return err
+	}
+	return nil
+}
+
+// DeleteTodo удаляет задачу, только если ее версия в базе равна version.
+// Подзадачи удаляются в той же транзакции и с тем же deleted_at:
+// по нему RestoreTodo находит, что восстанавливать вместе с задачей.
+func (r *todoRepository) DeleteTodo(ctx context.Context, id uint, version uint64) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Todo{}).Where("id = ? AND version = ?", id, version).UpdateColumn("deleted_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		return tx.Model(&models.Todo{}).Where("id IN (?)", descendantIDs(tx, []uint{id})).UpdateColumn("deleted_at", now).Error
+	})
+}
+
+func (r *todoRepository) ListTrash(ctx context.Context, userID uint, after *TodoCursor, limit int) ([]*models.Todo, error) {
+	q := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Where(`parent_id IS NULL OR NOT EXISTS (
+			SELECT 1 FROM todos p WHERE p.id = todos.parent_id AND p.deleted_at = todos.deleted_at)`)
+	if after != nil {
+		q = q.Where("(deleted_at, id) < (?, ?)", after.Time, after.ID)
+	}
+	var todos []*models.Todo
+	err := q.Preload("Tags", orderTagsByName).
+		Order("deleted_at DESC").Order("id DESC").
+		Limit(limit).
+		Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) GetTrashedTodo(ctx context.Context, id uint) (*models.Todo, error) {
+	var todo models.Todo
+	err := r.db.WithContext(ctx).Unscoped().Preload("Tags", orderTagsByName).
+		Where("deleted_at IS NOT NULL").
+		First(&todo, id).Error
+	if err != nil {
 		return nil, err
 	}
 	return &todo, nil
 }
 
-func (r *todoRepository) UpdateTodo(ctx context.Context, todo *models.Todo) error {
-	return r.db.WithContext(ctx).Save(todo).Error
+func (r *todoRepository) RestoreTodo(ctx context.Context, todo *models.Todo, listID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Подзадачи выбираются до восстановления корня, пока deleted_at
+		// еще связывает их с ним
+		err := tx.Unscoped().Model(&models.Todo{}).Where("id IN (?)", trashedSubtreeIDs(tx, todo)).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"list_id":    listID,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Model(&models.Todo{}).Where("id = ?", todo.ID).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"list_id":    listID,
+			"position":   todo.Position,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		todo.DeletedAt = gorm.DeletedAt{}
+		todo.ListID = listID
+		todo.Version++
+		return nil
+	})
+}
+
+func (r *todoRepository) PurgeTodo(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uint{id}
+		if err := tx.Raw(`WITH RECURSIVE subtree AS (
+			SELECT id FROM todos WHERE parent_id = ?
+			UNION ALL
+			SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id
+		) SELECT id FROM subtree`, id).Scan(&ids).Error; err != nil {
+			return err
+		}
+		return hardDeleteTodos(tx, append(ids, id))
+	})
+}
+
+func (r *todoRepository) PurgeDeletedBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Unscoped().Model(&models.Todo{}).
+			Where("deleted_at < ?", before).
+			Order("deleted_at").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		purged = int64(len(ids))
+		return hardDeleteTodos(tx, ids)
+	})
+	return purged, err
+}
+
+func (r *todoRepository) GetDescendants(ctx context.Context, rootIDs []uint) ([]*models.Todo, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx)
+	var todos []*models.Todo
+	err := db.Preload("Tags", orderTagsByName).
+		Where("id IN (?)", descendantIDs(db, rootIDs)).
+		Order("created_at").Order("id").
+		Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// updateSubtasks меняет колонки у подзадач ids, увеличивая их версии
+func updateSubtasks(tx *gorm.DB, ids []uint, columns map[string]interface{}) error {
+	if len(ids) == 0 || len(columns) == 0 {
+		return nil
+	}
+	updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	for column, value := range columns {
+		updates[column] = value
+	}
+	return tx.Model(&models.Todo{}).Where("id IN ?", ids).Updates(updates).Error
 }
 
-func (r *todoRepository) DeleteTodo(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.Todo{}, id).Error
-}
\ No newline at end of file
+// GetDueReminders возвращает незавершенные задачи, у которых наступило
+// время напоминания и напоминание еще не отправлялось
+func (r *todoRepository) GetDueReminders(ctx context.Context, now time.Time, limit int) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	err := r.db.WithContext(ctx).
+		Where("remind_at <= ? AND reminded_at IS NULL AND completed = ?", now, false).
+		Order("remind_at").
+		Limit(limit).
+		Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// MarkReminded отмечает напоминание отправленным. Служебное поле, поэтому
+// версия и updated_at не меняются.
+func (r *todoRepository) MarkReminded(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Todo{}).Where("id = ?", id).UpdateColumn("reminded_at", at).Error
+}
+
+func (r *todoRepository) GetLastPosition(ctx context.Context, userID, listID uint) (string, error) {
+	var last *string
+	err := r.db.WithContext(ctx).Model(&models.Todo{}).
+		Where("user_id = ? AND list_id = ?", userID, listID).
+		Select("MAX(" + positionColumn + ")").
+		Scan(&last).Error
+	if err != nil || last == nil {
+		return "", err
+	}
+	return *last, nil
+}
+
+func (r *todoRepository) GetNeighborPosition(ctx context.Context, userID, listID uint, key string, next bool, exclude []uint) (string, bool, error) {
+	cmp, dir := ">=", "ASC"
+	if !next {
+		cmp, dir = "<=", "DESC"
+	}
+	q := r.db.WithContext(ctx).Model(&models.Todo{}).
+		Where("user_id = ? AND list_id = ?", userID, listID).
+		Where(positionColumn+" "+cmp+" ?", key)
+	if len(exclude) > 0 {
+		q = q.Where("id NOT IN ?", exclude)
+	}
+	var keys []string
+	err := q.Order(positionColumn+" "+dir).Limit(1).Pluck("position", &keys).Error
+	if err != nil || len(keys) == 0 {
+		return "", false, err
+	}
+	return keys[0], true, nil
+}
+
+func (r *todoRepository) RebalancePositions(ctx context.Context, userID, listID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var todos []*models.Todo
+		err := tx.Select("id").
+			Where("user_id = ? AND list_id = ?", userID, listID).
+			Order(positionColumn).Order("created_at").Order("id").
+			Find(&todos).Error
+		if err != nil {
+			return err
+		}
+		for i, key := range position.Spread(len(todos)) {
+			err := tx.Model(&models.Todo{}).Where("id = ?", todos[i].ID).UpdateColumn("position", key).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// positionColumn сравнивает ключи порядка побайтно: пакет position
+// рассчитывает на порядок ASCII, а не на правила сортировки базы
+const positionColumn = `position COLLATE "C"`
+
+// descendantIDs — рекурсивный подзапрос ID всех неудаленных подзадач rootIDs
+func descendantIDs(db *gorm.DB, rootIDs []uint) *gorm.DB {
+	return db.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM todos WHERE parent_id IN ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+	) SELECT id FROM subtree`, rootIDs)
+}
+
+// trashedSubtreeIDs — рекурсивный подзапрос ID подзадач, удаленных вместе с todo
+func trashedSubtreeIDs(db *gorm.DB, todo *models.Todo) *gorm.DB {
+	return db.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM todos WHERE parent_id = ? AND deleted_at = ?
+		UNION ALL
+		SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at = ?
+	) SELECT id FROM subtree`, todo.ID, todo.DeletedAt.Time, todo.DeletedAt.Time)
+}
+
+// hardDeleteTodos удаляет строки задач и их связи с метками
+func hardDeleteTodos(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM todo_tags WHERE todo_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Todo{}).Error
+}
+
+// taggedTodoIDs — подзапрос ID задач, помеченных любой из меток names
+func (r *todoRepository) taggedTodoIDs(userID uint, names []string) *gorm.DB {
+	return r.db.Table("todo_tags").
+		Select("todo_tags.todo_id").
+		Joins("JOIN tags ON tags.id = todo_tags.tag_id").
+		Where("tags.user_id = ? AND tags.name IN ?", userID, names)
+}
+
+func orderTagsByName(db *gorm.DB) *gorm.DB {
+	return db.Order("name")
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE в пользовательском вводе
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+// Полнотекстовый поиск по задачам. На PostgreSQL он опирается на
+// генерируемую колонку search_vector с GIN-индексом (см. MigrateTodoSearch),
+// на остальных СУБД — на регистронезависимое сравнение подстрок.
+
+// searchLanguage — конфигурация текстового поиска PostgreSQL, задающая
+// стемминг. Зашита в выражение генерируемой колонки: после смены
+// колонку нужно пересоздать.
+const searchLanguage = "english"
+
+// Маркеры, которыми в сниппете выделяются найденные слова
+const (
+	HighlightStart = "<b>"
+	HighlightStop  = "</b>"
+)
+
+// escapedTitle — название, экранированное для HTML так же, как
+// html.EscapeString. Экранируется до ts_headline, чтобы разметкой в сниппете
+// были только маркеры: название задает пользователь.
+const escapedTitle = `replace(replace(replace(replace(replace(todos.title,
+	'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+
+// fallbackSearchLimit ограничивает число кандидатов, которые запасная
+// реализация ранжирует в памяти
+const fallbackSearchLimit = 1000
+
+// TodoSearchQuery описывает поисковый запрос. Terms — слова запроса,
+// каждое ищется как префикс; задача должна содержать все слова.
+type TodoSearchQuery struct {
+	UserID uint
+	Terms  []string
+	Limit  int
+}
+
+// TodoSearchResult — найденная задача с релевантностью и сниппетом.
+// Сниппет — название, экранированное для HTML: разметкой в нем могут
+// быть только маркеры выделения.
+type TodoSearchResult struct {
+	Todo    *models.Todo
+	Rank    float64
+	Snippet string
+}
+
+// MigrateTodoSearch добавляет колонку search_vector и индекс для нее.
+// Вызывается после AutoMigrate; на других СУБД ничего не делает.
+func MigrateTodoSearch(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	err := db.Exec(`ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('` + searchLanguage + `', coalesce(title, ''))) STORED`).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_todos_search_vector ON todos USING GIN (search_vector)").Error
+}
+
+// SearchTerms разбивает текст запроса на слова: все, кроме букв и цифр,
+// считается разделителем. Так в tsquery не попадают операторы.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+func (r *todoRepository) SearchTodos(ctx context.Context, q TodoSearchQuery) ([]*TodoSearchResult, error) {
+	if len(q.Terms) == 0 {
+		return nil, nil
+	}
+	if r.db.Dialector.Name() != "postgres" {
+		return r.searchTodosFallback(ctx, q)
+	}
+
+	// "buy milk" -> "buy:* & milk:*": все слова, каждое как префикс
+	prefixes := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		prefixes[i] = term + ":*"
+	}
+	tsquery := strings.Join(prefixes, " & ")
+
+	var hits []struct {
+		ID      uint
+		Rank    float64
+		Snippet string
+	}
+	err := r.db.WithContext(ctx).Raw(`SELECT todos.id,
+			ts_rank(todos.search_vector, query) AS rank,
+			ts_headline('`+searchLanguage+`', `+escapedTitle+`, query, ?) AS snippet
+		FROM todos, to_tsquery('`+searchLanguage+`', ?) AS query
+		WHERE todos.user_id = ? AND todos.deleted_at IS NULL AND todos.search_vector @@ query
+		ORDER BY rank DESC, todos.id DESC
+		LIMIT ?`,
+		"StartSel="+HighlightStart+", StopSel="+HighlightStop+", HighlightAll=true",
+		tsquery, q.UserID, q.Limit,
+	).Scan(&hits).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var todos []*models.Todo
+	if err := r.db.WithContext(ctx).Preload("Tags", orderTagsByName).Find(&todos, ids).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	results := make([]*TodoSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		// Задачу могли удалить между запросами
+		if todo, ok := byID[hit.ID]; ok {
+			results = append(results, &TodoSearchResult{Todo: todo, Rank: hit.Rank, Snippet: hit.Snippet})
+		}
+	}
+	return results, nil
+}
+
+// searchTodosFallback ищет задачи, в названии которых встречаются все слова
+// запроса (аналог ILIKE), и ранжирует их в памяти: совпадение с началом
+// слова ценится выше совпадения в его середине. Стемминга здесь нет.
+func (r *todoRepository) searchTodosFallback(ctx context.Context, q TodoSearchQuery) ([]*TodoSearchResult, error) {
+	db := r.db.WithContext(ctx).Where("user_id = ?", q.UserID)
+	for _, term := range q.Terms {
+		db = db.Where("LOWER(title) LIKE ?", "%"+escapeLike(term)+"%")
+	}
+	var todos []*models.Todo
+	err := db.Preload("Tags", orderTagsByName).
+		Order("id DESC").
+		Limit(fallbackSearchLimit).
+		Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*TodoSearchResult, 0, len(todos))
+	for _, todo := range todos {
+		rank, snippet := rankTitle(todo.Title, q.Terms)
+		results = append(results, &TodoSearchResult{Todo: todo, Rank: rank, Snippet: snippet})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// rankTitle оценивает совпадение слов запроса с названием и выделяет
+// найденные слова маркерами в экранированном для HTML названии
+func rankTitle(title string, terms []string) (float64, string) {
+	var rank float64
+	var b strings.Builder
+	for i, word := range strings.Fields(title) {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		lower := strings.ToLower(word)
+		matched := false
+		for _, term := range terms {
+			if strings.HasPrefix(strings.TrimLeftFunc(lower, isSeparator), term) {
+				rank++
+				matched = true
+			} else if strings.Contains(lower, term) {
+				rank += 0.5
+				matched = true
+			}
+		}
+		if matched {
+			b.WriteString(HighlightStart + html.EscapeString(word) + HighlightStop)
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+	}
+	return rank / float64(len(terms)), b.String()
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
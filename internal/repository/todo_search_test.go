@@ -0,0 +1,26 @@
+package repository
+
+import "testing"
+
+func TestRankTitleEscapesHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		terms []string
+		want  string
+	}{
+		{"plain", "buy milk", []string{"milk"}, "buy <b>milk</b>"},
+		{"markup in unmatched word", `<img src=x onerror="alert(1)"> milk`, []string{"milk"},
+			"&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <b>milk</b>"},
+		{"markup in matched word", "<script>milk</script>", []string{"milk"},
+			"<b>&lt;script&gt;milk&lt;/script&gt;</b>"},
+		{"ampersand and quote", "Tom's & Jerry's", []string{"jerry"}, "Tom&#39;s &amp; <b>Jerry&#39;s</b>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := rankTitle(tt.title, tt.terms); got != tt.want {
+				t.Errorf("snippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
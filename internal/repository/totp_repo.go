@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+// ErrTOTPCodeUsed возвращается, если код этого или более позднего
+// временного шага уже был принят
+var ErrTOTPCodeUsed = errors.New("totp code was already used")
+
+type TOTPRepository interface {
+	GetTOTPCredential(ctx context.Context, userID uint) (*models.TOTPCredential, error)
+	// SaveTOTPCredential создает или заменяет секрет пользователя
+	SaveTOTPCredential(ctx context.Context, credential *models.TOTPCredential) error
+	// ConfirmTOTPCredential включает второй фактор и заменяет коды
+	// восстановления новыми (хэши codeHashes)
+	ConfirmTOTPCredential(ctx context.Context, userID uint, counter uint64, at time.Time, codeHashes []string) error
+	// AdvanceTOTPCounter запоминает принятый шаг. Если принят шаг не меньше
+	// counter, возвращает ErrTOTPCodeUsed.
+	AdvanceTOTPCounter(ctx context.Context, userID uint, counter uint64) error
+	// UseRecoveryCode отмечает код использованным;
+	// gorm.ErrRecordNotFound — если кода нет или он уже использован
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string, at time.Time) error
+	// DeleteTOTP выключает второй фактор: удаляет секрет и коды восстановления
+	DeleteTOTP(ctx context.Context, userID uint) error
+}
+
+type totpRepository struct {
+	db *gorm.DB
+}
+
+func NewTOTPRepository(db *gorm.DB) TOTPRepository {
+	return &totpRepository{db: db}
+}
+
+func (r *totpRepository) GetTOTPCredential(ctx context.Context, userID uint) (*models.TOTPCredential, error) {
+	var credential models.TOTPCredential
+	if err := r.db.WithContext(ctx).First(&credential, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *totpRepository) SaveTOTPCredential(ctx context.Context, credential *models.TOTPCredential) error {
+	return r.db.WithContext(ctx).Save(credential).Error
+}
+
+func (r *totpRepository) ConfirmTOTPCredential(ctx context.Context, userID uint, counter uint64, at time.Time, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Условное обновление: подтвердить можно только неподтвержденный секрет
+		res := tx.Model(&models.TOTPCredential{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]interface{}{"confirmed_at": at, "last_counter": counter})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash, CreatedAt: at}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *totpRepository) AdvanceTOTPCounter(ctx context.Context, userID uint, counter uint64) error {
+	res := r.db.WithContext(ctx).Model(&models.TOTPCredential{}).
+		Where("user_id = ? AND last_counter < ?", userID, counter).
+		Update("last_counter", counter)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTOTPCodeUsed
+	}
+	return nil
+}
+
+func (r *totpRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string, at time.Time) error {
+	res := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *totpRepository) DeleteTOTP(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TOTPCredential{}).Error
+	})
+}
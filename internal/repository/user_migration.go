@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+// MigrateUsers мигрирует таблицу пользователей. Вызывается до AutoMigrate
+// остальных моделей.
+//
+// Пользователи, зарегистрированные до появления подтверждения email,
+// считаются подтвердившими адрес. Их адреса приводятся к нормализованному
+// виду (без пробелов по краям, в нижнем регистре), если это не создает
+// дубликатов; адреса с конфликтами остаются как есть и пишутся в лог.
+func MigrateUsers(db *gorm.DB) error {
+	hadVerification := db.Migrator().HasColumn(&models.User{}, "EmailVerified")
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		return err
+	}
+	if !hadVerification {
+		if err := db.Model(&models.User{}).Where("1 = 1").Update("email_verified", true).Error; err != nil {
+			return err
+		}
+	}
+
+	err := db.Exec(`UPDATE users SET email = LOWER(TRIM(email))
+		WHERE email <> LOWER(TRIM(email))
+		AND NOT EXISTS (SELECT 1 FROM users other
+			WHERE other.id <> users.id AND LOWER(TRIM(other.email)) = LOWER(TRIM(users.email)))`).Error
+	if err != nil {
+		return err
+	}
+	var conflicts []uint
+	err = db.Model(&models.User{}).Where("email <> LOWER(TRIM(email))").Pluck("id", &conflicts).Error
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		log.Printf("users: emails of users %v could not be normalized because of duplicates", conflicts)
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 	"server/internal/models"
 )
@@ -10,6 +13,23 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	UpdateUserRole(ctx context.Context, id uint, role string) error
+	// ListUsers возвращает пользователей по возрастанию id, начиная после q.AfterID
+	ListUsers(ctx context.Context, q UserQuery) ([]*models.User, error)
+	// SetUserDisabled блокирует пользователя (disabledAt != nil) или снимает блокировку
+	SetUserDisabled(ctx context.Context, id uint, disabledAt *time.Time) error
+	UpdateUserPassword(ctx context.Context, id uint, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id uint) error
+	// DeleteUser окончательно удаляет пользователя вместе с его токенами
+	// и вторым фактором
+	DeleteUser(ctx context.Context, id uint) error
+}
+
+// UserQuery описывает фильтр и окно выборки для ListUsers
+type UserQuery struct {
+	EmailContains string // подстрока email без учета регистра
+	AfterID       uint
+	Limit         int
 }
 
 type userRepository struct {
@@ -38,4 +58,65 @@ func (r *userRepository) GetUserByID(ctx context.Context, id uint) (*models.User
 		return nil, err
 	}
 	return &user, nil
-}
\ No newline at end of file
+}
+
+func (r *userRepository) UpdateUserRole(ctx context.Context, id uint, role string) error {
+	return r.updateUser(ctx, id, "role", role)
+}
+
+func (r *userRepository) ListUsers(ctx context.Context, q UserQuery) ([]*models.User, error) {
+	db := r.db.WithContext(ctx).Where("id > ?", q.AfterID)
+	if q.EmailContains != "" {
+		db = db.Where("LOWER(email) LIKE ?", "%"+escapeLike(strings.ToLower(q.EmailContains))+"%")
+	}
+	var users []*models.User
+	if err := db.Order("id").Limit(q.Limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) SetUserDisabled(ctx context.Context, id uint, disabledAt *time.Time) error {
+	return r.updateUser(ctx, id, "disabled_at", disabledAt)
+}
+
+func (r *userRepository) UpdateUserPassword(ctx context.Context, id uint, passwordHash string) error {
+	return r.updateUser(ctx, id, "password", passwordHash)
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint) error {
+	return r.updateUser(ctx, id, "email_verified", true)
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dependents := []interface{}{&models.RefreshToken{}, &models.UserToken{}, &models.RecoveryCode{}, &models.TOTPCredential{}, &models.APIKey{}, &models.OAuthCode{}, &models.OAuthToken{}, &models.Identity{}, &models.Session{}}
+		for _, model := range dependents {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Unscoped: иначе gorm только пометит запись удаленной, и email
+		// останется занятым для новой регистрации
+		result := tx.Unscoped().Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// updateUser меняет одну колонку пользователя; gorm.ErrRecordNotFound, если его нет
+func (r *userRepository) updateUser(ctx context.Context, id uint, column string, value interface{}) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update(column, value)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
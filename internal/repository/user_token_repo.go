@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"server/internal/models"
+)
+
+type UserTokenRepository interface {
+	CreateUserToken(ctx context.Context, token *models.UserToken) error
+	// ConsumeUserToken отмечает использованным действующий токен с назначением
+	// purpose и возвращает его. gorm.ErrRecordNotFound — если токена нет,
+	// он истек или уже использован.
+	ConsumeUserToken(ctx context.Context, purpose, hash string, at time.Time) (*models.UserToken, error)
+	// InvalidateUserTokens делает недействительными все неиспользованные
+	// токены пользователя с назначением purpose
+	InvalidateUserTokens(ctx context.Context, userID uint, purpose string, at time.Time) error
+	// GetActiveUserToken ищет действующий токен, не используя его.
+	// gorm.ErrRecordNotFound — если токена нет, он истек или уже использован.
+	GetActiveUserToken(ctx context.Context, purpose, hash string, at time.Time) (*models.UserToken, error)
+	// RecordUserTokenFailure засчитывает неудачную попытку; после
+	// maxAttempts попыток токен перестает действовать
+	RecordUserTokenFailure(ctx context.Context, id uint, maxAttempts int, at time.Time) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *userTokenRepository) ConsumeUserToken(ctx context.Context, purpose, hash string, at time.Time) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Условное обновление: из двух параллельных попыток успешна одна
+		res := tx.Model(&models.UserToken{}).
+			Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, at).
+			Update("used_at", at)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("token_hash = ?", hash).First(&token).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *userTokenRepository) InvalidateUserTokens(ctx context.Context, userID uint, purpose string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", at).Error
+}
+
+func (r *userTokenRepository) GetActiveUserToken(ctx context.Context, purpose, hash string, at time.Time) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, at).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *userTokenRepository) RecordUserTokenFailure(ctx context.Context, id uint, maxAttempts int, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.UserToken{}).
+			Where("id = ? AND used_at IS NULL", id).
+			Update("attempts", gorm.Expr("attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.UserToken{}).
+			Where("id = ? AND used_at IS NULL AND attempts >= ?", id, maxAttempts).
+			Update("used_at", at).Error
+	})
+}
@@ -0,0 +1,86 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит отзывы в памяти процесса. Подходит для тестов и для
+// единственного экземпляра UserService: после перезапуска отзывы теряются.
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time // jti -> expiresAt
+	users  map[uint]userCutoff
+}
+
+type userCutoff struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uint]userCutoff),
+	}
+}
+
+func (s *MemoryStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.tokens[jti]; ok && current.After(expiresAt) {
+		expiresAt = current
+	}
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryStore) RevokeUserTokens(ctx context.Context, userID uint, before, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Новый отзыв не должен ни сдвигать границу назад, ни сокращать срок
+	// записи: каждая из границ берется наибольшей
+	if current, ok := s.users[userID]; ok {
+		if current.before.After(before) {
+			before = current.before
+		}
+		if current.expiresAt.After(expiresAt) {
+			expiresAt = current.expiresAt
+		}
+	}
+	s.users[userID] = userCutoff{before: before, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(ctx context.Context, jti string, userID uint, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if expiresAt, ok := s.tokens[jti]; ok && jti != "" && now.Before(expiresAt) {
+		return true, nil
+	}
+	if cutoff, ok := s.users[userID]; ok && now.Before(cutoff.expiresAt) && issuedAt.Before(cutoff.before) {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for jti, expiresAt := range s.tokens {
+		if !now.Before(expiresAt) {
+			delete(s.tokens, jti)
+			deleted++
+		}
+	}
+	for userID, cutoff := range s.users {
+		if !now.Before(cutoff.expiresAt) {
+			delete(s.users, userID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,70 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+
+	if err := store.RevokeToken(ctx, "jti-1", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// Повторный отзыв с более ранним сроком не сокращает запись
+	if err := store.RevokeToken(ctx, "jti-1", now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := store.IsRevoked(ctx, "jti-1", 1, now); !revoked {
+		t.Fatalf("token is no longer revoked after a second revocation")
+	}
+	if revoked, _ := store.IsRevoked(ctx, "jti-2", 1, now); revoked {
+		t.Fatalf("unrelated token is revoked")
+	}
+	if revoked, _ := store.IsRevoked(ctx, "", 1, now); revoked {
+		t.Fatalf("token without jti is revoked")
+	}
+}
+
+func TestMemoryStoreRevokeUserTokens(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+	first, second := now.Add(-time.Hour), now.Add(-time.Minute)
+
+	if err := store.RevokeUserTokens(ctx, 1, second, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// Более ранний отзыв с более коротким сроком не отменяет более поздний
+	if err := store.RevokeUserTokens(ctx, 1, first, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		userID   uint
+		issuedAt time.Time
+		want     bool
+	}{
+		{"before both cutoffs", 1, first.Add(-time.Second), true},
+		{"between cutoffs", 1, first.Add(time.Second), true},
+		{"after the later cutoff", 1, second.Add(time.Second), false},
+		{"other user", 2, first.Add(-time.Second), false},
+	}
+	for _, tt := range tests {
+		revoked, err := store.IsRevoked(ctx, "", tt.userID, tt.issuedAt)
+		if err != nil || revoked != tt.want {
+			t.Errorf("%s: IsRevoked = %v, %v; want %v", tt.name, revoked, err, tt.want)
+		}
+	}
+
+	// Запись живет до наибольшего из сроков
+	if deleted, _ := store.DeleteExpired(ctx, now.Add(30*time.Minute)); deleted != 0 {
+		t.Fatalf("DeleteExpired removed %d entries before the longest expiry", deleted)
+	}
+	if deleted, _ := store.DeleteExpired(ctx, now.Add(2*time.Hour)); deleted != 1 {
+		t.Fatalf("DeleteExpired removed %d entries after expiry, want 1", deleted)
+	}
+}
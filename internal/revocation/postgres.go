@@ -0,0 +1,76 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/internal/models"
+)
+
+// PostgresStore хранит отзывы в базе UserService и общий для всех ее экземпляров
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	// Повторный отзыв не сокращает срок записи
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "jti"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"expires_at": gorm.Expr("GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at)"),
+			}),
+		}).
+		Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (s *PostgresStore) RevokeUserTokens(ctx context.Context, userID uint, before, expiresAt time.Time) error {
+	// Как и в MemoryStore, обе границы берутся наибольшими
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"revoked_before": gorm.Expr("GREATEST(user_revocations.revoked_before, EXCLUDED.revoked_before)"),
+				"expires_at":     gorm.Expr("GREATEST(user_revocations.expires_at, EXCLUDED.expires_at)"),
+			}),
+		}).
+		Create(&models.UserRevocation{UserID: userID, RevokedBefore: before, ExpiresAt: expiresAt}).Error
+}
+
+func (s *PostgresStore) IsRevoked(ctx context.Context, jti string, userID uint, issuedAt time.Time) (bool, error) {
+	now := time.Now()
+	var count int64
+	if jti != "" {
+		err := s.db.WithContext(ctx).Model(&models.RevokedToken{}).
+			Where("jti = ? AND expires_at > ?", jti, now).
+			Count(&count).Error
+		if err != nil || count > 0 {
+			return count > 0, err
+		}
+	}
+	err := s.db.WithContext(ctx).Model(&models.UserRevocation{}).
+		Where("user_id = ? AND revoked_before > ? AND expires_at > ?", userID, issuedAt, now).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *PostgresStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted += res.RowsAffected
+		res = tx.Where("expires_at <= ?", now).Delete(&models.UserRevocation{})
+		deleted += res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
@@ -0,0 +1,40 @@
+// Package revocation хранит отозванные access-токены до истечения их срока.
+// ValidateToken сверяется с хранилищем, поэтому JWT можно "убить" раньше exp.
+package revocation
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Store — хранилище отзывов. Отзыв нужен только пока отозванный токен
+// мог бы пройти проверку exp, поэтому у каждой записи есть срок expiresAt,
+// после которого ее можно забыть.
+type Store interface {
+	// RevokeToken отзывает один токен по его jti
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeUserTokens отзывает все токены пользователя, выданные раньше before
+	RevokeUserTokens(ctx context.Context, userID uint, before, expiresAt time.Time) error
+	// IsRevoked сообщает, отозван ли токен с данным jti и временем выдачи
+	IsRevoked(ctx context.Context, jti string, userID uint, issuedAt time.Time) (bool, error)
+	// DeleteExpired удаляет записи, срок которых истек к моменту now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// RunCleanup периодически удаляет просроченные записи. Блокируется до отмены ctx.
+func RunCleanup(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := store.DeleteExpired(ctx, now); err != nil {
+				log.Printf("revocation: failed to delete expired entries: %v", err)
+			}
+		}
+	}
+}
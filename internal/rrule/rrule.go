@@ -0,0 +1,510 @@
+// Package rrule разбирает и раскрывает правила повторения iCalendar
+// (RFC 5545, свойство RRULE) в объеме, нужном для повторяющихся задач:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, BYMONTHDAY, COUNT и UNTIL.
+//
+// Все даты вычисляются по "настенным часам" в часовом поясе DTSTART:
+// задача на 09:00 остается на 09:00 и после перехода на летнее время.
+// Несуществующие даты (31 февраля, 5-й вторник) пропускаются, как того
+// требует RFC 5545, а не переносятся на соседний день.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency int
+
+const (
+	Daily Frequency = iota + 1
+	Weekly
+	Monthly
+)
+
+var frequencyNames = map[string]Frequency{
+	"DAILY":   Daily,
+	"WEEKLY":  Weekly,
+	"MONTHLY": Monthly,
+}
+
+func (f Frequency) String() string {
+	switch f {
+	case Daily:
+		return "DAILY"
+	case Weekly:
+		return "WEEKLY"
+	case Monthly:
+		return "MONTHLY"
+	}
+	return fmt.Sprintf("Frequency(%d)", int(f))
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var weekdayCodes = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// WeekdayNum — элемент BYDAY: день недели и, для MONTHLY, его номер
+// в месяце (1 — первый, -1 — последний, 0 — каждый)
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return weekdayCodes[w.Weekday]
+	}
+	return strconv.Itoa(w.N) + weekdayCodes[w.Weekday]
+}
+
+// Rule — разобранное правило повторения
+type Rule struct {
+	Freq       Frequency
+	Interval   int // не меньше 1
+	ByDay      []WeekdayNum
+	ByMonthDay []int // 1..31 или -31..-1 (от конца месяца)
+	Count      int   // 0 — без ограничения
+	// Until — последняя допустимая дата (включительно); нулевое значение —
+	// без ограничения. Если в правиле была дата без часового пояса,
+	// она задана в UTC и при сравнении трактуется как время DTSTART.
+	Until         time.Time
+	untilFloating bool
+}
+
+// maxEmptyPeriods ограничивает число периодов подряд без единой даты,
+// чтобы правило вроде "каждые 12 месяцев 30-го числа" от февраля
+// не зациклило расчет
+const maxEmptyPeriods = 1000
+
+// ErrInvalidRule — общая причина ошибок разбора
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Parse разбирает строку вида "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10".
+// Префикс "RRULE:" допускается.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		name = strings.ToUpper(name)
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			freq, ok := frequencyNames[strings.ToUpper(value)]
+			if !ok {
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, value)
+			}
+			rule.Freq = freq
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err != nil || rule.Interval < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err != nil || rule.Count < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+			}
+		case "UNTIL":
+			rule.Until, rule.untilFloating, err = parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(v)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				day, err := strconv.Atoi(v)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY %q out of range", ErrInvalidRule, v)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "WKST":
+			// Поддерживается только неделя с понедельника
+			if strings.ToUpper(value) != "MO" {
+				return nil, fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRule)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, name)
+		}
+	}
+
+	if rule.Freq == 0 {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count != 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+	for _, wd := range rule.ByDay {
+		if wd.N != 0 && rule.Freq != Monthly {
+			return nil, fmt.Errorf("%w: numbered BYDAY is only allowed with FREQ=MONTHLY", ErrInvalidRule)
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == Weekly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY is not allowed with FREQ=WEEKLY", ErrInvalidRule)
+	}
+	return rule, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRule, s)
+	}
+	wd, ok := weekdayNames[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRule, s)
+	}
+	result := WeekdayNum{Weekday: wd}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRule, s)
+		}
+		result.N = n
+	}
+	return result, nil
+}
+
+func parseUntil(s string) (time.Time, bool, error) {
+	layouts := []struct {
+		layout   string
+		floating bool
+	}{
+		{"20060102T150405Z", false},
+		{"20060102T150405", true},
+		{"20060102", true},
+	}
+	for _, l := range layouts {
+		t, err := time.Parse(l.layout, s)
+		if err != nil {
+			continue
+		}
+		if l.layout == "20060102" {
+			// Дата без времени включает весь день
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, l.floating, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%w: bad UNTIL %q", ErrInvalidRule, s)
+}
+
+// String возвращает правило в каноническом виде без префикса "RRULE:"
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = wd.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.untilFloating {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next возвращает первое вхождение серии, начатой в dtstart, строго
+// позже after. ok=false, если серия закончилась (COUNT или UNTIL).
+func (r *Rule) Next(dtstart, after time.Time) (next time.Time, ok bool) {
+	it := r.Iterator(dtstart)
+	for {
+		t, ok := it.Next()
+		if !ok {
+			return time.Time{}, false
+		}
+		if t.After(after) {
+			return t, true
+		}
+	}
+}
+
+// All возвращает не больше limit первых вхождений серии
+func (r *Rule) All(dtstart time.Time, limit int) []time.Time {
+	var result []time.Time
+	it := r.Iterator(dtstart)
+	for len(result) < limit {
+		t, ok := it.Next()
+		if !ok {
+			break
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// Iterator перебирает вхождения серии по возрастанию. По RFC 5545
+// первым вхождением всегда считается сам dtstart.
+type Iterator struct {
+	rule    *Rule
+	dtstart time.Time
+	until   time.Time
+	period  int         // номер текущего периода (дня, недели, месяца)
+	pending []time.Time // вхождения текущего периода, еще не выданные
+	emitted int
+	started bool
+	done    bool
+}
+
+func (r *Rule) Iterator(dtstart time.Time) *Iterator {
+	until := r.Until
+	if r.untilFloating && !until.IsZero() {
+		until = time.Date(until.Year(), until.Month(), until.Day(),
+			until.Hour(), until.Minute(), until.Second(), until.Nanosecond(), dtstart.Location())
+	}
+	return &Iterator{rule: r, dtstart: dtstart, until: until}
+}
+
+func (it *Iterator) Next() (time.Time, bool) {
+	if it.done {
+		return time.Time{}, false
+	}
+	if !it.started {
+		it.started = true
+		return it.emit(it.dtstart)
+	}
+
+	for empty := 0; len(it.pending) == 0; empty++ {
+		if empty > maxEmptyPeriods {
+			it.done = true
+			return time.Time{}, false
+		}
+		for _, t := range it.rule.expandPeriod(it.dtstart, it.period) {
+			if t.After(it.dtstart) {
+				it.pending = append(it.pending, t)
+			}
+		}
+		it.period++
+		// UNTIL уже пройден в самом начале периода — дальше искать нечего
+		if len(it.pending) == 0 && !it.until.IsZero() && it.rule.periodStart(it.dtstart, it.period).After(it.until) {
+			it.done = true
+			return time.Time{}, false
+		}
+	}
+
+	t := it.pending[0]
+	it.pending = it.pending[1:]
+	return it.emit(t)
+}
+
+func (it *Iterator) emit(t time.Time) (time.Time, bool) {
+	if !it.until.IsZero() && t.After(it.until) {
+		it.done = true
+		return time.Time{}, false
+	}
+	it.emitted++
+	if it.rule.Count > 0 && it.emitted >= it.rule.Count {
+		it.done = true
+	}
+	return t, true
+}
+
+// periodStart — полночь первого дня периода n в часовом поясе dtstart
+func (r *Rule) periodStart(dtstart time.Time, n int) time.Time {
+	y, m, d := dtstart.Date()
+	loc := dtstart.Location()
+	switch r.Freq {
+	case Weekly:
+		monday := d - (int(dtstart.Weekday())+6)%7
+		return time.Date(y, m, monday+7*n*r.Interval, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(y, m+time.Month(n*r.Interval), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m, d+n*r.Interval, 0, 0, 0, 0, loc)
+	}
+}
+
+// expandPeriod возвращает отсортированные даты периода n со временем суток dtstart
+func (r *Rule) expandPeriod(dtstart time.Time, n int) []time.Time {
+	start := r.periodStart(dtstart, n)
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		if r.matchesDayFilters(start) {
+			days = append(days, start)
+		}
+	case Weekly:
+		weekdays := r.ByDay
+		if len(weekdays) == 0 {
+			weekdays = []WeekdayNum{{Weekday: dtstart.Weekday()}}
+		}
+		for _, wd := range weekdays {
+			offset := (int(wd.Weekday) + 6) % 7
+			days = append(days, start.AddDate(0, 0, offset))
+		}
+	case Monthly:
+		days = r.expandMonth(start, dtstart)
+	}
+
+	result := make([]time.Time, 0, len(days))
+	seen := make(map[time.Time]bool, len(days))
+	for _, day := range days {
+		t := withClock(day, dtstart)
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+// matchesDayFilters — BYDAY и BYMONTHDAY в роли фильтров для FREQ=DAILY
+func (r *Rule) matchesDayFilters(day time.Time) bool {
+	if len(r.ByDay) > 0 {
+		match := false
+		for _, wd := range r.ByDay {
+			if wd.Weekday == day.Weekday() {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	if len(r.ByMonthDay) > 0 {
+		last := daysIn(day.Year(), day.Month())
+		match := false
+		for _, md := range r.ByMonthDay {
+			if md == day.Day() || (md < 0 && last+md+1 == day.Day()) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// expandMonth раскрывает BYMONTHDAY и BYDAY внутри месяца. Если заданы
+// оба, в результат попадают только дни, подходящие под оба условия.
+func (r *Rule) expandMonth(first, dtstart time.Time) []time.Time {
+	year, month := first.Year(), first.Month()
+	last := daysIn(year, month)
+
+	var byMonthDay map[int]bool
+	if len(r.ByMonthDay) > 0 || len(r.ByDay) == 0 {
+		byMonthDay = make(map[int]bool)
+		monthDays := r.ByMonthDay
+		if len(monthDays) == 0 {
+			monthDays = []int{dtstart.Day()}
+		}
+		for _, md := range monthDays {
+			day := md
+			if md < 0 {
+				day = last + md + 1
+			}
+			// Дни, которых нет в этом месяце, пропускаются
+			if day >= 1 && day <= last {
+				byMonthDay[day] = true
+			}
+		}
+	}
+
+	var byDay map[int]bool
+	if len(r.ByDay) > 0 {
+		byDay = make(map[int]bool)
+		for _, wd := range r.ByDay {
+			var matches []int
+			for day := 1; day <= last; day++ {
+				if time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() == wd.Weekday {
+					matches = append(matches, day)
+				}
+			}
+			switch {
+			case wd.N == 0:
+				for _, day := range matches {
+					byDay[day] = true
+				}
+			case wd.N > 0 && wd.N <= len(matches):
+				byDay[matches[wd.N-1]] = true
+			case wd.N < 0 && -wd.N <= len(matches):
+				byDay[matches[len(matches)+wd.N]] = true
+			}
+		}
+	}
+
+	var days []time.Time
+	for day := 1; day <= last; day++ {
+		if byMonthDay != nil && !byMonthDay[day] {
+			continue
+		}
+		if byDay != nil && !byDay[day] {
+			continue
+		}
+		days = append(days, time.Date(year, month, day, 0, 0, 0, 0, first.Location()))
+	}
+	return days
+}
+
+// withClock переносит время суток dtstart на дату day по настенным часам
+func withClock(day, dtstart time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
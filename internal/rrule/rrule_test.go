@@ -0,0 +1,418 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // канонический вид; пусто — ожидается ошибка
+	}{
+		{"daily", "FREQ=DAILY", "FREQ=DAILY"},
+		{"prefix and lowercase", "RRULE:freq=weekly;byday=mo,we", "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{"interval one is dropped", "FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+		{"interval", "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR"},
+		{"monthly by month day", "FREQ=MONTHLY;BYMONTHDAY=1,15,-1", "FREQ=MONTHLY;BYMONTHDAY=1,15,-1"},
+		{"monthly numbered weekday", "FREQ=MONTHLY;BYDAY=2TU,-1FR", "FREQ=MONTHLY;BYDAY=2TU,-1FR"},
+		{"count", "FREQ=DAILY;COUNT=5", "FREQ=DAILY;COUNT=5"},
+		{"until utc", "FREQ=DAILY;UNTIL=20240131T235959Z", "FREQ=DAILY;UNTIL=20240131T235959Z"},
+		{"until floating", "FREQ=DAILY;UNTIL=20240131T090000", "FREQ=DAILY;UNTIL=20240131T090000"},
+		{"until date only", "FREQ=DAILY;UNTIL=20240131", "FREQ=DAILY;UNTIL=20240131T235959"},
+		{"wkst monday", "FREQ=WEEKLY;WKST=MO", "FREQ=WEEKLY"},
+		{"surrounding spaces", "  FREQ=DAILY  ", "FREQ=DAILY"},
+
+		{"empty", "", ""},
+		{"missing freq", "INTERVAL=2", ""},
+		{"yearly unsupported", "FREQ=YEARLY", ""},
+		{"unknown part", "FREQ=DAILY;BYHOUR=9", ""},
+		{"malformed part", "FREQ=DAILY;COUNT", ""},
+		{"empty value", "FREQ=", ""},
+		{"duplicate part", "FREQ=DAILY;FREQ=WEEKLY", ""},
+		{"zero interval", "FREQ=DAILY;INTERVAL=0", ""},
+		{"negative interval", "FREQ=DAILY;INTERVAL=-1", ""},
+		{"zero count", "FREQ=DAILY;COUNT=0", ""},
+		{"count and until", "FREQ=DAILY;COUNT=2;UNTIL=20240101", ""},
+		{"bad until", "FREQ=DAILY;UNTIL=2024-01-01", ""},
+		{"bad weekday", "FREQ=WEEKLY;BYDAY=XX", ""},
+		{"numbered weekday in weekly", "FREQ=WEEKLY;BYDAY=1MO", ""},
+		{"weekday number out of range", "FREQ=MONTHLY;BYDAY=6MO", ""},
+		{"zero month day", "FREQ=MONTHLY;BYMONTHDAY=0", ""},
+		{"month day out of range", "FREQ=MONTHLY;BYMONTHDAY=32", ""},
+		{"negative month day out of range", "FREQ=MONTHLY;BYMONTHDAY=-32", ""},
+		{"month day in weekly", "FREQ=WEEKLY;BYMONTHDAY=1", ""},
+		{"wkst sunday", "FREQ=WEEKLY;WKST=SU", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.in)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v, want error", tt.in, rule)
+				}
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Fatalf("Parse(%q) error %v does not wrap ErrInvalidRule", tt.in, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Fatalf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	utc := time.UTC
+	ny := mustLoad(t, "America/New_York")
+	berlin := mustLoad(t, "Europe/Berlin")
+	date := func(loc *time.Location, y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		limit   int
+		want    []time.Time
+	}{
+		{
+			name:    "daily",
+			rule:    "FREQ=DAILY",
+			dtstart: date(utc, 2024, 1, 30, 9, 0),
+			limit:   4,
+			want: []time.Time{
+				date(utc, 2024, 1, 30, 9, 0), date(utc, 2024, 1, 31, 9, 0),
+				date(utc, 2024, 2, 1, 9, 0), date(utc, 2024, 2, 2, 9, 0),
+			},
+		},
+		{
+			name:    "every third day across leap day",
+			rule:    "FREQ=DAILY;INTERVAL=3",
+			dtstart: date(utc, 2024, 2, 26, 8, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 2, 26, 8, 0), date(utc, 2024, 2, 29, 8, 0), date(utc, 2024, 3, 3, 8, 0)},
+		},
+		{
+			name:    "daily filtered to weekdays",
+			rule:    "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			dtstart: date(utc, 2024, 3, 7, 9, 0), // четверг
+			limit:   4,
+			want: []time.Time{
+				date(utc, 2024, 3, 7, 9, 0), date(utc, 2024, 3, 8, 9, 0),
+				date(utc, 2024, 3, 11, 9, 0), date(utc, 2024, 3, 12, 9, 0),
+			},
+		},
+		{
+			name:    "daily filtered to last day of month",
+			rule:    "FREQ=DAILY;BYMONTHDAY=-1",
+			dtstart: date(utc, 2023, 1, 31, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2023, 1, 31, 9, 0), date(utc, 2023, 2, 28, 9, 0), date(utc, 2023, 3, 31, 9, 0)},
+		},
+		{
+			name:    "weekly defaults to dtstart weekday",
+			rule:    "FREQ=WEEKLY",
+			dtstart: date(utc, 2024, 12, 25, 10, 0), // среда
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 12, 25, 10, 0), date(utc, 2025, 1, 1, 10, 0), date(utc, 2025, 1, 8, 10, 0)},
+		},
+		{
+			name:    "weekly on several days",
+			rule:    "FREQ=WEEKLY;BYDAY=FR,MO,WE",
+			dtstart: date(utc, 2024, 4, 1, 9, 0), // понедельник
+			limit:   5,
+			want: []time.Time{
+				date(utc, 2024, 4, 1, 9, 0), date(utc, 2024, 4, 3, 9, 0), date(utc, 2024, 4, 5, 9, 0),
+				date(utc, 2024, 4, 8, 9, 0), date(utc, 2024, 4, 10, 9, 0),
+			},
+		},
+		{
+			name:    "weekly skips days before dtstart in first week",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,FR",
+			dtstart: date(utc, 2024, 4, 3, 9, 0), // среда
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 4, 3, 9, 0), date(utc, 2024, 4, 5, 9, 0), date(utc, 2024, 4, 8, 9, 0)},
+		},
+		{
+			name:    "biweekly with sunday at the end of the week",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU",
+			dtstart: date(utc, 2024, 4, 1, 9, 0),
+			limit:   4,
+			want: []time.Time{
+				date(utc, 2024, 4, 1, 9, 0), date(utc, 2024, 4, 7, 9, 0),
+				date(utc, 2024, 4, 15, 9, 0), date(utc, 2024, 4, 21, 9, 0),
+			},
+		},
+		{
+			name:    "weekly across year boundary",
+			rule:    "FREQ=WEEKLY;BYDAY=TU",
+			dtstart: date(utc, 2024, 12, 24, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 12, 24, 9, 0), date(utc, 2024, 12, 31, 9, 0), date(utc, 2025, 1, 7, 9, 0)},
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rule:    "FREQ=MONTHLY",
+			dtstart: date(utc, 2024, 1, 31, 12, 0),
+			limit:   4,
+			want: []time.Time{
+				date(utc, 2024, 1, 31, 12, 0), date(utc, 2024, 3, 31, 12, 0),
+				date(utc, 2024, 5, 31, 12, 0), date(utc, 2024, 7, 31, 12, 0),
+			},
+		},
+		{
+			name:    "monthly on the 30th skips february",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=30",
+			dtstart: date(utc, 2024, 1, 30, 12, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 1, 30, 12, 0), date(utc, 2024, 3, 30, 12, 0), date(utc, 2024, 4, 30, 12, 0)},
+		},
+		{
+			name:    "monthly on the 29th in leap and common years",
+			rule:    "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=29",
+			dtstart: date(utc, 2024, 2, 29, 12, 0),
+			limit:   2,
+			want:    []time.Time{date(utc, 2024, 2, 29, 12, 0), date(utc, 2028, 2, 29, 12, 0)},
+		},
+		{
+			name:    "monthly on the last day",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: date(utc, 2024, 1, 31, 18, 0),
+			limit:   4,
+			want: []time.Time{
+				date(utc, 2024, 1, 31, 18, 0), date(utc, 2024, 2, 29, 18, 0),
+				date(utc, 2024, 3, 31, 18, 0), date(utc, 2024, 4, 30, 18, 0),
+			},
+		},
+		{
+			name:    "monthly on the first and fifteenth",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=15,1",
+			dtstart: date(utc, 2024, 1, 1, 9, 0),
+			limit:   4,
+			want: []time.Time{
+				date(utc, 2024, 1, 1, 9, 0), date(utc, 2024, 1, 15, 9, 0),
+				date(utc, 2024, 2, 1, 9, 0), date(utc, 2024, 2, 15, 9, 0),
+			},
+		},
+		{
+			name:    "same day via positive and negative month day is not duplicated",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=30,-1",
+			dtstart: date(utc, 2024, 4, 30, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 4, 30, 9, 0), date(utc, 2024, 5, 30, 9, 0), date(utc, 2024, 5, 31, 9, 0)},
+		},
+		{
+			name:    "quarterly",
+			rule:    "FREQ=MONTHLY;INTERVAL=3",
+			dtstart: date(utc, 2024, 11, 15, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 11, 15, 9, 0), date(utc, 2025, 2, 15, 9, 0), date(utc, 2025, 5, 15, 9, 0)},
+		},
+		{
+			name:    "second tuesday",
+			rule:    "FREQ=MONTHLY;BYDAY=2TU",
+			dtstart: date(utc, 2024, 1, 9, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 1, 9, 9, 0), date(utc, 2024, 2, 13, 9, 0), date(utc, 2024, 3, 12, 9, 0)},
+		},
+		{
+			name:    "last friday",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: date(utc, 2024, 1, 26, 17, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 1, 26, 17, 0), date(utc, 2024, 2, 23, 17, 0), date(utc, 2024, 3, 29, 17, 0)},
+		},
+		{
+			name:    "fifth monday only in months that have one",
+			rule:    "FREQ=MONTHLY;BYDAY=5MO",
+			dtstart: date(utc, 2024, 1, 29, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 1, 29, 9, 0), date(utc, 2024, 4, 29, 9, 0), date(utc, 2024, 7, 29, 9, 0)},
+		},
+		{
+			name:    "every monday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=MO",
+			dtstart: date(utc, 2024, 2, 5, 9, 0),
+			limit:   5,
+			want: []time.Time{
+				date(utc, 2024, 2, 5, 9, 0), date(utc, 2024, 2, 12, 9, 0), date(utc, 2024, 2, 19, 9, 0),
+				date(utc, 2024, 2, 26, 9, 0), date(utc, 2024, 3, 4, 9, 0),
+			},
+		},
+		{
+			name:    "friday the 13th",
+			rule:    "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			dtstart: date(utc, 2024, 9, 13, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(utc, 2024, 9, 13, 9, 0), date(utc, 2024, 12, 13, 9, 0), date(utc, 2025, 6, 13, 9, 0)},
+		},
+		{
+			name:    "count includes dtstart",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: date(utc, 2024, 5, 1, 9, 0),
+			limit:   10,
+			want:    []time.Time{date(utc, 2024, 5, 1, 9, 0), date(utc, 2024, 5, 2, 9, 0), date(utc, 2024, 5, 3, 9, 0)},
+		},
+		{
+			name:    "count with skipped month ends",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: date(utc, 2024, 8, 31, 9, 0),
+			limit:   10,
+			want:    []time.Time{date(utc, 2024, 8, 31, 9, 0), date(utc, 2024, 10, 31, 9, 0), date(utc, 2024, 12, 31, 9, 0)},
+		},
+		{
+			name:    "until is inclusive",
+			rule:    "FREQ=DAILY;UNTIL=20240503T090000Z",
+			dtstart: date(utc, 2024, 5, 1, 9, 0),
+			limit:   10,
+			want:    []time.Time{date(utc, 2024, 5, 1, 9, 0), date(utc, 2024, 5, 2, 9, 0), date(utc, 2024, 5, 3, 9, 0)},
+		},
+		{
+			name:    "until date covers the whole day",
+			rule:    "FREQ=WEEKLY;UNTIL=20240515",
+			dtstart: date(utc, 2024, 5, 1, 23, 0),
+			limit:   10,
+			want:    []time.Time{date(utc, 2024, 5, 1, 23, 0), date(utc, 2024, 5, 8, 23, 0), date(utc, 2024, 5, 15, 23, 0)},
+		},
+		{
+			name:    "floating until uses dtstart time zone",
+			rule:    "FREQ=DAILY;UNTIL=20240502T090000",
+			dtstart: date(berlin, 2024, 5, 1, 9, 0),
+			limit:   10,
+			want:    []time.Time{date(berlin, 2024, 5, 1, 9, 0), date(berlin, 2024, 5, 2, 9, 0)},
+		},
+		{
+			name:    "until before dtstart yields nothing",
+			rule:    "FREQ=DAILY;UNTIL=20240101T000000Z",
+			dtstart: date(utc, 2024, 5, 1, 9, 0),
+			limit:   10,
+			want:    nil,
+		},
+		{
+			name:    "until stops a rule with no further matches",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=31;UNTIL=20240430T000000Z",
+			dtstart: date(utc, 2024, 3, 31, 9, 0),
+			limit:   10,
+			want:    []time.Time{date(utc, 2024, 3, 31, 9, 0)},
+		},
+		{
+			name:    "rule that never matches again terminates",
+			rule:    "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30",
+			dtstart: date(utc, 2024, 2, 10, 9, 0),
+			limit:   10,
+			want:    []time.Time{date(utc, 2024, 2, 10, 9, 0)},
+		},
+		{
+			name:    "daily keeps wall clock across US spring forward",
+			rule:    "FREQ=DAILY",
+			dtstart: date(ny, 2024, 3, 9, 9, 0),
+			limit:   3,
+			want:    []time.Time{date(ny, 2024, 3, 9, 9, 0), date(ny, 2024, 3, 10, 9, 0), date(ny, 2024, 3, 11, 9, 0)},
+		},
+		{
+			name:    "weekly keeps wall clock across EU fall back",
+			rule:    "FREQ=WEEKLY;BYDAY=SU",
+			dtstart: date(berlin, 2024, 10, 20, 7, 30),
+			limit:   3,
+			want:    []time.Time{date(berlin, 2024, 10, 20, 7, 30), date(berlin, 2024, 10, 27, 7, 30), date(berlin, 2024, 11, 3, 7, 30)},
+		},
+		{
+			name:    "monthly keeps wall clock across DST",
+			rule:    "FREQ=MONTHLY",
+			dtstart: date(ny, 2024, 2, 15, 23, 30),
+			limit:   3,
+			want:    []time.Time{date(ny, 2024, 2, 15, 23, 30), date(ny, 2024, 3, 15, 23, 30), date(ny, 2024, 4, 15, 23, 30)},
+		},
+		{
+			name:    "late evening stays on the same local date across DST",
+			rule:    "FREQ=DAILY",
+			dtstart: date(berlin, 2024, 3, 30, 23, 45),
+			limit:   3,
+			want:    []time.Time{date(berlin, 2024, 3, 30, 23, 45), date(berlin, 2024, 3, 31, 23, 45), date(berlin, 2024, 4, 1, 23, 45)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			got := rule.All(tt.dtstart, tt.limit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("All() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) || got[i].Location() != tt.want[i].Location() {
+					t.Fatalf("All()[%d] = %v, want %v (all: %v)", i, got[i], tt.want[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestDSTOffsets(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	rule, err := Parse("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rule.All(time.Date(2024, 3, 9, 9, 0, 0, 0, ny), 2)
+	if d := got[1].Sub(got[0]); d != 23*time.Hour {
+		t.Fatalf("interval across spring forward = %v, want 23h", d)
+	}
+	got = rule.All(time.Date(2024, 11, 2, 9, 0, 0, 0, ny), 2)
+	if d := got[1].Sub(got[0]); d != 25*time.Hour {
+		t.Fatalf("interval across fall back = %v, want 25h", d)
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := time.UTC
+	dtstart := time.Date(2024, 1, 31, 9, 0, 0, 0, utc)
+
+	tests := []struct {
+		name   string
+		rule   string
+		after  time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{"after dtstart", "FREQ=MONTHLY", dtstart, time.Date(2024, 3, 31, 9, 0, 0, 0, utc), true},
+		{"before dtstart returns dtstart", "FREQ=MONTHLY", dtstart.Add(-time.Hour), dtstart, true},
+		{"between occurrences", "FREQ=DAILY", time.Date(2024, 2, 5, 12, 0, 0, 0, utc), time.Date(2024, 2, 6, 9, 0, 0, 0, utc), true},
+		{"exactly on an occurrence is excluded", "FREQ=DAILY", time.Date(2024, 2, 5, 9, 0, 0, 0, utc), time.Date(2024, 2, 6, 9, 0, 0, 0, utc), true},
+		{"count exhausted", "FREQ=DAILY;COUNT=2", time.Date(2024, 2, 1, 9, 0, 0, 0, utc), time.Time{}, false},
+		{"last counted occurrence", "FREQ=DAILY;COUNT=2", dtstart, time.Date(2024, 2, 1, 9, 0, 0, 0, utc), true},
+		{"until passed", "FREQ=DAILY;UNTIL=20240201T090000Z", time.Date(2024, 2, 1, 9, 0, 0, 0, utc), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			got, ok := rule.Next(dtstart, tt.after)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Fatalf("Next(%v) = %v, %v; want %v, %v", tt.after, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/repository"
+)
+
+// Методы управления пользователями. Право rbac.UsersManage проверяет
+// перехватчик (см. UserMethodPermissions).
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+func (s *UserServiceServer) ListUsers(ctx context.Context, req *proto.ListUsersRequest) (*proto.ListUsersResponse, error) {
+	pageSize := int(req.PageSize)
+	if pageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultUserPageSize
+	}
+	if pageSize > maxUserPageSize {
+		pageSize = maxUserPageSize
+	}
+
+	fingerprint := queryFingerprint(req)
+	var afterID uint
+	if req.PageToken != "" {
+		var err error
+		if afterID, err = decodeIDPageToken(req.PageToken, fingerprint); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	users, err := s.userRepo.ListUsers(ctx, repository.UserQuery{
+		EmailContains: req.EmailContains,
+		AfterID:       afterID,
+		Limit:         pageSize + 1,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list users: %v", err)
+	}
+
+	resp := &proto.ListUsersResponse{}
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		resp.NextPageToken = encodeIDPageToken(users[len(users)-1].ID, fingerprint)
+	}
+	for _, user := range users {
+		resp.Users = append(resp.Users, userToProto(user))
+	}
+	return resp, nil
+}
+
+func (s *UserServiceServer) GetUser(ctx context.Context, req *proto.GetUserRequest) (*proto.User, error) {
+	targetID, err := parseTargetUserID(req.TargetUserId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.getUser(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return userToProto(user), nil
+}
+
+// DisableUser блокирует пользователя и отзывает все его токены
+func (s *UserServiceServer) DisableUser(ctx context.Context, req *proto.UserActionRequest) (*proto.User, error) {
+	targetID, err := parseOtherUserID(req, "disable")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.userRepo.SetUserDisabled(ctx, targetID, &now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to disable user: %v", err)
+	}
+	if err := s.revokeAllTokens(ctx, targetID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return userToProto(user), nil
+}
+
+// EnableUser снимает блокировку. Отозванные при блокировке токены
+// остаются недействительными: пользователю нужно войти заново.
+func (s *UserServiceServer) EnableUser(ctx context.Context, req *proto.UserActionRequest) (*proto.User, error) {
+	targetID, err := parseTargetUserID(req.TargetUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetUserDisabled(ctx, targetID, nil); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to enable user: %v", err)
+	}
+
+	user, err := s.getUser(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return userToProto(user), nil
+}
+
+// DeleteUser окончательно удаляет пользователя. Его access-токены
+// отзываются, refresh-токены удаляются вместе с ним.
+func (s *UserServiceServer) DeleteUser(ctx context.Context, req *proto.UserActionRequest) (*proto.DeleteUserResponse, error) {
+	targetID, err := parseOtherUserID(req, "delete")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.DeleteUser(ctx, targetID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete user: %v", err)
+	}
+	now := time.Now()
+	if err := s.revocations.RevokeUserTokens(ctx, targetID, now, now.Add(s.cfg.AccessTokenTTL)); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke tokens: %v", err)
+	}
+
+	return &proto.DeleteUserResponse{Message: "User deleted successfully"}, nil
+}
+
+// ResetUserPassword заменяет пароль пользователя случайным временным
+// и отзывает все его токены
+func (s *UserServiceServer) ResetUserPassword(ctx context.Context, req *proto.UserActionRequest) (*proto.ResetUserPasswordResponse, error) {
+	targetID, err := parseTargetUserID(req.TargetUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := randomToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate password: %v", err)
+	}
+	if err := s.setPassword(ctx, targetID, password); err != nil {
+		return nil, err
+	}
+
+	return &proto.ResetUserPasswordResponse{TemporaryPassword: password}, nil
+}
+
+func (s *UserServiceServer) getUser(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	return user, nil
+}
+
+func parseTargetUserID(targetUserID string) (uint, error) {
+	id, err := strconv.ParseUint(targetUserID, 10, 64)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid target user ID format")
+	}
+	return uint(id), nil
+}
+
+// parseOtherUserID запрещает администратору применять action к самому себе:
+// иначе можно остаться без единого администратора
+func parseOtherUserID(req *proto.UserActionRequest, action string) (uint, error) {
+	targetID, err := parseTargetUserID(req.TargetUserId)
+	if err != nil {
+		return 0, err
+	}
+	if req.TargetUserId == req.UserId {
+		return 0, status.Errorf(codes.FailedPrecondition, "cannot %s your own account", action)
+	}
+	return targetID, nil
+}
+
+func userToProto(user *models.User) *proto.User {
+	item := &proto.User{
+		Id:        fmt.Sprintf("%d", user.ID),
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+
+		EmailVerified: user.EmailVerified,
+	}
+	if user.DisabledAt != nil {
+		item.DisabledAt = timestamppb.New(*user.DisabledAt)
+	}
+	return item
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+
+	"server/internal/apikey"
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/rbac"
+)
+
+const (
+	maxAPIKeyNameLength = 100
+	maxAPIKeysPerUser   = 50
+	// Сколько символов случайной части токена показывается в списке ключей
+	apiKeyVisibleChars = 6
+	// Время последнего использования обновляется не чаще раза в минуту
+	apiKeyTouchInterval = time.Minute
+)
+
+// CreateAPIKey выдает персональный токен доступа. Права ключа ограничены
+// scopes, и выдать можно только те права, что есть у роли владельца.
+func (s *UserServiceServer) CreateAPIKey(ctx context.Context, req *proto.CreateAPIKeyRequest) (*proto.CreateAPIKeyResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "name must be at most %d characters", maxAPIKeyNameLength)
+	}
+	if req.ExpiresIn < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "expires_in must not be negative")
+	}
+
+	user, err := s.getUser(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := apiKeyScopes(req.Scopes, s.tokenRole(user))
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.apiKeyRepo.ListAPIKeys(ctx, user.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list API keys: %v", err)
+	}
+	// Истекшие ключи в лимит не входят
+	now := time.Now()
+	active := 0
+	for _, key := range existing {
+		if key.ExpiresAt == nil || now.Before(*key.ExpiresAt) {
+			active++
+		}
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, status.Errorf(codes.FailedPrecondition, "at most %d API keys are allowed, revoke unused ones", maxAPIKeysPerUser)
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
+	}
+	token := apikey.Prefix + secret
+
+	key := &models.APIKey{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    token[:len(apikey.Prefix)+apiKeyVisibleChars],
+		TokenHash: hashToken(token),
+		Scopes:    strings.Join(scopes, " "),
+		CreatedAt: now,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := now.Add(time.Duration(req.ExpiresIn) * time.Second)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, key); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save API key: %v", err)
+	}
+
+	return &proto.CreateAPIKeyResponse{ApiKey: apiKeyToProto(key), Token: token}, nil
+}
+
+func (s *UserServiceServer) ListAPIKeys(ctx context.Context, req *proto.ListAPIKeysRequest) (*proto.ListAPIKeysResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	keys, err := s.apiKeyRepo.ListAPIKeys(ctx, uint(userID))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list API keys: %v", err)
+	}
+
+	resp := &proto.ListAPIKeysResponse{}
+	for i := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, apiKeyToProto(&keys[i]))
+	}
+	return resp, nil
+}
+
+func (s *UserServiceServer) RevokeAPIKey(ctx context.Context, req *proto.RevokeAPIKeyRequest) (*proto.RevokeAPIKeyResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	keyID, err := strconv.ParseUint(req.ApiKeyId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid API key ID format")
+	}
+
+	if err := s.apiKeyRepo.RevokeAPIKey(ctx, uint(userID), uint(keyID), time.Now()); err != nil {
+		// Чужой ключ неотличим от несуществующего
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "API key not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to revoke API key: %v", err)
+	}
+	return &proto.RevokeAPIKeyResponse{Message: "API key revoked successfully"}, nil
+}
+
+// validateAPIKey — ValidateToken для API-ключа. Роль берется из базы, так
+// что смена роли и блокировка владельца действуют сразу.
+func (s *UserServiceServer) validateAPIKey(ctx context.Context, token string) (*proto.ValidateTokenResponse, error) {
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid API key")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get API key: %v", err)
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "API key has been revoked")
+	}
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, status.Errorf(codes.Unauthenticated, "API key has expired")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid API key")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if user.DisabledAt != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user is disabled")
+	}
+	if !user.EmailVerified && s.cfg.RequireVerifiedEmail {
+		return nil, status.Errorf(codes.Unauthenticated, "email address is not verified")
+	}
+
+	// Ошибка записи не должна мешать запросу
+	if err := s.apiKeyRepo.TouchAPIKey(ctx, key.ID, now, apiKeyTouchInterval); err != nil {
+		log.Printf("failed to update API key %d last use: %v", key.ID, err)
+	}
+
+	return &proto.ValidateTokenResponse{
+		IsValid: true,
+		UserId:  fmt.Sprintf("%d", user.ID),
+		Role:    s.tokenRole(user),
+		ApiKey:  true,
+		Scopes:  strings.Fields(key.Scopes),
+	}, nil
+}
+
+// apiKeyScopes проверяет запрошенные права и возвращает их без повторов
+func apiKeyScopes(requested []string, role string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one scope is required")
+	}
+	seen := make(map[string]bool, len(requested))
+	var scopes []string
+	for _, scope := range requested {
+		permission := rbac.Permission(scope)
+		if !rbac.ValidPermission(permission) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown scope %q", scope)
+		}
+		if !rbac.HasPermission(role, permission) {
+			return nil, status.Errorf(codes.PermissionDenied, "scope %s is not granted to your role", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+func apiKeyToProto(key *models.APIKey) *proto.APIKey {
+	result := &proto.APIKey{
+		Id:        fmt.Sprintf("%d", key.ID),
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    strings.Fields(key.Scopes),
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if key.ExpiresAt != nil {
+		result.ExpiresAt = timestamppb.New(*key.ExpiresAt)
+	}
+	if key.LastUsedAt != nil {
+		result.LastUsedAt = timestamppb.New(*key.LastUsedAt)
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/mailer"
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/rbac"
+)
+
+// RFC 5321 ограничивает длину пути (адреса) 254 символами
+const maxEmailLength = 254
+
+const verificationResendMessage = "If an unverified account with this email exists, a verification link has been sent"
+
+// VerifyEmail подтверждает адрес по токену из письма. Токены, выданные до
+// подтверждения, несут ограниченную роль — права расширятся после
+// обновления токенов через Refresh.
+func (s *UserServiceServer) VerifyEmail(ctx context.Context, req *proto.VerifyEmailRequest) (*proto.VerifyEmailResponse, error) {
+	if req.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "verification token is required")
+	}
+
+	token, err := s.userTokenRepo.ConsumeUserToken(ctx, models.UserTokenEmailVerification, hashToken(req.Token), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired verification token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to use verification token: %v", err)
+	}
+
+	if err := s.userRepo.MarkEmailVerified(ctx, token.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+	}
+	return &proto.VerifyEmailResponse{Message: "Email verified successfully"}, nil
+}
+
+// ResendVerificationEmail отправляет новую ссылку; прежние перестают действовать
+func (s *UserServiceServer) ResendVerificationEmail(ctx context.Context, req *proto.ResendVerificationEmailRequest) (*proto.VerifyEmailResponse, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &proto.VerifyEmailResponse{Message: verificationResendMessage}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if user.EmailVerified || user.DisabledAt != nil {
+		return &proto.VerifyEmailResponse{Message: verificationResendMessage}, nil
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return nil, err
+	}
+	return &proto.VerifyEmailResponse{Message: verificationResendMessage}, nil
+}
+
+// sendVerificationEmail выдает токен подтверждения и отправляет письмо.
+// Ошибка доставки только пишется в лог: письмо можно запросить повторно.
+func (s *UserServiceServer) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, expiresAt, err := s.issueUserToken(ctx, user.ID, models.UserTokenEmailVerification, s.cfg.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	if err := s.mailer.Send(ctx, verificationMail(user.Email, s.cfg.EmailVerificationURL, token, expiresAt)); err != nil {
+		log.Printf("email verification: failed to send mail to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// issueUserToken выдает одноразовый токен с назначением purpose. Прежние
+// неиспользованные токены с тем же назначением перестают действовать:
+// работает только ссылка из последнего письма.
+func (s *UserServiceServer) issueUserToken(ctx context.Context, userID uint, purpose string, ttl time.Duration) (string, time.Time, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", time.Time{}, status.Errorf(codes.Internal, "failed to generate token: %v", err)
+	}
+	now := time.Now()
+	if err := s.userTokenRepo.InvalidateUserTokens(ctx, userID, purpose, now); err != nil {
+		return "", time.Time{}, status.Errorf(codes.Internal, "failed to invalidate tokens: %v", err)
+	}
+	err = s.userTokenRepo.CreateUserToken(ctx, &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", time.Time{}, status.Errorf(codes.Internal, "failed to save token: %v", err)
+	}
+	return token, now.Add(ttl), nil
+}
+
+// tokenRole — роль, которая попадает в access-токен. Пока email не
+// подтвержден, вместо настоящей роли выдается rbac.RoleUnverified.
+func (s *UserServiceServer) tokenRole(user *models.User) string {
+	if !user.EmailVerified {
+		return rbac.RoleUnverified
+	}
+	return user.Role
+}
+
+// normalizeEmail проверяет синтаксис адреса и приводит его к виду,
+// в котором он хранится: без пробелов по краям и в нижнем регистре
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", status.Errorf(codes.InvalidArgument, "email is required")
+	}
+	if len(email) > maxEmailLength {
+		return "", status.Errorf(codes.InvalidArgument, "email must be at most %d characters", maxEmailLength)
+	}
+	// ParseAddress принимает и "Имя <адрес>"; нужен только сам адрес
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@")+1:], ".") {
+		return "", status.Errorf(codes.InvalidArgument, "invalid email address")
+	}
+	return strings.ToLower(email), nil
+}
+
+func tokenLink(baseURL, token string) string {
+	sep := "?"
+	if strings.Contains(baseURL, "?") {
+		sep = "&"
+	}
+	return baseURL + sep + "token=" + url.QueryEscape(token)
+}
+
+func verificationMail(to, verifyURL, token string, expiresAt time.Time) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Welcome! To confirm your email address, open this link before %s:\n%s\n\n"+
+			"If you did not create an account, ignore this email.\n",
+			expiresAt.UTC().Format(time.RFC1123), tokenLink(verifyURL, token)),
+	}
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/rbac"
+)
+
+// identityLinkTTL — сколько действует токен привязки: столько у пользователя
+// есть на вход у провайдера
+const identityLinkTTL = 10 * time.Minute
+
+// ExternalLogin входит по учетной записи внешнего провайдера. Claims
+// ID-токена уже проверены шлюзом.
+func (s *UserServiceServer) ExternalLogin(ctx context.Context, req *proto.ExternalLoginRequest) (*proto.LoginResponse, error) {
+	if err := checkExternalIdentity(req.Identity); err != nil {
+		return nil, err
+	}
+	user, err := s.externalUser(ctx, req.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLoginAllowed(user); err != nil {
+		return nil, err
+	}
+
+	// Провайдер заменяет пароль, но не второй фактор
+	enabled, err := s.secondFactorEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		challenge, _, err := s.issueUserToken(ctx, user.ID, models.UserTokenLoginChallenge, loginChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &proto.LoginResponse{SecondFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// externalUser находит пользователя по учетной записи провайдера. Новая
+// учетная запись привязывается к пользователю с тем же email, только если
+// адрес подтвердили и провайдер, и сам пользователь: иначе можно было бы
+// заранее зарегистрироваться на чужой адрес и получить доступ к аккаунту
+// после первого входа владельца через SSO. Если пользователя нет, он
+// создается без пароля.
+func (s *UserServiceServer) externalUser(ctx context.Context, ext *proto.ExternalIdentity) (*models.User, error) {
+	now := time.Now()
+	identity, err := s.identityRepo.GetIdentity(ctx, ext.Provider, ext.Subject)
+	switch {
+	case err == nil:
+		user, err := s.userRepo.GetUserByID(ctx, identity.UserID)
+		if err == nil {
+			if err := s.identityRepo.TouchIdentity(ctx, identity.ID, ext.Email, now); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to update identity: %v", err)
+			}
+			return user, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+		}
+		// Пользователя удалили, а учетная запись провайдера осталась: она
+		// отвязывается, и вход продолжается как первый
+		if err := s.identityRepo.DeleteIdentity(ctx, identity.UserID, identity.Provider); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed to delete identity: %v", err)
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, status.Errorf(codes.Internal, "failed to get identity: %v", err)
+	}
+
+	email, err := normalizeEmail(ext.Email)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "identity provider did not return a valid email address")
+	}
+	identity = &models.Identity{
+		Provider:    ext.Provider,
+		Subject:     ext.Subject,
+		Email:       ext.Email,
+		LastLoginAt: &now,
+		CreatedAt:   now,
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !ext.EmailVerified || !user.EmailVerified {
+			return nil, status.Errorf(codes.FailedPrecondition, "an account with this email already exists, sign in with your password and link %s in account settings", ext.Provider)
+		}
+		identity.UserID = user.ID
+		if err := s.identityRepo.CreateIdentity(ctx, identity); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, status.Errorf(codes.FailedPrecondition, "the account is already linked to another %s identity", ext.Provider)
+			}
+			return nil, status.Errorf(codes.Internal, "failed to link identity: %v", err)
+		}
+		return user, nil
+
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user = &models.User{
+			Email:         email,
+			Role:          rbac.DefaultRole,
+			EmailVerified: ext.EmailVerified,
+		}
+		if err := s.identityRepo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, status.Errorf(codes.Aborted, "the account was created concurrently, try again")
+			}
+			return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
+		}
+		if !user.EmailVerified {
+			if err := s.sendVerificationEmail(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+
+	default:
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+}
+
+func (s *UserServiceServer) CreateIdentityLinkToken(ctx context.Context, req *proto.CreateIdentityLinkTokenRequest) (*proto.CreateIdentityLinkTokenResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	user, err := s.getUser(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	token, _, err := s.issueUserToken(ctx, user.ID, models.UserTokenIdentityLink, identityLinkTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.CreateIdentityLinkTokenResponse{LinkToken: token}, nil
+}
+
+// LinkIdentity привязывает учетную запись провайдера к пользователю,
+// получившему токен привязки
+func (s *UserServiceServer) LinkIdentity(ctx context.Context, req *proto.LinkIdentityRequest) (*proto.Identity, error) {
+	if err := checkExternalIdentity(req.Identity); err != nil {
+		return nil, err
+	}
+	ext := req.Identity
+	if req.LinkToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "link token is required")
+	}
+
+	now := time.Now()
+	token, err := s.userTokenRepo.ConsumeUserToken(ctx, models.UserTokenIdentityLink, hashToken(req.LinkToken), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired link token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to use link token: %v", err)
+	}
+
+	existing, err := s.identityRepo.GetIdentity(ctx, ext.Provider, ext.Subject)
+	if err == nil {
+		if existing.UserID != token.UserID {
+			return nil, status.Errorf(codes.AlreadyExists, "this %s identity is linked to another user", ext.Provider)
+		}
+		return identityToProto(existing), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.Internal, "failed to get identity: %v", err)
+	}
+
+	identity := &models.Identity{
+		UserID:    token.UserID,
+		Provider:  ext.Provider,
+		Subject:   ext.Subject,
+		Email:     ext.Email,
+		CreatedAt: now,
+	}
+	if err := s.identityRepo.CreateIdentity(ctx, identity); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, status.Errorf(codes.AlreadyExists, "the account is already linked to another %s identity", ext.Provider)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to link identity: %v", err)
+	}
+	return identityToProto(identity), nil
+}
+
+func (s *UserServiceServer) ListIdentities(ctx context.Context, req *proto.ListIdentitiesRequest) (*proto.ListIdentitiesResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	identities, err := s.identityRepo.ListIdentities(ctx, uint(userID))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list identities: %v", err)
+	}
+	resp := &proto.ListIdentitiesResponse{}
+	for i := range identities {
+		resp.Identities = append(resp.Identities, identityToProto(&identities[i]))
+	}
+	return resp, nil
+}
+
+func (s *UserServiceServer) UnlinkIdentity(ctx context.Context, req *proto.UnlinkIdentityRequest) (*proto.UnlinkIdentityResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	user, err := s.getUser(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identityRepo.ListIdentities(ctx, user.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list identities: %v", err)
+	}
+	if user.Password == "" && len(identities) == 1 && identities[0].Provider == req.Provider {
+		return nil, status.Errorf(codes.FailedPrecondition, "set a password before unlinking the last identity provider")
+	}
+
+	if err := s.identityRepo.DeleteIdentity(ctx, user.ID, req.Provider); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "identity not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to unlink identity: %v", err)
+	}
+	return &proto.UnlinkIdentityResponse{Message: "Identity unlinked"}, nil
+}
+
+func checkExternalIdentity(ext *proto.ExternalIdentity) error {
+	if ext == nil || strings.TrimSpace(ext.Provider) == "" || ext.Subject == "" {
+		return status.Errorf(codes.InvalidArgument, "provider and subject are required")
+	}
+	return nil
+}
+
+func identityToProto(identity *models.Identity) *proto.Identity {
+	result := &proto.Identity{
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: timestamppb.New(identity.CreatedAt),
+	}
+	if identity.LastLoginAt != nil {
+		result.LastLoginAt = timestamppb.New(*identity.LastLoginAt)
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"server/internal/proto"
+)
+
+func TestExternalLoginAfterUserDeleted(t *testing.T) {
+	env := newUserTestEnv(t)
+	req := &proto.ExternalLoginRequest{Identity: &proto.ExternalIdentity{
+		Provider:      "corp",
+		Subject:       "42",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+	}}
+	if _, err := env.service.ExternalLogin(context.Background(), req); err != nil {
+		t.Fatalf("ExternalLogin: %v", err)
+	}
+	first, err := env.identities.GetIdentity(context.Background(), "corp", "42")
+	if err != nil {
+		t.Fatalf("GetIdentity: %v", err)
+	}
+
+	// Учетная запись провайдера пережила своего пользователя
+	if err := env.users.DeleteUser(context.Background(), first.UserID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := env.service.ExternalLogin(context.Background(), req); err != nil {
+		t.Fatalf("ExternalLogin after user was deleted: %v", err)
+	}
+	second, err := env.identities.GetIdentity(context.Background(), "corp", "42")
+	if err != nil {
+		t.Fatalf("GetIdentity: %v", err)
+	}
+	if second.UserID == first.UserID {
+		t.Fatalf("identity still points to the deleted user %d", first.UserID)
+	}
+	if _, err := env.users.GetUserByID(context.Background(), second.UserID); err != nil {
+		t.Fatalf("new user: %v", err)
+	}
+}
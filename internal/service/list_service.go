@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/repository"
+)
+
+func (s *TodoServiceServer) CreateList(ctx context.Context, req *proto.CreateListRequest) (*proto.TodoList, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "list name is required")
+	}
+
+	list := &models.List{UserID: uint(userID), Name: name}
+	if err := s.listRepo.CreateList(ctx, list); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create list: %v", err)
+	}
+
+	return listToProto(list), nil
+}
+
+func (s *TodoServiceServer) GetLists(ctx context.Context, req *proto.GetListsRequest) (*proto.GetListsResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	// Inbox создается лениво, поэтому гарантируем его наличие до выборки
+	if _, err := s.listRepo.GetOrCreateInbox(ctx, uint(userID)); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get inbox: %v", err)
+	}
+	lists, err := s.listRepo.GetListsByUserID(ctx, uint(userID))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get lists: %v", err)
+	}
+
+	var items []*proto.TodoList
+	for _, list := range lists {
+		items = append(items, listToProto(list))
+	}
+	return &proto.GetListsResponse{Lists: items}, nil
+}
+
+func (s *TodoServiceServer) UpdateList(ctx context.Context, req *proto.UpdateListRequest) (*proto.TodoList, error) {
+	list, err := s.getOwnedList(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if list.IsInbox {
+		return nil, status.Errorf(codes.FailedPrecondition, "inbox cannot be renamed")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "list name is required")
+	}
+
+	list.Name = name
+	if err := s.listRepo.UpdateList(ctx, list); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update list: %v", err)
+	}
+
+	return listToProto(list), nil
+}
+
+func (s *TodoServiceServer) DeleteList(ctx context.Context, req *proto.DeleteListRequest) (*proto.DeleteListResponse, error) {
+	list, err := s.getOwnedList(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if list.IsInbox {
+		return nil, status.Errorf(codes.FailedPrecondition, "inbox cannot be deleted")
+	}
+
+	inbox, err := s.listRepo.GetOrCreateInbox(ctx, list.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get inbox: %v", err)
+	}
+	if err := s.listRepo.DeleteList(ctx, list, inbox.ID, req.Cascade); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete list: %v", err)
+	}
+
+	return &proto.DeleteListResponse{Message: "List deleted successfully"}, nil
+}
+
+func (s *TodoServiceServer) MoveTodo(ctx context.Context, req *proto.MoveTodoRequest) (*proto.TodoItem, error) {
+	todoID, err := strconv.ParseUint(req.Id, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid todo ID format")
+	}
+
+	todo, err := s.todoRepo.GetTodoByID(ctx, uint(todoID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "todo not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get todo: %v", err)
+	}
+
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	if todo.UserID != uint(userID) {
+		return nil, status.Errorf(codes.PermissionDenied, "you don't have permission to move this todo")
+	}
+	if req.ExpectedVersion != 0 && todo.Version != req.ExpectedVersion {
+		return nil, status.Errorf(codes.Aborted, "todo version mismatch: expected %d, current %d", req.ExpectedVersion, todo.Version)
+	}
+
+	if todo.ParentID != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "subtasks are moved together with their parent")
+	}
+
+	list, err := s.resolveList(ctx, req.ListId, todo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if todo.ListID == list.ID {
+		return todoToProto(todo), nil
+	}
+	descendants, err := s.loadSubtree(ctx, todo, todo.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	todo.ListID = list.ID
+	if todo.Position, err = s.appendPosition(ctx, todo.UserID, list.ID); err != nil {
+		return nil, err
+	}
+	update := repository.TodoUpdate{
+		Todo:           todo,
+		SubtaskIDs:     todoIDs(descendants),
+		SubtaskColumns: map[string]interface{}{"list_id": list.ID},
+	}
+	if err := s.todoRepo.UpdateTodo(ctx, update); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, status.Errorf(codes.Aborted, "todo was modified concurrently, reload and retry")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to move todo: %v", err)
+	}
+
+	return todoToProto(todo), nil
+}
+
+// resolveList возвращает список пользователя по строковому ID,
+// а для пустого ID — его Inbox
+func (s *TodoServiceServer) resolveList(ctx context.Context, listID string, userID uint) (*models.List, error) {
+	if listID == "" {
+		inbox, err := s.listRepo.GetOrCreateInbox(ctx, userID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get inbox: %v", err)
+		}
+		return inbox, nil
+	}
+	return s.getOwnedList(ctx, listID, fmt.Sprintf("%d", userID))
+}
+
+// getOwnedList загружает список и проверяет, что он принадлежит пользователю.
+// Чужой список неотличим от несуществующего.
+func (s *TodoServiceServer) getOwnedList(ctx context.Context, id, userID string) (*models.List, error) {
+	listID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid list ID format")
+	}
+	ownerID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	list, err := s.listRepo.GetListByID(ctx, uint(listID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "list not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get list: %v", err)
+	}
+	if list.UserID != uint(ownerID) {
+		return nil, status.Errorf(codes.NotFound, "list not found")
+	}
+	return list, nil
+}
+
+func listToProto(list *models.List) *proto.TodoList {
+	return &proto.TodoList{
+		Id:      fmt.Sprintf("%d", list.ID),
+		UserId:  fmt.Sprintf("%d", list.UserID),
+		Name:    list.Name,
+		IsInbox: list.IsInbox,
+	}
+}
+
+// formatListID: задачи, созданные до появления списков, лежат в Inbox,
+// который еще не создан, — для них list_id пустой
+func formatListID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", id)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"server/internal/clientip"
+	"server/internal/proto"
+)
+
+// dummyPasswordHash сравнивается с паролем, когда пользователя нет, чтобы
+// по времени ответа нельзя было отличить неизвестный email от неверного пароля
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// loginKey — email, по которому считаются попытки входа. Некорректные
+// адреса тоже считаются, чтобы перебор вариантов написания не обходил лимит.
+func loginKey(email string) string {
+	if normalized, err := normalizeEmail(email); err == nil {
+		return normalized
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkLoginThrottle отклоняет попытку входа, если аккаунт или адрес
+// клиента временно заблокированы после неудачных попыток
+func (s *UserServiceServer) checkLoginThrottle(ctx context.Context, email string) error {
+	wait, err := s.loginGuard.Check(ctx, email, clientip.FromIncomingContext(ctx))
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to check login attempts: %v", err)
+	}
+	if wait > 0 {
+		return tooManyAttempts(wait)
+	}
+	return nil
+}
+
+// loginFailed засчитывает неудачную попытку и возвращает ошибку для клиента
+func (s *UserServiceServer) loginFailed(ctx context.Context, email string, failure error) error {
+	if err := s.loginGuard.Failure(ctx, email, clientip.FromIncomingContext(ctx)); err != nil {
+		return status.Errorf(codes.Internal, "failed to record login attempt: %v", err)
+	}
+	return failure
+}
+
+// loginSucceeded сбрасывает счетчик аккаунта. Ошибка только логируется:
+// вход уже состоялся, а счетчик все равно сбросится со временем.
+func (s *UserServiceServer) loginSucceeded(ctx context.Context, email string) {
+	if err := s.loginGuard.Success(ctx, email); err != nil {
+		log.Printf("failed to reset login attempts: %v", err)
+	}
+}
+
+// tooManyAttempts — ошибка с RetryInfo, по которой шлюз выставляет Retry-After
+func tooManyAttempts(wait time.Duration) error {
+	// Округление вверх, чтобы клиент не пришел раньше времени
+	seconds := int64((wait + time.Second - 1) / time.Second)
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("too many failed login attempts, try again in %d seconds", seconds))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(seconds) * time.Second)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// UnlockUser снимает временную блокировку входа в аккаунт. Блокировка
+// по IP-адресу не снимается: она не привязана к пользователю.
+func (s *UserServiceServer) UnlockUser(ctx context.Context, req *proto.UserActionRequest) (*proto.User, error) {
+	targetID, err := parseTargetUserID(req.TargetUserId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.getUser(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loginGuard.Unlock(ctx, user.Email); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unlock user: %v", err)
+	}
+	return userToProto(user), nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/proto"
+)
+
+// Logout отзывает предъявленный access-токен до истечения его срока
+// и завершает его сессию, а вместе с refresh-токеном — и все семейство,
+// полученное ротацией
+func (s *UserServiceServer) Logout(ctx context.Context, req *proto.LogoutRequest) (*proto.LogoutResponse, error) {
+	claims, err := s.parseToken(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if claims.JTI != "" {
+		if err := s.revocations.RevokeToken(ctx, claims.JTI, claims.ExpiresAt); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke token: %v", err)
+		}
+	} else {
+		// Старый токен без jti отозвать точечно нельзя
+		if err := s.revocations.RevokeUserTokens(ctx, claims.UserID, now, now.Add(s.cfg.AccessTokenTTL)); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke token: %v", err)
+		}
+	}
+
+	if claims.SessionID != "" {
+		if err := s.revokeSession(ctx, claims.UserID, claims.SessionID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+		}
+	}
+
+	if req.RefreshToken != "" {
+		stored, err := s.refreshTokenRepo.GetRefreshTokenByHash(ctx, hashToken(req.RefreshToken))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed to get refresh token: %v", err)
+		}
+		// Чужой или неизвестный refresh-токен молча пропускаем
+		if err == nil && stored.UserID == claims.UserID {
+			if err := s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to revoke refresh token: %v", err)
+			}
+		}
+	}
+
+	return &proto.LogoutResponse{Message: "Logged out successfully"}, nil
+}
+
+// LogoutAll отзывает все access- и refresh-токены пользователя
+func (s *UserServiceServer) LogoutAll(ctx context.Context, req *proto.LogoutAllRequest) (*proto.LogoutResponse, error) {
+	claims, err := s.parseToken(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.revokeAllTokens(ctx, claims.UserID); err != nil {
+		return nil, err
+	}
+
+	return &proto.LogoutResponse{Message: "Logged out from all sessions"}, nil
+}
+
+// IsTokenRevoked отвечает шлюзу, который проверяет подпись токенов сам
+// и сверяется здесь только со списком отзывов и сессиями
+func (s *UserServiceServer) IsTokenRevoked(ctx context.Context, req *proto.IsTokenRevokedRequest) (*proto.IsTokenRevokedResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, req.Jti, uint(userID), time.UnixMicro(req.IssuedAtMicros))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check token revocation: %v", err)
+	}
+	if !revoked {
+		active, err := s.sessionActive(ctx, req.SessionId)
+		if err != nil {
+			return nil, err
+		}
+		revoked = !active
+	}
+	return &proto.IsTokenRevokedResponse{Revoked: revoked}, nil
+}
+
+// revokeAllTokens делает недействительными все уже выданные токены пользователя.
+// Запись об отзыве access-токенов живет, пока не истечет самый поздний из них.
+func (s *UserServiceServer) revokeAllTokens(ctx context.Context, userID uint) error {
+	now := time.Now()
+	if err := s.revocations.RevokeUserTokens(ctx, userID, now, now.Add(s.cfg.AccessTokenTTL)); err != nil {
+		return status.Errorf(codes.Internal, "failed to revoke tokens: %v", err)
+	}
+	if err := s.refreshTokenRepo.RevokeUserTokens(ctx, userID, now); err != nil {
+		return status.Errorf(codes.Internal, "failed to revoke refresh tokens: %v", err)
+	}
+	if err := s.sessionRepo.RevokeUserSessions(ctx, userID, now); err != nil {
+		return status.Errorf(codes.Internal, "failed to revoke sessions: %v", err)
+	}
+	return nil
+}
+
+func timeToFloat(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
+func floatToTime(f float64) time.Time {
+	return time.UnixMicro(int64(math.Round(f * 1e6)))
+}
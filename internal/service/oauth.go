@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/oidc"
+	"server/internal/proto"
+	"server/internal/repository"
+)
+
+// Сервер авторизации OAuth 2.0 / OpenID Connect. Поддерживается только
+// код авторизации с обязательным PKCE. Access-токены непрозрачны и дают
+// доступ только к userinfo: приложение узнает, кто вошел, но не получает
+// доступа к задачам пользователя.
+
+const (
+	// Срок жизни кода авторизации; RFC 6749 советует не больше 10 минут
+	oauthCodeTTL = 5 * time.Minute
+	// Domain в google.rpc.ErrorInfo, reason — код ошибки OAuth
+	oauthErrorDomain         = "oauth2"
+	maxOAuthClientNameLength = 100
+)
+
+// oauthError — ошибка с кодом OAuth (RFC 6749, 5.2), который шлюз
+// отдает клиенту как есть
+func oauthError(code codes.Code, reason, format string, args ...interface{}) error {
+	st := status.New(code, fmt.Sprintf(format, args...))
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: oauthErrorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func (s *UserServiceServer) CreateOAuthClient(ctx context.Context, req *proto.CreateOAuthClientRequest) (*proto.CreateOAuthClientResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+	if utf8.RuneCountInString(name) > maxOAuthClientNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "name must be at most %d characters", maxOAuthClientNameLength)
+	}
+	if len(req.RedirectUris) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one redirect URI is required")
+	}
+	var redirectURIs []string
+	seen := make(map[string]bool, len(req.RedirectUris))
+	for _, uri := range req.RedirectUris {
+		if err := validateRedirectURI(uri); err != nil {
+			return nil, err
+		}
+		if !seen[uri] {
+			seen[uri] = true
+			redirectURIs = append(redirectURIs, uri)
+		}
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate client ID: %v", err)
+	}
+	client := &models.OAuthClient{
+		ClientID:     hex.EncodeToString(idBytes),
+		Name:         name,
+		RedirectURIs: strings.Join(redirectURIs, " "),
+		CreatedBy:    uint(userID),
+		CreatedAt:    time.Now(),
+	}
+	var secret string
+	if req.Confidential {
+		if secret, err = randomToken(); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to generate client secret: %v", err)
+		}
+		client.SecretHash = hashToken(secret)
+	}
+	if err := s.oauthRepo.CreateOAuthClient(ctx, client); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create client: %v", err)
+	}
+
+	return &proto.CreateOAuthClientResponse{Client: oauthClientToProto(client), ClientSecret: secret}, nil
+}
+
+func (s *UserServiceServer) ListOAuthClients(ctx context.Context, req *proto.ListOAuthClientsRequest) (*proto.ListOAuthClientsResponse, error) {
+	clients, err := s.oauthRepo.ListOAuthClients(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list clients: %v", err)
+	}
+
+	resp := &proto.ListOAuthClientsResponse{}
+	for i := range clients {
+		resp.Clients = append(resp.Clients, oauthClientToProto(&clients[i]))
+	}
+	return resp, nil
+}
+
+// DeleteOAuthClient удаляет клиента; выданные ему токены перестают действовать
+func (s *UserServiceServer) DeleteOAuthClient(ctx context.Context, req *proto.DeleteOAuthClientRequest) (*proto.DeleteOAuthClientResponse, error) {
+	if err := s.oauthRepo.DeleteOAuthClient(ctx, req.ClientId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "client not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete client: %v", err)
+	}
+	return &proto.DeleteOAuthClientResponse{Message: "Client deleted successfully"}, nil
+}
+
+func (s *UserServiceServer) ValidateAuthorizeRequest(ctx context.Context, req *proto.AuthorizeRequest) (*proto.ValidateAuthorizeResponse, error) {
+	client, redirectURI, err := s.resolveOAuthClient(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	scopes, reason, description := checkAuthorizeParams(req)
+	if reason != "" {
+		return &proto.ValidateAuthorizeResponse{
+			ErrorRedirectTo: withQuery(redirectURI, oauthErrorParams(reason, description, req.State)),
+		}, nil
+	}
+	return &proto.ValidateAuthorizeResponse{ClientName: client.Name, Scopes: scopes}, nil
+}
+
+// Authorize выдает код авторизации. Согласие пользователя получает
+// страница входа до вызова: сам вызов и означает согласие.
+func (s *UserServiceServer) Authorize(ctx context.Context, req *proto.AuthorizeRequest) (*proto.AuthorizeResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	client, redirectURI, err := s.resolveOAuthClient(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	scopes, reason, description := checkAuthorizeParams(req)
+	if reason != "" {
+		return &proto.AuthorizeResponse{RedirectTo: withQuery(redirectURI, oauthErrorParams(reason, description, req.State))}, nil
+	}
+
+	user, err := s.getUser(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "user is disabled")
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate code: %v", err)
+	}
+	now := time.Now()
+	err = s.oauthRepo.CreateOAuthCode(ctx, &models.OAuthCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectUri,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     now.Add(oauthCodeTTL),
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save code: %v", err)
+	}
+
+	params := url.Values{"code": {code}, "iss": {s.cfg.OAuthIssuer}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return &proto.AuthorizeResponse{RedirectTo: withQuery(redirectURI, params)}, nil
+}
+
+// OAuthToken обменивает код авторизации на access-токен и, для scope
+// openid, ID-токен
+func (s *UserServiceServer) OAuthToken(ctx context.Context, req *proto.OAuthTokenRequest) (*proto.OAuthTokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, oauthError(codes.InvalidArgument, "unsupported_grant_type", "grant type %q is not supported", req.GrantType)
+	}
+	client, err := s.authenticateOAuthClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if req.Code == "" {
+		return nil, oauthError(codes.InvalidArgument, "invalid_request", "code is required")
+	}
+	if req.CodeVerifier == "" {
+		return nil, oauthError(codes.InvalidArgument, "invalid_request", "code_verifier is required")
+	}
+
+	code, err := s.oauthRepo.GetOAuthCode(ctx, hashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(codes.InvalidArgument, "invalid_grant", "invalid authorization code")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get code: %v", err)
+	}
+	// Все проверки — до того, как код будет использован: иначе перехвативший
+	// код без code_verifier мог бы сжечь его
+	now := time.Now()
+	if code.ClientID != client.ClientID || !now.Before(code.ExpiresAt) {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "invalid authorization code")
+	}
+	if code.RedirectURI != "" && req.RedirectUri != code.RedirectURI {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "redirect_uri does not match the authorization request")
+	}
+	challenge := oidc.S256Challenge(req.CodeVerifier)
+	if !oidc.ValidVerifier(req.CodeVerifier) || subtle.ConstantTimeCompare([]byte(challenge), []byte(code.CodeChallenge)) != 1 {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "code_verifier does not match code_challenge")
+	}
+
+	if err := s.oauthRepo.ConsumeOAuthCode(ctx, code.ID, now); err != nil {
+		if errors.Is(err, repository.ErrOAuthCodeUsed) {
+			log.Printf("oauth: authorization code reuse detected for client %s, user %d", client.ClientID, code.UserID)
+			return nil, oauthError(codes.InvalidArgument, "invalid_grant", "authorization code was already used")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to use code: %v", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(codes.InvalidArgument, "invalid_grant", "invalid authorization code")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if user.DisabledAt != nil {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "user is disabled")
+	}
+
+	accessToken, err := randomToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
+	}
+	err = s.oauthRepo.CreateOAuthToken(ctx, &models.OAuthToken{
+		TokenHash: hashToken(accessToken),
+		CodeID:    code.ID,
+		ClientID:  client.ClientID,
+		UserID:    user.ID,
+		Scope:     code.Scope,
+		ExpiresAt: now.Add(s.cfg.AccessTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save token: %v", err)
+	}
+
+	resp := &proto.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.AccessTokenTTL / time.Second),
+		Scope:       code.Scope,
+	}
+	scopes := strings.Fields(code.Scope)
+	if hasScope(scopes, oidc.ScopeOpenID) {
+		resp.IdToken, err = s.generateIDToken(user, client.ClientID, code.Nonce, scopes, now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to generate ID token: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+// OAuthUserInfo возвращает данные пользователя по access-токену OAuth
+func (s *UserServiceServer) OAuthUserInfo(ctx context.Context, req *proto.OAuthUserInfoRequest) (*proto.OAuthUserInfoResponse, error) {
+	if req.AccessToken == "" {
+		return nil, oauthError(codes.Unauthenticated, "invalid_token", "access token is required")
+	}
+	token, err := s.oauthRepo.GetOAuthToken(ctx, hashToken(req.AccessToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(codes.Unauthenticated, "invalid_token", "invalid access token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get token: %v", err)
+	}
+	if token.RevokedAt != nil || !time.Now().Before(token.ExpiresAt) {
+		return nil, oauthError(codes.Unauthenticated, "invalid_token", "access token is expired or revoked")
+	}
+	scopes := strings.Fields(token.Scope)
+	if !hasScope(scopes, oidc.ScopeOpenID) {
+		return nil, oauthError(codes.PermissionDenied, "insufficient_scope", "scope openid is required")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(codes.Unauthenticated, "invalid_token", "invalid access token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if user.DisabledAt != nil {
+		return nil, oauthError(codes.Unauthenticated, "invalid_token", "user is disabled")
+	}
+
+	resp := &proto.OAuthUserInfoResponse{Sub: fmt.Sprintf("%d", user.ID)}
+	if hasScope(scopes, oidc.ScopeEmail) {
+		resp.Email = user.Email
+		resp.EmailVerified = user.EmailVerified
+	}
+	return resp, nil
+}
+
+// RunOAuthCleanup периодически удаляет истекшие коды и токены OAuth.
+// Блокируется до отмены ctx.
+func (s *UserServiceServer) RunOAuthCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.oauthRepo.DeleteExpiredOAuthGrants(ctx, now.Add(-s.cfg.AccessTokenTTL)); err != nil {
+				log.Printf("oauth: failed to delete expired grants: %v", err)
+			}
+		}
+	}
+}
+
+// generateIDToken подписывает ID-токен тем же ключом, что и access-токены
+// приложения, так что его можно проверить по JWKS. В нем нет user_id,
+// поэтому шлюз не примет его вместо access-токена.
+func (s *UserServiceServer) generateIDToken(user *models.User, clientID, nonce string, scopes []string, now time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"iss": s.cfg.OAuthIssuer,
+		"sub": fmt.Sprintf("%d", user.ID),
+		"aud": clientID,
+		"iat": now.Unix(),
+		"exp": now.Add(s.cfg.AccessTokenTTL).Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if hasScope(scopes, oidc.ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerified
+	}
+
+	key := s.keys.Current()
+	token := jwt.NewWithClaims(key.SigningMethod(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey())
+}
+
+// resolveOAuthClient находит клиента и адрес возврата. Ошибки здесь
+// возвращаются пользователю, а не клиенту: перенаправлять на
+// непроверенный адрес нельзя.
+func (s *UserServiceServer) resolveOAuthClient(ctx context.Context, req *proto.AuthorizeRequest) (*models.OAuthClient, string, error) {
+	if req.ClientId == "" {
+		return nil, "", status.Errorf(codes.InvalidArgument, "client_id is required")
+	}
+	client, err := s.oauthRepo.GetOAuthClient(ctx, req.ClientId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", status.Errorf(codes.InvalidArgument, "unknown client")
+		}
+		return nil, "", status.Errorf(codes.Internal, "failed to get client: %v", err)
+	}
+
+	registered := strings.Fields(client.RedirectURIs)
+	if req.RedirectUri == "" {
+		// Без redirect_uri можно обойтись, только если адрес единственный
+		if len(registered) != 1 {
+			return nil, "", status.Errorf(codes.InvalidArgument, "redirect_uri is required")
+		}
+		return client, registered[0], nil
+	}
+	for _, uri := range registered {
+		if uri == req.RedirectUri {
+			return client, uri, nil
+		}
+	}
+	return nil, "", status.Errorf(codes.InvalidArgument, "redirect_uri is not registered for this client")
+}
+
+// authenticateOAuthClient проверяет client_id и, у конфиденциального
+// клиента, секрет
+func (s *UserServiceServer) authenticateOAuthClient(ctx context.Context, clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, oauthError(codes.Unauthenticated, "invalid_client", "client authentication is required")
+	}
+	client, err := s.oauthRepo.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(codes.Unauthenticated, "invalid_client", "client authentication failed")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get client: %v", err)
+	}
+	if client.SecretHash != "" && subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, oauthError(codes.Unauthenticated, "invalid_client", "client authentication failed")
+	}
+	return client, nil
+}
+
+// checkAuthorizeParams проверяет параметры запроса авторизации. Ошибка
+// возвращается кодом OAuth и описанием: о ней сообщают клиенту через
+// адрес возврата.
+func checkAuthorizeParams(req *proto.AuthorizeRequest) (scopes []string, reason, description string) {
+	if req.ResponseType != "code" {
+		return nil, "unsupported_response_type", "only response_type=code is supported"
+	}
+	if req.CodeChallenge == "" {
+		return nil, "invalid_request", "code_challenge is required"
+	}
+	if req.CodeChallengeMethod != oidc.CodeChallengeMethodS256 {
+		return nil, "invalid_request", "code_challenge_method must be S256"
+	}
+	// base64url от SHA-256 без выравнивания
+	if len(req.CodeChallenge) != 43 {
+		return nil, "invalid_request", "invalid code_challenge"
+	}
+
+	scopes = strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		return nil, "invalid_scope", "scope is required"
+	}
+	for _, scope := range scopes {
+		if !hasScope(oidc.Scopes, scope) {
+			return nil, "invalid_scope", fmt.Sprintf("unsupported scope %q", scope)
+		}
+	}
+	return scopes, "", ""
+}
+
+// validateRedirectURI допускает только https и http на loopback-адресе
+// (для локальной разработки и нативных приложений)
+func validateRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return status.Errorf(codes.InvalidArgument, "redirect URI %q must be an absolute URL", raw)
+	}
+	if u.Fragment != "" {
+		return status.Errorf(codes.InvalidArgument, "redirect URI %q must not contain a fragment", raw)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return status.Errorf(codes.InvalidArgument, "redirect URI %q must use https", raw)
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "redirect URI %q must use https", raw)
+	}
+	return nil
+}
+
+func oauthErrorParams(reason, description, state string) url.Values {
+	params := url.Values{"error": {reason}, "error_description": {description}}
+	if state != "" {
+		params.Set("state", state)
+	}
+	return params
+}
+
+// withQuery добавляет параметры к адресу, сохраняя его собственные
+func withQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func oauthClientToProto(client *models.OAuthClient) *proto.OAuthClient {
+	return &proto.OAuthClient{
+		ClientId:     client.ClientID,
+		Name:         client.Name,
+		RedirectUris: strings.Fields(client.RedirectURIs),
+		Confidential: client.SecretHash != "",
+		CreatedAt:    timestamppb.New(client.CreatedAt),
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/position"
+	"server/internal/proto"
+	"server/internal/repository"
+)
+
+// ReorderTodo ставит задачу между соседями after_id и before_id в ее списке.
+// Достаточно одного соседа: второй определяется по текущему порядку.
+func (s *TodoServiceServer) ReorderTodo(ctx context.Context, req *proto.ReorderTodoRequest) (*proto.TodoItem, error) {
+	todoID, err := strconv.ParseUint(req.Id, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid todo ID format")
+	}
+
+	todo, err := s.todoRepo.GetTodoByID(ctx, uint(todoID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "todo not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get todo: %v", err)
+	}
+
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	if todo.UserID != uint(userID) {
+		return nil, status.Errorf(codes.PermissionDenied, "you don't have permission to reorder this todo")
+	}
+	if req.ExpectedVersion != 0 && todo.Version != req.ExpectedVersion {
+		return nil, status.Errorf(codes.Aborted, "todo version mismatch: expected %d, current %d", req.ExpectedVersion, todo.Version)
+	}
+	if req.AfterId == "" && req.BeforeId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "after_id or before_id is required")
+	}
+
+	// Если соседи делят один ключ (или у старых задач ключей нет),
+	// места между ними не найти: список перебалансируется и ключи
+	// соседей читаются заново
+	var key string
+	for attempt := 0; ; attempt++ {
+		var ok bool
+		key, ok, err = s.positionBetween(ctx, todo, req.AfterId, req.BeforeId)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if attempt > 0 {
+			return nil, status.Errorf(codes.Internal, "failed to find a position between neighbors")
+		}
+		if err := s.todoRepo.RebalancePositions(ctx, todo.UserID, todo.ListID); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to rebalance positions: %v", err)
+		}
+	}
+
+	todo.Position = key
+	if err := s.todoRepo.UpdateTodo(ctx, repository.TodoUpdate{Todo: todo}); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, status.Errorf(codes.Aborted, "todo was modified concurrently, reload and retry")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reorder todo: %v", err)
+	}
+
+	return todoToProto(todo), nil
+}
+
+// positionBetween вычисляет ключ между соседями. ok == false означает,
+// что ключи соседей совпадают и список нужно перебалансировать.
+func (s *TodoServiceServer) positionBetween(ctx context.Context, todo *models.Todo, afterID, beforeID string) (string, bool, error) {
+	exclude := []uint{todo.ID}
+	var after, before *models.Todo
+	var err error
+	if afterID != "" {
+		if after, err = s.getNeighbor(ctx, todo, afterID); err != nil {
+			return "", false, err
+		}
+		exclude = append(exclude, after.ID)
+	}
+	if beforeID != "" {
+		if before, err = s.getNeighbor(ctx, todo, beforeID); err != nil {
+			return "", false, err
+		}
+		exclude = append(exclude, before.ID)
+	}
+
+	var lower, upper string
+	switch {
+	case after != nil && before != nil:
+		lower, upper = after.Position, before.Position
+		if lower > upper {
+			return "", false, status.Errorf(codes.InvalidArgument, "after_id must precede before_id")
+		}
+	case after != nil:
+		lower = after.Position
+		next, found, err := s.todoRepo.GetNeighborPosition(ctx, todo.UserID, todo.ListID, lower, true, exclude)
+		if err != nil {
+			return "", false, status.Errorf(codes.Internal, "failed to get neighbor position: %v", err)
+		}
+		if found {
+			upper = next
+		}
+	default:
+		upper = before.Position
+		prev, found, err := s.todoRepo.GetNeighborPosition(ctx, todo.UserID, todo.ListID, upper, false, exclude)
+		if err != nil {
+			return "", false, status.Errorf(codes.Internal, "failed to get neighbor position: %v", err)
+		}
+		if found {
+			lower = prev
+		}
+		if upper == "" {
+			// Перед задачей без ключа места нет
+			return "", false, nil
+		}
+	}
+	if upper != "" && lower >= upper {
+		return "", false, nil
+	}
+
+	key, err := position.Between(lower, upper)
+	if err != nil {
+		return "", false, status.Errorf(codes.Internal, "failed to compute position: %v", err)
+	}
+	return key, true, nil
+}
+
+// getNeighbor загружает соседа для ReorderTodo: свою задачу из того же списка
+func (s *TodoServiceServer) getNeighbor(ctx context.Context, todo *models.Todo, id string) (*models.Todo, error) {
+	neighborID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid neighbor ID format")
+	}
+	if uint(neighborID) == todo.ID {
+		return nil, status.Errorf(codes.InvalidArgument, "todo cannot be its own neighbor")
+	}
+
+	neighbor, err := s.todoRepo.GetTodoByID(ctx, uint(neighborID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "neighbor todo not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get neighbor todo: %v", err)
+	}
+	if neighbor.UserID != todo.UserID {
+		return nil, status.Errorf(codes.NotFound, "neighbor todo not found")
+	}
+	if neighbor.ListID != todo.ListID {
+		return nil, status.Errorf(codes.FailedPrecondition, "neighbor todo is in another list")
+	}
+	return neighbor, nil
+}
+
+// appendPosition возвращает ключ, ставящий задачу в конец списка
+func (s *TodoServiceServer) appendPosition(ctx context.Context, userID, listID uint) (string, error) {
+	last, err := s.todoRepo.GetLastPosition(ctx, userID, listID)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to get list positions: %v", err)
+	}
+	key, err := position.Between(last, "")
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to compute position: %v", err)
+	}
+	return key, nil
+}
+
+func validatePriority(p proto.Priority) error {
+	if _, ok := proto.Priority_name[int32(p)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown priority %d", p)
+	}
+	return nil
+}
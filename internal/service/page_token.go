@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"server/internal/models"
+	"server/internal/repository"
+)
+
+// pageToken — содержимое непрозрачного курсора GetTodos, ListTrash и ListUsers. Вместе с позицией
+// в нем хранится отпечаток запроса, чтобы курсор нельзя было применить
+// к выборке с другими фильтрами или сортировкой.
+type pageToken struct {
+	Query string    `json:"f"`
+	Time  time.Time `json:"t,omitempty"`
+	Text  string    `json:"v,omitempty"`
+	Int   int       `json:"n,omitempty"`
+	ID    uint      `json:"i"`
+}
+
+// queryFingerprint хэширует все параметры запроса страницы, кроме
+// размера страницы и самого курсора
+func queryFingerprint(req protobuf.Message) string {
+	q := protobuf.Clone(req).ProtoReflect()
+	for _, name := range []protoreflect.Name{"page_size", "page_token"} {
+		if field := q.Descriptor().Fields().ByName(name); field != nil {
+			q.Clear(field)
+		}
+	}
+	data, _ := protobuf.MarshalOptions{Deterministic: true}.Marshal(q.Interface())
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodePageToken(last *models.Todo, sortBy repository.TodoSortField, fingerprint string) string {
+	t := pageToken{Query: fingerprint, ID: last.ID}
+	switch sortBy {
+	case repository.TodoSortByUpdated:
+		t.Time = last.UpdatedAt
+	case repository.TodoSortByTitle:
+		t.Text = last.Title
+	case repository.TodoSortByPosition:
+		t.Text = last.Position
+	case repository.TodoSortByPriority:
+		t.Int = last.Priority
+	case repository.TodoSortByDeleted:
+		t.Time = last.DeletedAt.Time
+	default:
+		t.Time = last.CreatedAt
+	}
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token, fingerprint string) (*repository.TodoCursor, error) {
+	t, err := parsePageToken(token, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return &repository.TodoCursor{Time: t.Time, Text: t.Text, Int: t.Int, ID: t.ID}, nil
+}
+
+// encodeIDPageToken — курсор для выборок, упорядоченных только по id
+func encodeIDPageToken(lastID uint, fingerprint string) string {
+	data, _ := json.Marshal(pageToken{Query: fingerprint, ID: lastID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeIDPageToken(token, fingerprint string) (uint, error) {
+	t, err := parsePageToken(token, fingerprint)
+	if err != nil {
+		return 0, err
+	}
+	return t.ID, nil
+}
+
+func parsePageToken(token, fingerprint string) (*pageToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var t pageToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	if t.Query != fingerprint {
+		return nil, errors.New("page token does not match the query")
+	}
+	return &t, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/mailer"
+	"server/internal/models"
+	"server/internal/proto"
+)
+
+const minPasswordLength = 8
+
+// bcrypt учитывает только первые 72 байта пароля
+const maxPasswordLength = 72
+
+const passwordResetMessage = "If an account with this email exists, a password reset link has been sent"
+
+// ChangePassword меняет пароль после проверки текущего. Все токены
+// пользователя отзываются, вызывающий получает новую пару.
+func (s *UserServiceServer) ChangePassword(ctx context.Context, req *proto.ChangePasswordRequest) (*proto.LoginResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	if err := validatePassword(req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, uint(userID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "current password is incorrect")
+	}
+
+	if err := s.setPassword(ctx, user.ID, req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	// Новые токены выдаются позже отзыва и под него не попадают
+	return s.startSession(ctx, user)
+}
+
+// RequestPasswordReset отправляет одноразовую ссылку для сброса пароля.
+// Чтобы по ответу нельзя было узнать, зарегистрирован ли адрес, ответ
+// всегда одинаковый, а ошибки доставки только пишутся в лог.
+func (s *UserServiceServer) RequestPasswordReset(ctx context.Context, req *proto.RequestPasswordResetRequest) (*proto.PasswordResetResponse, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &proto.PasswordResetResponse{Message: passwordResetMessage}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if user.DisabledAt != nil {
+		return &proto.PasswordResetResponse{Message: passwordResetMessage}, nil
+	}
+
+	token, expiresAt, err := s.issueUserToken(ctx, user.ID, models.UserTokenPasswordReset, s.cfg.PasswordResetTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mailer.Send(ctx, passwordResetMail(user.Email, s.cfg.PasswordResetURL, token, expiresAt)); err != nil {
+		log.Printf("password reset: failed to send mail to user %d: %v", user.ID, err)
+	}
+	return &proto.PasswordResetResponse{Message: passwordResetMessage}, nil
+}
+
+// ConfirmPasswordReset задает новый пароль по токену из письма.
+// Токен одноразовый; все сессии пользователя завершаются.
+func (s *UserServiceServer) ConfirmPasswordReset(ctx context.Context, req *proto.ConfirmPasswordResetRequest) (*proto.PasswordResetResponse, error) {
+	if req.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "reset token is required")
+	}
+	if err := validatePassword(req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	token, err := s.userTokenRepo.ConsumeUserToken(ctx, models.UserTokenPasswordReset, hashToken(req.Token), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired reset token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to use reset token: %v", err)
+	}
+
+	if err := s.setPassword(ctx, token.UserID, req.NewPassword); err != nil {
+		return nil, err
+	}
+	return &proto.PasswordResetResponse{Message: "Password has been reset"}, nil
+}
+
+// setPassword сохраняет новый пароль и отзывает все токены пользователя
+func (s *UserServiceServer) setPassword(ctx context.Context, userID uint, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to hash password: %v", err)
+	}
+	if err := s.userRepo.UpdateUserPassword(ctx, userID, string(hashedPassword)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Errorf(codes.NotFound, "user not found")
+		}
+		return status.Errorf(codes.Internal, "failed to update password: %v", err)
+	}
+	if err := s.revokeAllTokens(ctx, userID); err != nil {
+		return err
+	}
+	// Неиспользованные ссылки для сброса больше не нужны
+	if err := s.userTokenRepo.InvalidateUserTokens(ctx, userID, models.UserTokenPasswordReset, time.Now()); err != nil {
+		return status.Errorf(codes.Internal, "failed to invalidate reset tokens: %v", err)
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return status.Errorf(codes.InvalidArgument, "password must be at least %d characters", minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return status.Errorf(codes.InvalidArgument, "password must be at most %d bytes", maxPasswordLength)
+	}
+	return nil
+}
+
+func passwordResetMail(to, resetURL, token string, expiresAt time.Time) mailer.Message {
+	link := tokenLink(resetURL, token)
+	return mailer.Message{
+		To:      to,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Someone requested a password reset for your account.\n\n"+
+			"To choose a new password, open this link before %s:\n%s\n\n"+
+			"If you did not request a reset, ignore this email.\n", expiresAt.UTC().Format(time.RFC1123), link),
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/models"
+	"server/internal/rrule"
+)
+
+// normalizeRecurrence проверяет правило повторения и часовой пояс задачи
+// и приводит RRULE к каноническому виду. stored — задача до изменения,
+// nil для новой. Серия начинается заново с текущего due_at, если задача
+// новая или у нее изменились правило, часовой пояс или due_at.
+func normalizeRecurrence(todo, stored *models.Todo) error {
+	if _, err := loadTimeZone(todo.TimeZone); err != nil {
+		return err
+	}
+	if todo.Recurrence == "" {
+		todo.SeriesStart = nil
+		return nil
+	}
+
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid recurrence: %v", err)
+	}
+	if todo.DueAt == nil {
+		return status.Errorf(codes.InvalidArgument, "recurring todo requires due_at")
+	}
+	todo.Recurrence = rule.String()
+	restart := stored == nil || todo.Recurrence != stored.Recurrence ||
+		todo.TimeZone != stored.TimeZone || !sameTime(todo.DueAt, stored.DueAt)
+	if restart || todo.SeriesStart == nil {
+		start := *todo.DueAt
+		todo.SeriesStart = &start
+	}
+	return nil
+}
+
+// nextOccurrence строит следующее вхождение повторяющейся задачи после ее
+// текущего due_at. Возвращает nil, если серия закончилась. Ключ порядка
+// вхождению назначает вызывающий код.
+func nextOccurrence(todo *models.Todo) (*models.Todo, error) {
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "stored recurrence is invalid: %v", err)
+	}
+	loc, err := loadTimeZone(todo.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesStart := *todo.DueAt
+	if todo.SeriesStart != nil {
+		seriesStart = *todo.SeriesStart
+	}
+	due, ok := rule.Next(seriesStart.In(loc), todo.DueAt.In(loc))
+	if !ok {
+		return nil, nil
+	}
+	due = due.UTC()
+
+	next := &models.Todo{
+		UserID:      todo.UserID,
+		ListID:      todo.ListID,
+		ParentID:    todo.ParentID,
+		Title:       todo.Title,
+		Version:     1,
+		DueAt:       &due,
+		Tags:        todo.Tags,
+		Recurrence:  todo.Recurrence,
+		SeriesStart: todo.SeriesStart,
+		TimeZone:    todo.TimeZone,
+		Priority:    todo.Priority,
+	}
+	if todo.RemindAt != nil {
+		// Напоминание сдвигается вместе со сроком
+		remind := due.Add(todo.RemindAt.Sub(*todo.DueAt))
+		next.RemindAt = &remind
+	}
+	return next, nil
+}
+
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown time zone %q", name)
+	}
+	return loc, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"server/internal/models"
+	"server/internal/proto"
+)
+
+// addSeries создает ежедневную серию из трех вхождений, начатую за день
+// до due: текущее вхождение — второе
+func addSeries(env *todoTestEnv, due time.Time) *models.Todo {
+	start := due.AddDate(0, 0, -1)
+	return env.add(&models.Todo{
+		Title:       "standup",
+		DueAt:       &due,
+		Recurrence:  "FREQ=DAILY;COUNT=3",
+		SeriesStart: &start,
+		Position:    "U",
+	})
+}
+
+func TestReplaceKeepsSeriesStart(t *testing.T) {
+	env := newTodoTestEnv(t)
+	due := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	todo := addSeries(env, due)
+
+	// Полная замена с прежним правилом (в другой записи) и сроком
+	_, err := env.put(&proto.UpdateTodoRequest{Id: formatID(todo.ID), Title: "daily standup", DueAt: timestamppb.New(due), Recurrence: "freq=daily;count=3"})
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if stored := env.todos.Todo(todo.ID); !stored.SeriesStart.Equal(*todo.SeriesStart) {
+		t.Fatalf("series start = %v, want %v", stored.SeriesStart, todo.SeriesStart)
+	}
+
+	// Новый срок начинает серию заново
+	moved := due.Add(time.Hour)
+	_, err = env.put(&proto.UpdateTodoRequest{Id: formatID(todo.ID), Title: "daily standup", DueAt: timestamppb.New(moved), Recurrence: "FREQ=DAILY;COUNT=3"})
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if stored := env.todos.Todo(todo.ID); !stored.SeriesStart.Equal(moved) {
+		t.Fatalf("series start = %v after due_at changed, want %v", stored.SeriesStart, moved)
+	}
+}
+
+func TestCompletingSeriesCreatesNextOccurrence(t *testing.T) {
+	env := newTodoTestEnv(t)
+	due := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	todo := addSeries(env, due)
+
+	item, err := env.patch(&proto.UpdateTodoRequest{Id: formatID(todo.ID), Completed: true}, "completed")
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if item.NextOccurrence == nil {
+		t.Fatalf("no next occurrence")
+	}
+	if got, want := item.NextOccurrence.DueAt.AsTime(), due.AddDate(0, 0, 1); !got.Equal(want) {
+		t.Errorf("next due_at = %v, want %v", got, want)
+	}
+	if stored := env.todos.Todo(todo.ID); stored.Recurrence != "" || !stored.Completed {
+		t.Errorf("completed todo: recurrence %q, completed %v", stored.Recurrence, stored.Completed)
+	}
+
+	// Третье вхождение — последнее: после него серия заканчивается
+	todos := env.todos.UserTodos(testUserID)
+	if len(todos) != 2 {
+		t.Fatalf("todos = %d, want 2", len(todos))
+	}
+	next := todos[1]
+	if !next.SeriesStart.Equal(*todo.SeriesStart) {
+		t.Errorf("next series start = %v, want %v", next.SeriesStart, todo.SeriesStart)
+	}
+	if next.Position <= todo.Position {
+		t.Errorf("next position = %q, want after %q", next.Position, todo.Position)
+	}
+	item, err = env.patch(&proto.UpdateTodoRequest{Id: formatID(next.ID), Completed: true}, "completed")
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if item.NextOccurrence != nil {
+		t.Errorf("series with COUNT=3 produced a fourth occurrence")
+	}
+}
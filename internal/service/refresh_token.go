@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/repository"
+)
+
+// Refresh обменивает refresh-токен на новую пару. Повторное предъявление
+// уже обмененного токена означает, что он утек: отзывается все семейство,
+// и владельцу придется войти заново.
+func (s *UserServiceServer) Refresh(ctx context.Context, req *proto.RefreshRequest) (*proto.LoginResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "refresh token is required")
+	}
+
+	stored, err := s.refreshTokenRepo.GetRefreshTokenByHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get refresh token: %v", err)
+	}
+
+	now := time.Now()
+	if stored.RevokedAt != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "refresh token has been revoked")
+	}
+	if stored.UsedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, stored, now)
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, status.Errorf(codes.Unauthenticated, "refresh token has expired")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if user.DisabledAt != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user is disabled")
+	}
+	if !user.EmailVerified && s.cfg.RequireVerifiedEmail {
+		return nil, status.Errorf(codes.Unauthenticated, "email address is not verified")
+	}
+
+	if err := s.continueSession(ctx, stored); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user, stored.FamilyID, stored)
+}
+
+// issueTokens выдает access-токен и refresh-токен семейства familyID.
+// Семейство — это сессия, ее идентификатор попадает в access-токен.
+// Если передан previous, он обменивается на новый атомарно.
+func (s *UserServiceServer) issueTokens(ctx context.Context, user *models.User, familyID string, previous *models.RefreshToken) (*proto.LoginResponse, error) {
+	accessToken, err := s.generateToken(user.ID, s.tokenRole(user), familyID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
+	}
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
+	}
+
+	now := time.Now()
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.cfg.RefreshTokenTTL),
+		CreatedAt: now,
+	}
+	if previous == nil {
+		err = s.refreshTokenRepo.CreateRefreshToken(ctx, record)
+	} else {
+		err = s.refreshTokenRepo.RotateRefreshToken(ctx, previous, record)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenUsed) {
+			// Параллельный запрос успел обменять тот же токен
+			return nil, s.revokeReusedFamily(ctx, previous, now)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to save refresh token: %v", err)
+	}
+
+	return &proto.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.cfg.AccessTokenTTL / time.Second),
+	}, nil
+}
+
+// revokeReusedFamily завершает сессию утекшего токена: отзываются ее
+// refresh-токены, а уже выданные access-токены перестают проходить проверку
+func (s *UserServiceServer) revokeReusedFamily(ctx context.Context, token *models.RefreshToken, now time.Time) error {
+	log.Printf("refresh token reuse detected for user %d, revoking family %s", token.UserID, token.FamilyID)
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID, now); err != nil {
+		return status.Errorf(codes.Internal, "failed to revoke refresh tokens: %v", err)
+	}
+	// У семейств, начатых до появления сессий, сессии может не быть, а
+	// сессию могли уже завершить
+	if err := s.revokeSession(ctx, token.UserID, token.FamilyID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+	return status.Errorf(codes.Unauthenticated, "refresh token reuse detected, please log in again")
+}
+
+// randomToken — 256 случайных бит в base64url
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken — ключ для поиска токена в базе. У токена 256 бит энтропии,
+// поэтому соль и медленный хэш не нужны.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/models"
+	"server/internal/proto"
+)
+
+func TestRefreshReuseEndsSession(t *testing.T) {
+	env := newUserTestEnv(t)
+	user := env.addUser("alice@example.com")
+	first := env.login("alice@example.com")
+	other := env.login("alice@example.com")
+
+	rotated, err := env.refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if err := env.validate(rotated.Token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	// Старый токен предъявлен повторно — он утек
+	if _, err := env.refresh(first.RefreshToken); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("reused refresh token: err = %v, want Unauthenticated", err)
+	}
+	// Access-токены этой сессии, выданные до обнаружения, больше не действуют
+	for _, token := range []string{first.Token, rotated.Token} {
+		if err := env.validate(token); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("access token of the stolen session: err = %v, want Unauthenticated", err)
+		}
+	}
+	sessions, err := env.service.ListSessions(context.Background(), &proto.ListSessionsRequest{UserId: strconv.Itoa(int(user.ID))})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions.Sessions) != 1 {
+		t.Fatalf("sessions after reuse = %d, want only the other one", len(sessions.Sessions))
+	}
+
+	// Остальные сессии пользователя не затронуты
+	if err := env.validate(other.Token); err != nil {
+		t.Fatalf("other session: %v", err)
+	}
+	if _, err := env.refresh(other.RefreshToken); err != nil {
+		t.Fatalf("other session refresh: %v", err)
+	}
+}
+
+func TestRefreshRotation(t *testing.T) {
+	env := newUserTestEnv(t)
+	env.addUser("alice@example.com")
+	tokens := env.login("alice@example.com")
+
+	// Каждый токен обменивается ровно один раз
+	chain := []string{tokens.RefreshToken}
+	for i := 0; i < 2; i++ {
+		next, err := env.refresh(chain[len(chain)-1])
+		if err != nil {
+			t.Fatalf("Refresh #%d: %v", i+1, err)
+		}
+		if next.RefreshToken == chain[len(chain)-1] {
+			t.Fatalf("Refresh #%d returned the same refresh token", i+1)
+		}
+		chain = append(chain, next.RefreshToken)
+	}
+
+	// Повтор обмененного токена отзывает все семейство, включая новейший токен
+	if _, err := env.refresh(chain[1]); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("replayed refresh token: err = %v, want Unauthenticated", err)
+	}
+	for i, token := range chain {
+		if _, err := env.refresh(token); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("token #%d after replay: err = %v, want Unauthenticated", i, err)
+		}
+	}
+
+	// Новый вход начинает новое семейство
+	if _, err := env.refresh(env.login("alice@example.com").RefreshToken); err != nil {
+		t.Fatalf("refresh after new login: %v", err)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	env := newUserTestEnv(t)
+	user := env.addUser("alice@example.com")
+
+	expired := "expired-refresh-token"
+	err := env.refreshTokens.CreateRefreshToken(context.Background(), &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  "family",
+		TokenHash: hashToken(expired),
+		ExpiresAt: time.Now().Add(-time.Second),
+		CreatedAt: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateRefreshToken: %v", err)
+	}
+	if _, err := env.refresh(expired); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expired refresh token: err = %v, want Unauthenticated", err)
+	}
+	if _, err := env.refresh("unknown"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unknown refresh token: err = %v, want Unauthenticated", err)
+	}
+	if _, err := env.refresh(""); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("empty refresh token: err = %v, want InvalidArgument", err)
+	}
+}
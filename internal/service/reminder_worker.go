@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"server/internal/notifier"
+	"server/internal/repository"
+)
+
+// reminderBatchSize — сколько напоминаний обрабатывается за один проход
+const reminderBatchSize = 100
+
+// ReminderWorker периодически ищет задачи с наступившим remind_at
+// и отправляет по ним напоминания через Notifier
+type ReminderWorker struct {
+	todoRepo repository.TodoRepository
+	notifier notifier.Notifier
+	interval time.Duration
+}
+
+func NewReminderWorker(todoRepo repository.TodoRepository, n notifier.Notifier, interval time.Duration) *ReminderWorker {
+	return &ReminderWorker{todoRepo: todoRepo, notifier: n, interval: interval}
+}
+
+// Run блокируется до отмены ctx
+func (w *ReminderWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.tick(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *ReminderWorker) tick(ctx context.Context, now time.Time) {
+	todos, err := w.todoRepo.GetDueReminders(ctx, now, reminderBatchSize)
+	if err != nil {
+		log.Printf("reminder worker: failed to load due reminders: %v", err)
+		return
+	}
+
+	for _, todo := range todos {
+		event := notifier.ReminderEvent{
+			TodoID:   todo.ID,
+			UserID:   todo.UserID,
+			Title:    todo.Title,
+			DueAt:    todo.DueAt,
+			RemindAt: *todo.RemindAt,
+		}
+		if err := w.notifier.NotifyReminder(ctx, event); err != nil {
+			// Не отмечаем — попробуем снова на следующем проходе
+			log.Printf("reminder worker: failed to notify about todo %d: %v", todo.ID, err)
+			continue
+		}
+		if err := w.todoRepo.MarkReminded(ctx, todo.ID, now); err != nil {
+			log.Printf("reminder worker: failed to mark todo %d as reminded: %v", todo.ID, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"server/internal/models"
+	"server/internal/notifier"
+	"server/internal/repository/repotest"
+)
+
+// recordingNotifier запоминает отправленные напоминания; пока fail
+// не пуст, отказывает для перечисленных задач
+type recordingNotifier struct {
+	sent []uint
+	fail map[uint]bool
+}
+
+func (n *recordingNotifier) NotifyReminder(ctx context.Context, event notifier.ReminderEvent) error {
+	if n.fail[event.TodoID] {
+		return errors.New("delivery failed")
+	}
+	n.sent = append(n.sent, event.TodoID)
+	return nil
+}
+
+func TestReminderWorkerSendsDueRemindersOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+	todos := repotest.NewTodos(repotest.NewTags())
+	add := func(todo *models.Todo) uint {
+		todo.UserID = 1
+		if err := todos.CreateTodo(ctx, todo); err != nil {
+			t.Fatalf("CreateTodo: %v", err)
+		}
+		return todo.ID
+	}
+	due := add(&models.Todo{Title: "due", RemindAt: at(-time.Minute)})
+	exact := add(&models.Todo{Title: "due right now", RemindAt: at(0)})
+	add(&models.Todo{Title: "later", RemindAt: at(time.Hour)})
+	add(&models.Todo{Title: "no reminder"})
+	add(&models.Todo{Title: "completed", RemindAt: at(-time.Minute), Completed: true})
+	add(&models.Todo{Title: "already sent", RemindAt: at(-time.Hour), RemindedAt: at(-time.Hour)})
+	flaky := add(&models.Todo{Title: "delivery fails", RemindAt: at(-2 * time.Minute)})
+
+	n := &recordingNotifier{fail: map[uint]bool{flaky: true}}
+	worker := NewReminderWorker(todos, n, time.Minute)
+
+	worker.tick(ctx, now)
+	if want := []uint{due, exact}; !equalIDs(n.sent, want) {
+		t.Fatalf("first tick sent %v, want %v", n.sent, want)
+	}
+	if todo := todos.Todo(due); todo.RemindedAt == nil || !todo.RemindedAt.Equal(now) {
+		t.Errorf("reminded_at = %v, want %v", todo.RemindedAt, now)
+	}
+	if todo := todos.Todo(flaky); todo.RemindedAt != nil {
+		t.Errorf("failed delivery was marked as sent")
+	}
+
+	// Отправленные не повторяются, неудачное уходит при следующем проходе
+	n.sent, n.fail = nil, nil
+	worker.tick(ctx, now.Add(time.Minute))
+	if want := []uint{flaky}; !equalIDs(n.sent, want) {
+		t.Fatalf("second tick sent %v, want %v", n.sent, want)
+	}
+}
+
+func equalIDs(got, want []uint) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
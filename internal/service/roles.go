@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/proto"
+	"server/internal/rbac"
+)
+
+// UserMethodPermissions — права, которых требуют методы UserService.
+// Проверяются перехватчиком rbac.UnaryServerInterceptor; метод, которого
+// здесь нет, недоступен.
+var UserMethodPermissions = map[string]rbac.Permission{
+	// Вход, токены и регистрация
+	proto.UserService_Register_FullMethodName:                rbac.NoPermission,
+	proto.UserService_Login_FullMethodName:                   rbac.NoPermission,
+	proto.UserService_LoginSecondFactor_FullMethodName:       rbac.NoPermission,
+	proto.UserService_ExternalLogin_FullMethodName:           rbac.NoPermission,
+	proto.UserService_ValidateToken_FullMethodName:           rbac.NoPermission,
+	proto.UserService_Refresh_FullMethodName:                 rbac.NoPermission,
+	proto.UserService_IsTokenRevoked_FullMethodName:          rbac.NoPermission,
+	proto.UserService_GetJWKS_FullMethodName:                 rbac.NoPermission,
+	proto.UserService_VerifyEmail_FullMethodName:             rbac.NoPermission,
+	proto.UserService_ResendVerificationEmail_FullMethodName: rbac.NoPermission,
+	proto.UserService_RequestPasswordReset_FullMethodName:    rbac.NoPermission,
+	proto.UserService_ConfirmPasswordReset_FullMethodName:    rbac.NoPermission,
+
+	// OAuth: клиент приложения и согласие пользователя
+	proto.UserService_ValidateAuthorizeRequest_FullMethodName: rbac.NoPermission,
+	proto.UserService_Authorize_FullMethodName:                rbac.NoPermission,
+	proto.UserService_OAuthToken_FullMethodName:               rbac.NoPermission,
+	proto.UserService_OAuthUserInfo_FullMethodName:            rbac.NoPermission,
+
+	// Своя учетная запись
+	proto.UserService_Logout_FullMethodName:                  rbac.NoPermission,
+	proto.UserService_LogoutAll_FullMethodName:               rbac.NoPermission,
+	proto.UserService_ChangePassword_FullMethodName:          rbac.NoPermission,
+	proto.UserService_EnrollTOTP_FullMethodName:              rbac.NoPermission,
+	proto.UserService_ConfirmTOTP_FullMethodName:             rbac.NoPermission,
+	proto.UserService_DisableTOTP_FullMethodName:             rbac.NoPermission,
+	proto.UserService_CreateAPIKey_FullMethodName:            rbac.NoPermission,
+	proto.UserService_ListAPIKeys_FullMethodName:             rbac.NoPermission,
+	proto.UserService_RevokeAPIKey_FullMethodName:            rbac.NoPermission,
+	proto.UserService_ListSessions_FullMethodName:            rbac.NoPermission,
+	proto.UserService_RevokeSession_FullMethodName:           rbac.NoPermission,
+	proto.UserService_CreateIdentityLinkToken_FullMethodName: rbac.NoPermission,
+	proto.UserService_LinkIdentity_FullMethodName:            rbac.NoPermission,
+	proto.UserService_ListIdentities_FullMethodName:          rbac.NoPermission,
+	proto.UserService_UnlinkIdentity_FullMethodName:          rbac.NoPermission,
+
+	// Администрирование
+	proto.UserService_RotateSigningKey_FullMethodName:  rbac.KeysRotate,
+	proto.UserService_ChangeUserRole_FullMethodName:    rbac.UsersManage,
+	proto.UserService_ListUsers_FullMethodName:         rbac.UsersManage,
+	proto.UserService_GetUser_FullMethodName:           rbac.UsersManage,
+	proto.UserService_DisableUser_FullMethodName:       rbac.UsersManage,
+	proto.UserService_EnableUser_FullMethodName:        rbac.UsersManage,
+	proto.UserService_DeleteUser_FullMethodName:        rbac.UsersManage,
+	proto.UserService_ResetUserPassword_FullMethodName: rbac.UsersManage,
+	proto.UserService_UnlockUser_FullMethodName:        rbac.UsersManage,
+	proto.UserService_CreateOAuthClient_FullMethodName: rbac.OAuthClientsManage,
+	proto.UserService_ListOAuthClients_FullMethodName:  rbac.OAuthClientsManage,
+	proto.UserService_DeleteOAuthClient_FullMethodName: rbac.OAuthClientsManage,
+}
+
+// TodoMethodPermissions — права, которых требуют методы TodoService. Роль
+// вызывающего передает шлюз (rbac.RoleFromIncomingContext).
+var TodoMethodPermissions = map[string]rbac.Permission{
+	proto.TodoService_GetTodos_FullMethodName:    rbac.TodosRead,
+	proto.TodoService_GetTodo_FullMethodName:     rbac.TodosRead,
+	proto.TodoService_SearchTodos_FullMethodName: rbac.TodosRead,
+	proto.TodoService_ListTrash_FullMethodName:   rbac.TodosRead,
+	proto.TodoService_GetLists_FullMethodName:    rbac.TodosRead,
+	proto.TodoService_GetTags_FullMethodName:     rbac.TodosRead,
+
+	proto.TodoService_CreateTodo_FullMethodName:  rbac.TodosWrite,
+	proto.TodoService_UpdateTodo_FullMethodName:  rbac.TodosWrite,
+	proto.TodoService_DeleteTodo_FullMethodName:  rbac.TodosWrite,
+	proto.TodoService_MoveTodo_FullMethodName:    rbac.TodosWrite,
+	proto.TodoService_ReorderTodo_FullMethodName: rbac.TodosWrite,
+	proto.TodoService_RestoreTodo_FullMethodName: rbac.TodosWrite,
+	proto.TodoService_PurgeTodo_FullMethodName:   rbac.TodosWrite,
+	proto.TodoService_CreateList_FullMethodName:  rbac.TodosWrite,
+	proto.TodoService_UpdateList_FullMethodName:  rbac.TodosWrite,
+	proto.TodoService_DeleteList_FullMethodName:  rbac.TodosWrite,
+	proto.TodoService_CreateTag_FullMethodName:   rbac.TodosWrite,
+	proto.TodoService_UpdateTag_FullMethodName:   rbac.TodosWrite,
+	proto.TodoService_DeleteTag_FullMethodName:   rbac.TodosWrite,
+}
+
+// UserRole возвращает действующую роль пользователя по данным из базы
+// (см. tokenRole); подходит как rbac.RoleLookup
+func (s *UserServiceServer) UserRole(ctx context.Context, userID uint) (string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		// Удаленный пользователь мог сохранить действующий токен
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", status.Errorf(codes.PermissionDenied, "user not found")
+		}
+		return "", err
+	}
+	return s.tokenRole(user), nil
+}
+
+// ChangeUserRole назначает пользователю новую роль. Его access-токены
+// отзываются, чтобы роль из старых токенов не действовала до их истечения;
+// refresh-токены остаются, и новая пара токенов выдается уже с новой ролью.
+func (s *UserServiceServer) ChangeUserRole(ctx context.Context, req *proto.ChangeUserRoleRequest) (*proto.ChangeUserRoleResponse, error) {
+	targetID, err := parseTargetUserID(req.TargetUserId)
+	if err != nil {
+		return nil, err
+	}
+	if !rbac.ValidRole(req.Role) {
+		return nil, status.Errorf(codes.InvalidArgument, "role must be one of: %s", strings.Join(rbac.Roles(), ", "))
+	}
+	// Иначе последний администратор может случайно лишить себя прав
+	if req.TargetUserId == req.UserId {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot change your own role")
+	}
+
+	if err := s.userRepo.UpdateUserRole(ctx, targetID, req.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update user role: %v", err)
+	}
+
+	now := time.Now()
+	if err := s.revocations.RevokeUserTokens(ctx, targetID, now, now.Add(s.cfg.AccessTokenTTL)); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke tokens: %v", err)
+	}
+
+	return &proto.ChangeUserRoleResponse{UserId: fmt.Sprintf("%d", targetID), Role: req.Role}, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"server/internal/proto"
+	"server/internal/rbac"
+)
+
+// Перехватчик отклоняет методы, которых нет в карте, так что каждый метод
+// сервиса должен быть в ней упомянут
+func TestMethodPermissionsCoverServices(t *testing.T) {
+	for _, tc := range []struct {
+		desc        grpc.ServiceDesc
+		permissions map[string]rbac.Permission
+	}{
+		{proto.UserService_ServiceDesc, UserMethodPermissions},
+		{proto.TodoService_ServiceDesc, TodoMethodPermissions},
+	} {
+		for _, method := range tc.desc.Methods {
+			name := "/" + tc.desc.ServiceName + "/" + method.MethodName
+			if _, ok := tc.permissions[name]; !ok {
+				t.Errorf("%s has no permission rule", name)
+			}
+		}
+		if len(tc.permissions) != len(tc.desc.Methods) {
+			t.Errorf("%s: %d rules for %d methods", tc.desc.ServiceName, len(tc.permissions), len(tc.desc.Methods))
+		}
+	}
+	// Методы TodoService работают с задачами и без права на них недоступны
+	for method, permission := range TodoMethodPermissions {
+		if permission != rbac.TodosRead && permission != rbac.TodosWrite {
+			t.Errorf("%s requires %q", method, permission)
+		}
+	}
+}
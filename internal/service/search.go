@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+	"server/internal/repository"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	maxSearchQueryLength  = 256
+)
+
+func (s *TodoServiceServer) SearchTodos(ctx context.Context, req *proto.SearchTodosRequest) (*proto.SearchTodosResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "search query is required")
+	}
+	if len(req.Query) > maxSearchQueryLength {
+		return nil, status.Errorf(codes.InvalidArgument, "search query must be at most %d bytes", maxSearchQueryLength)
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultSearchPageSize
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+
+	results, err := s.todoRepo.SearchTodos(ctx, repository.TodoSearchQuery{
+		UserID: uint(userID),
+		Terms:  repository.SearchTerms(req.Query),
+		Limit:  pageSize,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to search todos: %v", err)
+	}
+
+	resp := &proto.SearchTodosResponse{}
+	for _, result := range results {
+		resp.Hits = append(resp.Hits, &proto.SearchHit{
+			Todo:    todoToProto(result.Todo),
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+
+	"server/internal/clientip"
+	"server/internal/models"
+	"server/internal/proto"
+)
+
+const (
+	// sessionTouchInterval — как часто проверка токена обращается к базе:
+	// обновляет время последней активности сессии и узнает, не отозвана ли
+	// она. Между обращениями используется результат прошлой проверки.
+	sessionTouchInterval = time.Minute
+	// Столько сессий помнит кэш проверок
+	sessionActivityMaxEntries = 10000
+	// Длиннее User-Agent не сохраняется
+	maxUserAgentLength = 512
+)
+
+// sessionActivity — кэш проверок сессий. Отзыв через RevokeSession виден
+// этому экземпляру сервиса сразу, остальным — не позже sessionTouchInterval.
+type sessionActivity struct {
+	mu      sync.Mutex
+	entries map[string]sessionCheck
+}
+
+type sessionCheck struct {
+	checkedAt time.Time
+	active    bool
+}
+
+func newSessionActivity() *sessionActivity {
+	return &sessionActivity{entries: make(map[string]sessionCheck)}
+}
+
+// lookup возвращает результат проверки, если он еще не устарел
+func (a *sessionActivity) lookup(id string, now time.Time) (active, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	check, ok := a.entries[id]
+	if !ok || now.Sub(check.checkedAt) >= sessionTouchInterval {
+		return false, false
+	}
+	return check.active, true
+}
+
+func (a *sessionActivity) store(id string, active bool, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.entries[id]; !ok && len(a.entries) >= sessionActivityMaxEntries {
+		for key, check := range a.entries {
+			if now.Sub(check.checkedAt) >= sessionTouchInterval {
+				delete(a.entries, key)
+			}
+		}
+		if len(a.entries) >= sessionActivityMaxEntries {
+			a.entries = make(map[string]sessionCheck)
+		}
+	}
+	a.entries[id] = sessionCheck{checkedAt: now, active: active}
+}
+
+// startSession начинает сессию нового входа и выдает ее первую пару токенов.
+// Каждая сессия — отдельное семейство refresh-токенов.
+func (s *UserServiceServer) startSession(ctx context.Context, user *models.User) (*proto.LoginResponse, error) {
+	id, err := randomToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
+	}
+	if err := s.createSession(ctx, user.ID, id); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user, id, nil)
+}
+
+// createSession сохраняет сессию с IP и User-Agent клиента, переданными шлюзом
+func (s *UserServiceServer) createSession(ctx context.Context, userID uint, id string) error {
+	userAgent := clientip.UserAgentFromIncomingContext(ctx)
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	now := time.Now()
+	session := &models.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         clientip.FromIncomingContext(ctx),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.cfg.RefreshTokenTTL),
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return status.Errorf(codes.Internal, "failed to create session: %v", err)
+	}
+	return nil
+}
+
+// continueSession продлевает сессию при обмене refresh-токена. Семейства,
+// начатые до появления сессий, получают сессию при первом обмене.
+func (s *UserServiceServer) continueSession(ctx context.Context, token *models.RefreshToken) error {
+	session, err := s.sessionRepo.GetSession(ctx, token.FamilyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.createSession(ctx, token.UserID, token.FamilyID)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get session: %v", err)
+	}
+	if session.RevokedAt != nil {
+		return status.Errorf(codes.Unauthenticated, "session has been revoked")
+	}
+	now := time.Now()
+	if err := s.sessionRepo.ExtendSession(ctx, session.ID, now, now.Add(s.cfg.RefreshTokenTTL)); err != nil {
+		return status.Errorf(codes.Internal, "failed to update session: %v", err)
+	}
+	return nil
+}
+
+// sessionActive проверяет, не отозвана ли сессия токена, и отмечает ее
+// активность. В базу пишется не чаще раза в sessionTouchInterval.
+func (s *UserServiceServer) sessionActive(ctx context.Context, id string) (bool, error) {
+	// У токенов, выданных до появления сессий, sid нет
+	if id == "" {
+		return true, nil
+	}
+	now := time.Now()
+	if active, ok := s.sessions.lookup(id, now); ok {
+		return active, nil
+	}
+	active, err := s.sessionRepo.TouchSession(ctx, id, now)
+	if err != nil {
+		return false, status.Errorf(codes.Internal, "failed to check session: %v", err)
+	}
+	s.sessions.store(id, active, now)
+	return active, nil
+}
+
+// ListSessions возвращает действующие сессии пользователя
+func (s *UserServiceServer) ListSessions(ctx context.Context, req *proto.ListSessionsRequest) (*proto.ListSessionsResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	sessions, err := s.sessionRepo.ListSessions(ctx, uint(userID), time.Now())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+	resp := &proto.ListSessionsResponse{}
+	for i := range sessions {
+		resp.Sessions = append(resp.Sessions, sessionToProto(&sessions[i]))
+	}
+	return resp, nil
+}
+
+// RevokeSession завершает сессию: ее refresh-токены отзываются, а
+// access-токены перестают проходить проверку
+func (s *UserServiceServer) RevokeSession(ctx context.Context, req *proto.RevokeSessionRequest) (*proto.RevokeSessionResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	if req.SessionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "session ID is required")
+	}
+	if err := s.revokeSession(ctx, uint(userID), req.SessionId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+	return &proto.RevokeSessionResponse{Message: "Session revoked"}, nil
+}
+
+func (s *UserServiceServer) revokeSession(ctx context.Context, userID uint, id string) error {
+	now := time.Now()
+	if err := s.sessionRepo.RevokeSession(ctx, userID, id, now); err != nil {
+		return err
+	}
+	s.sessions.store(id, false, now)
+	return nil
+}
+
+// RunSessionCleanup периодически удаляет завершенные сессии.
+// Блокируется до отмены ctx.
+func (s *UserServiceServer) RunSessionCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// Отозванные сессии хранятся, пока могут действовать их access-токены
+			if err := s.sessionRepo.DeleteEndedSessions(ctx, now.Add(-s.cfg.AccessTokenTTL)); err != nil {
+				log.Printf("sessions: failed to delete ended sessions: %v", err)
+			}
+		}
+	}
+}
+
+func sessionToProto(session *models.Session) *proto.Session {
+	return &proto.Session{
+		Id:         session.ID,
+		UserAgent:  session.UserAgent,
+		Ip:         session.IP,
+		CreatedAt:  timestamppb.New(session.CreatedAt),
+		LastSeenAt: timestamppb.New(session.LastSeenAt),
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/proto"
+)
+
+// GetJWKS публикует открытые ключи, которыми могут быть подписаны
+// действующие токены, включая выведенные из оборота
+func (s *UserServiceServer) GetJWKS(ctx context.Context, req *proto.GetJWKSRequest) (*proto.GetJWKSResponse, error) {
+	resp := &proto.GetJWKSResponse{}
+	for _, key := range s.keys.Keys() {
+		jwk := key.JWK()
+		resp.Keys = append(resp.Keys, &proto.JWK{
+			Kty: jwk.Kty,
+			Kid: jwk.Kid,
+			Alg: jwk.Alg,
+			Use: jwk.Use,
+			N:   jwk.N,
+			E:   jwk.E,
+			Crv: jwk.Crv,
+			X:   jwk.X,
+		})
+	}
+	return resp, nil
+}
+
+// RotateSigningKey выпускает новый ключ подписи. Право rbac.KeysRotate
+// проверяет перехватчик (см. UserMethodPermissions).
+func (s *UserServiceServer) RotateSigningKey(ctx context.Context, req *proto.RotateSigningKeyRequest) (*proto.RotateSigningKeyResponse, error) {
+	key, err := s.keys.Rotate(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to rotate signing key: %v", err)
+	}
+	return &proto.RotateSigningKeyResponse{Kid: key.ID}, nil
+}
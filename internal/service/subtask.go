@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+)
+
+// loadSubtree возвращает все подзадачи todo и проверяет, что каждая из них
+// принадлежит userID. Чужая подзадача в дереве — отказ для всей операции.
+func (s *TodoServiceServer) loadSubtree(ctx context.Context, todo *models.Todo, userID uint) ([]*models.Todo, error) {
+	descendants, err := s.todoRepo.GetDescendants(ctx, []uint{todo.ID})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get subtasks: %v", err)
+	}
+	for _, d := range descendants {
+		if d.UserID != userID {
+			return nil, status.Errorf(codes.PermissionDenied, "you don't have permission to modify subtask %d", d.ID)
+		}
+	}
+	return descendants, nil
+}
+
+// resolveParent загружает будущего родителя подзадачи и проверяет владельца
+func (s *TodoServiceServer) resolveParent(ctx context.Context, parentID string, userID uint) (*models.Todo, error) {
+	id, err := strconv.ParseUint(parentID, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid parent ID format")
+	}
+	parent, err := s.todoRepo.GetTodoByID(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "parent todo not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get parent todo: %v", err)
+	}
+	if parent.UserID != userID {
+		return nil, status.Errorf(codes.NotFound, "parent todo not found")
+	}
+	return parent, nil
+}
+
+// todoDepth — уровень задачи в дереве, у задачи верхнего уровня он равен 1.
+// Подъем по родителям ограничен maxDepth, поэтому цикл в данных не зациклит запрос.
+func (s *TodoServiceServer) todoDepth(ctx context.Context, todo *models.Todo) (int, error) {
+	depth := 1
+	for current := todo; current.ParentID != nil; depth++ {
+		if depth > s.maxDepth {
+			return depth, nil
+		}
+		parent, err := s.todoRepo.GetTodoByID(ctx, *current.ParentID)
+		if err != nil {
+			return 0, status.Errorf(codes.Internal, "failed to get parent todo: %v", err)
+		}
+		current = parent
+	}
+	return depth, nil
+}
+
+// checkNesting проверяет, что поддерево высотой height можно подвесить
+// под parent, не превысив максимальную глубину
+func (s *TodoServiceServer) checkNesting(ctx context.Context, parent *models.Todo, height int) error {
+	depth, err := s.todoDepth(ctx, parent)
+	if err != nil {
+		return err
+	}
+	if depth+height > s.maxDepth {
+		return status.Errorf(codes.FailedPrecondition, "subtasks can be nested at most %d levels deep", s.maxDepth)
+	}
+	return nil
+}
+
+// reparentTodo переносит todo под задачу parentID (пустой — на верхний
+// уровень). Подзадача всегда живет в списке родителя.
+func (s *TodoServiceServer) reparentTodo(ctx context.Context, todo *models.Todo, parentID string, descendants []*models.Todo) error {
+	if parentID == "" {
+		todo.ParentID = nil
+		return nil
+	}
+
+	parent, err := s.resolveParent(ctx, parentID, todo.UserID)
+	if err != nil {
+		return err
+	}
+	if parent.ID == todo.ID {
+		return status.Errorf(codes.FailedPrecondition, "todo cannot be its own parent")
+	}
+	for _, d := range descendants {
+		if d.ID == parent.ID {
+			return status.Errorf(codes.FailedPrecondition, "todo cannot be moved under its own subtask")
+		}
+	}
+	if err := s.checkNesting(ctx, parent, subtreeHeight(todo, descendants)); err != nil {
+		return err
+	}
+
+	todo.ParentID = &parent.ID
+	if parent.ListID != 0 {
+		todo.ListID = parent.ListID
+	}
+	return nil
+}
+
+// subtreeHeight — число уровней в поддереве root, включая сам root
+func subtreeHeight(root *models.Todo, descendants []*models.Todo) int {
+	children := make(map[uint][]uint)
+	for _, d := range descendants {
+		children[*d.ParentID] = append(children[*d.ParentID], d.ID)
+	}
+	var height func(id uint) int
+	height = func(id uint) int {
+		h := 0
+		for _, child := range children[id] {
+			if ch := height(child); ch > h {
+				h = ch
+			}
+		}
+		return h + 1
+	}
+	return height(root.ID)
+}
+
+func todoIDs(todos []*models.Todo) []uint {
+	ids := make([]uint, 0, len(todos))
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+// buildTodoTree раскладывает подзадачи по children соответствующих родителей
+func buildTodoTree(roots, descendants []*models.Todo) []*proto.TodoItem {
+	items := make(map[uint]*proto.TodoItem, len(roots)+len(descendants))
+	var result []*proto.TodoItem
+	for _, root := range roots {
+		item := todoToProto(root)
+		items[root.ID] = item
+		result = append(result, item)
+	}
+	for _, d := range descendants {
+		items[d.ID] = todoToProto(d)
+	}
+	for _, d := range descendants {
+		if parent, ok := items[*d.ParentID]; ok {
+			parent.Children = append(parent.Children, items[d.ID])
+		}
+	}
+	return result
+}
+
+func formatParentID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"server/internal/models"
+	"server/internal/proto"
+)
+
+// subtaskTree — задача root в списке from с подзадачей child и задача
+// parent в списке to
+type subtaskTree struct {
+	from, to            *models.List
+	root, child, parent *models.Todo
+}
+
+func newSubtaskTree(env *todoTestEnv) subtaskTree {
+	var tree subtaskTree
+	tree.from, tree.to = env.addList("from"), env.addList("to")
+	tree.root = env.add(&models.Todo{Title: "root", ListID: tree.from.ID, Position: "U"})
+	tree.child = env.add(&models.Todo{Title: "child", ListID: tree.from.ID, ParentID: &tree.root.ID, Position: "k"})
+	tree.parent = env.add(&models.Todo{Title: "parent", ListID: tree.to.ID, Position: "U"})
+	return tree
+}
+
+// checkMoved проверяет, что child переехал в список to и получил новую версию
+func (tree subtaskTree) checkMoved(t *testing.T, env *todoTestEnv) {
+	t.Helper()
+	child := env.todos.Todo(tree.child.ID)
+	if child.ListID != tree.to.ID {
+		t.Errorf("subtask list = %d, want %d", child.ListID, tree.to.ID)
+	}
+	if child.Version != tree.child.Version+1 {
+		t.Errorf("subtask version = %d, want %d", child.Version, tree.child.Version+1)
+	}
+}
+
+func TestReparentMovesSubtasks(t *testing.T) {
+	env := newTodoTestEnv(t)
+	tree := newSubtaskTree(env)
+
+	item, err := env.patch(&proto.UpdateTodoRequest{Id: formatID(tree.root.ID), ParentId: formatID(tree.parent.ID)}, "parent_id")
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if item.ListId != formatID(tree.to.ID) || item.ParentId != formatID(tree.parent.ID) {
+		t.Fatalf("moved todo: list %s, parent %s", item.ListId, item.ParentId)
+	}
+	// Задача встает в конец нового списка, а не сохраняет ключ из прежнего
+	if item.Position <= tree.parent.Position {
+		t.Errorf("moved todo position = %q, want after %q", item.Position, tree.parent.Position)
+	}
+	tree.checkMoved(t, env)
+}
+
+func TestMoveTodoMovesSubtasks(t *testing.T) {
+	env := newTodoTestEnv(t)
+	tree := newSubtaskTree(env)
+
+	_, err := env.service.MoveTodo(context.Background(), &proto.MoveTodoRequest{
+		Id:     formatID(tree.root.ID),
+		UserId: formatID(testUserID),
+		ListId: formatID(tree.to.ID),
+	})
+	if err != nil {
+		t.Fatalf("MoveTodo: %v", err)
+	}
+	tree.checkMoved(t, env)
+}
+
+func TestReplaceKeepsParent(t *testing.T) {
+	env := newTodoTestEnv(t)
+	tree := newSubtaskTree(env)
+
+	// Полная замена без parent_id не отрывает подзадачу от родителя
+	if _, err := env.put(&proto.UpdateTodoRequest{Id: formatID(tree.child.ID), Title: "renamed"}); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	child := env.todos.Todo(tree.child.ID)
+	if child.ParentID == nil || *child.ParentID != tree.root.ID || child.Title != "renamed" {
+		t.Fatalf("subtask after replace: parent %v, title %q", child.ParentID, child.Title)
+	}
+}
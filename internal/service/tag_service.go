@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+)
+
+const maxTagNameLength = 64
+
+func (s *TodoServiceServer) CreateTag(ctx context.Context, req *proto.CreateTagRequest) (*proto.Tag, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{UserID: uint(userID), Name: name}
+	if err := s.tagRepo.CreateTag(ctx, tag); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, status.Errorf(codes.AlreadyExists, "tag %q already exists", name)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create tag: %v", err)
+	}
+
+	return tagToProto(tag), nil
+}
+
+func (s *TodoServiceServer) GetTags(ctx context.Context, req *proto.GetTagsRequest) (*proto.GetTagsResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	tags, err := s.tagRepo.GetTagsByUserID(ctx, uint(userID))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get tags: %v", err)
+	}
+
+	var items []*proto.Tag
+	for _, tag := range tags {
+		items = append(items, tagToProto(tag))
+	}
+	return &proto.GetTagsResponse{Tags: items}, nil
+}
+
+func (s *TodoServiceServer) UpdateTag(ctx context.Context, req *proto.UpdateTagRequest) (*proto.Tag, error) {
+	tag, err := s.getOwnedTag(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag.Name = name
+	if err := s.tagRepo.UpdateTag(ctx, tag); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, status.Errorf(codes.AlreadyExists, "tag %q already exists", name)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update tag: %v", err)
+	}
+
+	return tagToProto(tag), nil
+}
+
+func (s *TodoServiceServer) DeleteTag(ctx context.Context, req *proto.DeleteTagRequest) (*proto.DeleteTagResponse, error) {
+	tag, err := s.getOwnedTag(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.DeleteTag(ctx, tag.ID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete tag: %v", err)
+	}
+
+	return &proto.DeleteTagResponse{Message: "Tag deleted successfully"}, nil
+}
+
+// upsertTags нормализует имена меток и создает недостающие метки пользователя
+func (s *TodoServiceServer) upsertTags(ctx context.Context, userID uint, names []string) ([]models.Tag, error) {
+	normalized, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.tagRepo.UpsertTags(ctx, userID, normalized)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save tags: %v", err)
+	}
+	return tags, nil
+}
+
+// getOwnedTag загружает метку и проверяет, что она принадлежит пользователю
+func (s *TodoServiceServer) getOwnedTag(ctx context.Context, id, userID string) (*models.Tag, error) {
+	tagID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tag ID format")
+	}
+	ownerID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	tag, err := s.tagRepo.GetTagByID(ctx, uint(tagID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "tag not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get tag: %v", err)
+	}
+	if tag.UserID != uint(ownerID) {
+		return nil, status.Errorf(codes.NotFound, "tag not found")
+	}
+	return tag, nil
+}
+
+// normalizeTagName приводит имя метки к нижнему регистру без крайних пробелов
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", status.Errorf(codes.InvalidArgument, "tag name is required")
+	}
+	if utf8.RuneCountInString(name) > maxTagNameLength {
+		return "", status.Errorf(codes.InvalidArgument, "tag name must be at most %d characters", maxTagNameLength)
+	}
+	return name, nil
+}
+
+// normalizeTagNames нормализует имена и убирает дубликаты
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, raw := range names {
+		name, err := normalizeTagName(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+func tagToProto(tag *models.Tag) *proto.Tag {
+	return &proto.Tag{
+		Id:     fmt.Sprintf("%d", tag.ID),
+		UserId: fmt.Sprintf("%d", tag.UserID),
+		Name:   tag.Name,
+	}
+}
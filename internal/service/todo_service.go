@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	
+	"strings"
+	"time"
+
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"gorm.io/gorm"
 
 	"server/internal/models"
@@ -15,16 +18,48 @@ import (
 	"server/internal/repository"
 )
 
+const (
+	defaultTodoPageSize = 50
+	maxTodoPageSize     = 500
+)
+
+// todoUpdatableFields — пути, допустимые в UpdateTodoRequest.update_mask
+var todoUpdatableFields = []string{
+	"title", "completed", "due_at", "remind_at", "tags", "parent_id", "recurrence", "time_zone", "priority",
+}
+
+// todoReplaceFields — пути полной замены (пустая маска). Место в дереве
+// меняется только явно: клиент, не знающий о подзадачах, не должен
+// отрывать подзадачу от родителя.
+var todoReplaceFields = []string{
+	"title", "completed", "due_at", "remind_at", "tags", "recurrence", "time_zone", "priority",
+}
+
+var todoSortFields = map[proto.TodoSortField]repository.TodoSortField{
+	proto.TodoSortField_TODO_SORT_FIELD_UNSPECIFIED: repository.TodoSortByCreated,
+	proto.TodoSortField_TODO_SORT_FIELD_CREATED:     repository.TodoSortByCreated,
+	proto.TodoSortField_TODO_SORT_FIELD_UPDATED:     repository.TodoSortByUpdated,
+	proto.TodoSortField_TODO_SORT_FIELD_TITLE:       repository.TodoSortByTitle,
+	proto.TodoSortField_TODO_SORT_FIELD_PRIORITY:    repository.TodoSortByPriority,
+	proto.TodoSortField_TODO_SORT_FIELD_POSITION:    repository.TodoSortByPosition,
+}
+
 type TodoServiceServer struct {
 	proto.UnimplementedTodoServiceServer
-	todoRepo repository.TodoRepository
+	todoRepo   repository.TodoRepository
+	listRepo   repository.ListRepository
+	tagRepo    repository.TagRepository
 	userClient proto.UserServiceClient // Клиент для gRPC-сервиса User
+	maxDepth   int                     // Максимальная глубина вложенности подзадач
 }
 
-func NewTodoServiceServer(todoRepo repository.TodoRepository, userClient proto.UserServiceClient) *TodoServiceServer {
+func NewTodoServiceServer(todoRepo repository.TodoRepository, listRepo repository.ListRepository, tagRepo repository.TagRepository, userClient proto.UserServiceClient, maxDepth int) *TodoServiceServer {
 	return &TodoServiceServer{
-		todoRepo: todoRepo,
+		todoRepo:   todoRepo,
+		listRepo:   listRepo,
+		tagRepo:    tagRepo,
 		userClient: userClient,
+		maxDepth:   maxDepth,
 	}
 }
 
@@ -37,20 +72,58 @@ func (s *TodoServiceServer) CreateTodo(ctx context.Context, req *proto.CreateTod
 	}
 
 	todo := &models.Todo{
-		UserID: uint(userID),
-		Title:  req.Title,
+		UserID:     uint(userID),
+		Title:      req.Title,
+		Version:    1,
+		DueAt:      timestampToTime(req.DueAt),
+		RemindAt:   timestampToTime(req.RemindAt),
+		Recurrence: req.Recurrence,
+		TimeZone:   req.TimeZone,
+		Priority:   int(req.Priority),
+	}
+	if err := validatePriority(req.Priority); err != nil {
+		return nil, err
+	}
+	if err := validateTodoSchedule(todo); err != nil {
+		return nil, err
+	}
+	if err := normalizeRecurrence(todo, nil); err != nil {
+		return nil, err
+	}
+	if req.ParentId != "" {
+		parent, err := s.resolveParent(ctx, req.ParentId, todo.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkNesting(ctx, parent, 1); err != nil {
+			return nil, err
+		}
+		if req.ListId != "" && req.ListId != formatListID(parent.ListID) {
+			return nil, status.Errorf(codes.InvalidArgument, "subtask must be in its parent's list")
+		}
+		todo.ParentID = &parent.ID
+		todo.ListID = parent.ListID
+	}
+	if todo.ListID == 0 {
+		list, err := s.resolveList(ctx, req.ListId, todo.UserID)
+		if err != nil {
+			return nil, err
+		}
+		todo.ListID = list.ID
+	}
+	// Новая задача встает в конец своего списка
+	if todo.Position, err = s.appendPosition(ctx, todo.UserID, todo.ListID); err != nil {
+		return nil, err
+	}
+	if todo.Tags, err = s.upsertTags(ctx, todo.UserID, req.Tags); err != nil {
+		return nil, err
 	}
 
 	if err := s.todoRepo.CreateTodo(ctx, todo); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create todo: %v", err)
 	}
 
-	return &proto.TodoItem{
-		Id:     fmt.Sprintf("%d", todo.ID),
-		UserId: fmt.Sprintf("%d", todo.UserID),
-		Title:  todo.Title,
-		Completed: todo.Completed,
-	}, nil
+	return todoToProto(todo), nil
 }
 
 func (s *TodoServiceServer) GetTodos(ctx context.Context, req *proto.GetTodosRequest) (*proto.GetTodosResponse, error) {
@@ -59,22 +132,110 @@ func (s *TodoServiceServer) GetTodos(ctx context.Context, req *proto.GetTodosReq
 		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
 	}
 
-	todos, err := s.todoRepo.GetTodosByUserID(ctx, uint(userID))
+	pageSize := int(req.PageSize)
+	if pageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultTodoPageSize
+	}
+	if pageSize > maxTodoPageSize {
+		pageSize = maxTodoPageSize
+	}
+
+	query := repository.TodoQuery{
+		UserID:        uint(userID),
+		RootsOnly:     req.AsTree,
+		Completed:     req.Completed,
+		TitleContains: req.TitleContains,
+		SortBy:        todoSortFields[req.SortBy],
+		Descending:    req.Descending,
+		// Берем на одну запись больше, чтобы понять, есть ли следующая страница
+		Limit: pageSize + 1,
+	}
+	if query.SortBy == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown sort field")
+	}
+	if err := applyDueFilter(&query, req, time.Now()); err != nil {
+		return nil, err
+	}
+	if req.ListId != "" {
+		list, err := s.resolveList(ctx, req.ListId, query.UserID)
+		if err != nil {
+			return nil, err
+		}
+		query.ListID = list.ID
+	}
+	if query.AnyTags, err = normalizeTagNames(req.AnyTags); err != nil {
+		return nil, err
+	}
+	if query.AllTags, err = normalizeTagNames(req.AllTags); err != nil {
+		return nil, err
+	}
+	fingerprint := queryFingerprint(req)
+	if req.PageToken != "" {
+		cursor, err := decodePageToken(req.PageToken, fingerprint)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+		query.After = cursor
+	}
+
+	todos, total, err := s.todoRepo.ListTodos(ctx, query)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get todos: %v", err)
 	}
 
+	var nextPageToken string
+	if len(todos) > pageSize {
+		todos = todos[:pageSize]
+		nextPageToken = encodePageToken(todos[len(todos)-1], query.SortBy, fingerprint)
+	}
+
 	var todoItems []*proto.TodoItem
-	for _, todo := range todos {
-		todoItems = append(todoItems, &proto.TodoItem{
-			Id:     fmt.Sprintf("%d", todo.ID),
-			UserId: fmt.Sprintf("%d", todo.UserID),
-			Title:  todo.Title,
-			Completed: todo.Completed,
-		})
+	if req.AsTree {
+		descendants, err := s.todoRepo.GetDescendants(ctx, todoIDs(todos))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get subtasks: %v", err)
+		}
+		todoItems = buildTodoTree(todos, descendants)
+	} else {
+		for _, todo := range todos {
+			todoItems = append(todoItems, todoToProto(todo))
+		}
+	}
+
+	return &proto.GetTodosResponse{
+		Todos:         todoItems,
+		NextPageToken: nextPageToken,
+		TotalCount:    total,
+	}, nil
+}
+
+func (s *TodoServiceServer) GetTodo(ctx context.Context, req *proto.GetTodoRequest) (*proto.TodoItem, error) {
+	todoID, err := strconv.ParseUint(req.Id, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid todo ID format")
+	}
+
+	todo, err := s.todoRepo.GetTodoByID(ctx, uint(todoID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "todo not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get todo: %v", err)
+	}
+
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	if todo.UserID != uint(userID) {
+		// Не раскрываем существование чужих задач
+		return nil, status.Errorf(codes.NotFound, "todo not found")
 	}
 
-	return &proto.GetTodosResponse{Todos: todoItems}, nil
+	return todoToProto(todo), nil
 }
 
 func (s *TodoServiceServer) UpdateTodo(ctx context.Context, req *proto.UpdateTodoRequest) (*proto.TodoItem, error) {
@@ -82,7 +243,7 @@ func (s *TodoServiceServer) UpdateTodo(ctx context.Context, req *proto.UpdateTod
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid todo ID format")
 	}
-	
+
 	todo, err := s.todoRepo.GetTodoByID(ctx, uint(todoID))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -99,19 +260,93 @@ func (s *TodoServiceServer) UpdateTodo(ctx context.Context, req *proto.UpdateTod
 		return nil, status.Errorf(codes.PermissionDenied, "you don't have permission to update this todo")
 	}
 
-	todo.Title = req.Title
-	todo.Completed = req.Completed
+	if req.ExpectedVersion != 0 && todo.Version != req.ExpectedVersion {
+		return nil, status.Errorf(codes.Aborted, "todo version mismatch: expected %d, current %d", req.ExpectedVersion, todo.Version)
+	}
 
-	if err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		// Без маски — полная замена, но обязательные поля не затираем
+		paths = todoReplaceFields
+	}
+	stored := *todo
+	if err := applyTodoUpdate(todo, req, paths); err != nil {
+		return nil, err
+	}
+	// Метки создаются в одной транзакции с записью задачи: при конфликте
+	// версий не должно остаться созданных впустую меток
+	update := repository.TodoUpdate{Todo: todo, ReplaceTags: containsString(paths, "tags")}
+	if update.ReplaceTags {
+		if update.TagNames, err = normalizeTagNames(req.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateTodoSchedule(todo); err != nil {
+		return nil, err
+	}
+	if err := normalizeRecurrence(todo, &stored); err != nil {
+		return nil, err
+	}
+
+	// Выполнение повторяющейся задачи порождает следующее вхождение.
+	// Серия переходит к нему, а у выполненной задачи правило снимается,
+	// чтобы повторная отметка не создала дубликат. Вхождение сохраняется
+	// в одной транзакции с отметкой: сбой вставки не должен обрывать серию.
+	if !stored.Completed && todo.Completed && todo.Recurrence != "" {
+		if update.Next, err = nextOccurrence(todo); err != nil {
+			return nil, err
+		}
+		// Новое вхождение встает в конец списка, как новая задача
+		if update.Next != nil {
+			if update.Next.Position, err = s.appendPosition(ctx, todo.UserID, todo.ListID); err != nil {
+				return nil, err
+			}
+		}
+		todo.Recurrence = ""
+		todo.SeriesStart = nil
+	}
+
+	// Перенос в другое место дерева и каскадное выполнение затрагивают
+	// подзадачи, поэтому владелец проверяется для всего поддерева
+	reparent := containsString(paths, "parent_id") && req.ParentId != formatParentID(todo.ParentID)
+	completeSubtasks := req.CompleteSubtasks && todo.Completed
+	var descendants []*models.Todo
+	if reparent || completeSubtasks {
+		if descendants, err = s.loadSubtree(ctx, todo, todo.UserID); err != nil {
+			return nil, err
+		}
+	}
+	update.SubtaskColumns = map[string]interface{}{}
+	if reparent {
+		listID := todo.ListID
+		if err := s.reparentTodo(ctx, todo, req.ParentId, descendants); err != nil {
+			return nil, err
+		}
+		if todo.ListID != listID {
+			update.SubtaskColumns["list_id"] = todo.ListID
+		}
+		// Прежний ключ порядка относится к прежним соседям
+		if todo.Position, err = s.appendPosition(ctx, todo.UserID, todo.ListID); err != nil {
+			return nil, err
+		}
+	}
+	if completeSubtasks {
+		update.SubtaskColumns["completed"] = true
+	}
+	update.SubtaskIDs = todoIDs(descendants)
+
+	if err := s.todoRepo.UpdateTodo(ctx, update); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, status.Errorf(codes.Aborted, "todo was modified concurrently, reload and retry")
+		}
 		return nil, status.Errorf(codes.Internal, "failed to update todo: %v", err)
 	}
 
-	return &proto.TodoItem{
-		Id:     fmt.Sprintf("%d", todo.ID),
-		UserId: fmt.Sprintf("%d", todo.UserID),
-		Title:  todo.Title,
-		Completed: todo.Completed,
-	}, nil
+	item := todoToProto(todo)
+	if update.Next != nil {
+		item.NextOccurrence = todoToProto(update.Next)
+	}
+	return item, nil
 }
 
 func (s *TodoServiceServer) DeleteTodo(ctx context.Context, req *proto.DeleteTodoRequest) (*proto.DeleteTodoResponse, error) {
@@ -119,7 +354,7 @@ func (s *TodoServiceServer) DeleteTodo(ctx context.Context, req *proto.DeleteTod
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid todo ID format")
 	}
-	
+
 	todo, err := s.todoRepo.GetTodoByID(ctx, uint(todoID))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -127,7 +362,7 @@ func (s *TodoServiceServer) DeleteTodo(ctx context.Context, req *proto.DeleteTod
 		}
 		return nil, status.Errorf(codes.Internal, "failed to get todo: %v", err)
 	}
-	
+
 	userID, err := strconv.ParseUint(req.UserId, 10, 64)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
@@ -136,9 +371,170 @@ func (s *TodoServiceServer) DeleteTodo(ctx context.Context, req *proto.DeleteTod
 		return nil, status.Errorf(codes.PermissionDenied, "you don't have permission to delete this todo")
 	}
 
-	if err := s.todoRepo.DeleteTodo(ctx, todo.ID); err != nil {
+	if req.ExpectedVersion != 0 && todo.Version != req.ExpectedVersion {
+		return nil, status.Errorf(codes.Aborted, "todo version mismatch: expected %d, current %d", req.ExpectedVersion, todo.Version)
+	}
+
+	// Подзадачи удаляются вместе с задачей, поэтому они тоже должны быть своими
+	if _, err := s.loadSubtree(ctx, todo, todo.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := s.todoRepo.DeleteTodo(ctx, todo.ID, todo.Version); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, status.Errorf(codes.Aborted, "todo was modified concurrently, reload and retry")
+		}
 		return nil, status.Errorf(codes.Internal, "failed to delete todo: %v", err)
 	}
 
-	return &proto.DeleteTodoResponse{Message: "Todo deleted successfully"}, nil
-}
\ No newline at end of file
+	todo.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return &proto.DeleteTodoResponse{Message: "Todo moved to trash", Todo: todoToProto(todo)}, nil
+}
+
+func todoToProto(todo *models.Todo) *proto.TodoItem {
+	item := &proto.TodoItem{
+		Id:         fmt.Sprintf("%d", todo.ID),
+		UserId:     fmt.Sprintf("%d", todo.UserID),
+		Title:      todo.Title,
+		Completed:  todo.Completed,
+		Version:    todo.Version,
+		DueAt:      timeToTimestamp(todo.DueAt),
+		RemindAt:   timeToTimestamp(todo.RemindAt),
+		ListId:     formatListID(todo.ListID),
+		Tags:       tagNames(todo.Tags),
+		ParentId:   formatParentID(todo.ParentID),
+		Recurrence: todo.Recurrence,
+		TimeZone:   todo.TimeZone,
+		Priority:   proto.Priority(todo.Priority),
+		Position:   todo.Position,
+	}
+	if todo.DeletedAt.Valid {
+		item.DeletedAt = timestamppb.New(todo.DeletedAt.Time)
+	}
+	return item
+}
+
+func tagNames(tags []models.Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// sameTime сравнивает необязательные моменты времени
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTodoSchedule проверяет, что напоминание приходит раньше срока
+func validateTodoSchedule(todo *models.Todo) error {
+	if todo.DueAt != nil && todo.RemindAt != nil && !todo.RemindAt.Before(*todo.DueAt) {
+		return status.Errorf(codes.InvalidArgument, "remind_at must be before due_at")
+	}
+	return nil
+}
+
+// applyDueFilter переводит фильтр по сроку в границы due_at.
+// "Сегодня" считается в часовом поясе клиента.
+func applyDueFilter(query *repository.TodoQuery, req *proto.GetTodosRequest, now time.Time) error {
+	loc := time.UTC
+	if req.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.TimeZone); err != nil {
+			return status.Errorf(codes.InvalidArgument, "unknown time zone %q", req.TimeZone)
+		}
+	}
+	now = now.In(loc)
+
+	switch req.DueFilter {
+	case proto.DueFilter_DUE_FILTER_UNSPECIFIED:
+	case proto.DueFilter_DUE_FILTER_OVERDUE:
+		if req.Completed != nil && *req.Completed {
+			return status.Errorf(codes.InvalidArgument, "overdue todos cannot be completed")
+		}
+		notCompleted := false
+		query.Completed = &notCompleted
+		query.DueBefore = &now
+	case proto.DueFilter_DUE_FILTER_DUE_TODAY:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		end := start.AddDate(0, 0, 1)
+		query.DueFrom, query.DueBefore = &start, &end
+	case proto.DueFilter_DUE_FILTER_DUE_WITHIN:
+		if req.DueWithinDays <= 0 {
+			return status.Errorf(codes.InvalidArgument, "due_within_days must be positive")
+		}
+		end := now.AddDate(0, 0, int(req.DueWithinDays))
+		query.DueFrom, query.DueBefore = &now, &end
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown due filter")
+	}
+	return nil
+}
+
+func timestampToTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func timeToTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// applyTodoUpdate переносит в todo только поля, перечисленные в paths
+func applyTodoUpdate(todo *models.Todo, req *proto.UpdateTodoRequest, paths []string) error {
+	for _, path := range paths {
+		switch path {
+		case "title":
+			if strings.TrimSpace(req.Title) == "" {
+				return status.Errorf(codes.InvalidArgument, "title is required")
+			}
+			todo.Title = req.Title
+		case "completed":
+			todo.Completed = req.Completed
+		case "due_at":
+			todo.DueAt = timestampToTime(req.DueAt)
+		case "remind_at":
+			remindAt := timestampToTime(req.RemindAt)
+			// Новое время напоминания — новое напоминание. То же время
+			// (например, в полной замене через PUT) отправленное
+			// напоминание не возобновляет.
+			if !sameTime(todo.RemindAt, remindAt) {
+				todo.RemindedAt = nil
+			}
+			todo.RemindAt = remindAt
+		case "recurrence":
+			todo.Recurrence = req.Recurrence
+		case "time_zone":
+			todo.TimeZone = req.TimeZone
+		case "priority":
+			if err := validatePriority(req.Priority); err != nil {
+				return err
+			}
+			todo.Priority = int(req.Priority)
+		case "tags", "parent_id":
+			// Обрабатываются в UpdateTodo, здесь только проверка пути
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown field in update mask: %q", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/repository/repotest"
+)
+
+const testUserID = 1
+
+// todoTestEnv — TodoService поверх хранилищ в памяти
+type todoTestEnv struct {
+	t       *testing.T
+	service *TodoServiceServer
+	todos   *repotest.Todos
+	lists   *repotest.Lists
+	tags    *repotest.Tags
+}
+
+func newTodoTestEnv(t *testing.T) *todoTestEnv {
+	tags := repotest.NewTags()
+	todos := repotest.NewTodos(tags)
+	lists := repotest.NewLists()
+	return &todoTestEnv{
+		t:       t,
+		service: NewTodoServiceServer(todos, lists, tags, nil, 5),
+		todos:   todos,
+		lists:   lists,
+		tags:    tags,
+	}
+}
+
+// addList создает список пользователя testUserID
+func (e *todoTestEnv) addList(name string) *models.List {
+	e.t.Helper()
+	list := &models.List{UserID: testUserID, Name: name}
+	if err := e.lists.CreateList(context.Background(), list); err != nil {
+		e.t.Fatalf("CreateList: %v", err)
+	}
+	return list
+}
+
+// add сохраняет задачу пользователя testUserID в обход сервиса
+func (e *todoTestEnv) add(todo *models.Todo) *models.Todo {
+	e.t.Helper()
+	todo.UserID = testUserID
+	if err := e.todos.CreateTodo(context.Background(), todo); err != nil {
+		e.t.Fatalf("CreateTodo: %v", err)
+	}
+	return todo
+}
+
+// put заменяет задачу целиком: пустая маска
+func (e *todoTestEnv) put(req *proto.UpdateTodoRequest) (*proto.TodoItem, error) {
+	req.UserId = strconv.Itoa(testUserID)
+	return e.service.UpdateTodo(context.Background(), req)
+}
+
+// patch меняет только поля paths
+func (e *todoTestEnv) patch(req *proto.UpdateTodoRequest, paths ...string) (*proto.TodoItem, error) {
+	req.UserId = strconv.Itoa(testUserID)
+	req.UpdateMask = &fieldmaskpb.FieldMask{Paths: paths}
+	return e.service.UpdateTodo(context.Background(), req)
+}
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func TestUpdateTodoKeepsSentReminder(t *testing.T) {
+	env := newTodoTestEnv(t)
+	remindAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	sentAt := remindAt.Add(time.Second)
+	todo := env.add(&models.Todo{Title: "call", RemindAt: &remindAt, RemindedAt: &sentAt})
+	id := formatID(todo.ID)
+
+	// Полная замена с тем же remind_at не возобновляет отправленное напоминание
+	if _, err := env.put(&proto.UpdateTodoRequest{Id: id, Title: "call mom", RemindAt: timestamppb.New(remindAt)}); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if stored := env.todos.Todo(todo.ID); stored.RemindedAt == nil {
+		t.Fatalf("reminder was re-armed by an update that kept remind_at")
+	}
+
+	// Новое время — новое напоминание
+	later := remindAt.Add(time.Hour)
+	if _, err := env.put(&proto.UpdateTodoRequest{Id: id, Title: "call mom", RemindAt: timestamppb.New(later)}); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if stored := env.todos.Todo(todo.ID); stored.RemindedAt != nil {
+		t.Fatalf("reminded_at = %v after remind_at changed, want nil", stored.RemindedAt)
+	}
+}
+
+// staleTodos отдает задачи с устаревшей версией, как если бы их изменили
+// между чтением и записью
+type staleTodos struct {
+	*repotest.Todos
+}
+
+func (r staleTodos) GetTodoByID(ctx context.Context, id uint) (*models.Todo, error) {
+	todo, err := r.Todos.GetTodoByID(ctx, id)
+	if err == nil {
+		todo.Version--
+	}
+	return todo, err
+}
+
+func TestUpdateTodoConflictCreatesNoTags(t *testing.T) {
+	env := newTodoTestEnv(t)
+	todo := env.add(&models.Todo{Title: "call", Version: 2})
+	env.service = NewTodoServiceServer(staleTodos{env.todos}, nil, env.tags, nil, 5)
+
+	_, err := env.put(&proto.UpdateTodoRequest{Id: formatID(todo.ID), Title: "call", Tags: []string{"home"}})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("UpdateTodo error = %v, want Aborted", err)
+	}
+	if tags, _ := env.tags.GetTagsByUserID(context.Background(), testUserID); len(tags) != 0 {
+		t.Fatalf("conflicting update left tags behind: %+v", tags)
+	}
+}
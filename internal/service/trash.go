@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/repository"
+)
+
+func (s *TodoServiceServer) ListTrash(ctx context.Context, req *proto.ListTrashRequest) (*proto.ListTrashResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultTodoPageSize
+	}
+	if pageSize > maxTodoPageSize {
+		pageSize = maxTodoPageSize
+	}
+
+	fingerprint := queryFingerprint(req)
+	var after *repository.TodoCursor
+	if req.PageToken != "" {
+		if after, err = decodePageToken(req.PageToken, fingerprint); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	todos, err := s.todoRepo.ListTrash(ctx, uint(userID), after, pageSize+1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get trash: %v", err)
+	}
+
+	var nextPageToken string
+	if len(todos) > pageSize {
+		todos = todos[:pageSize]
+		nextPageToken = encodePageToken(todos[len(todos)-1], repository.TodoSortByDeleted, fingerprint)
+	}
+
+	var todoItems []*proto.TodoItem
+	for _, todo := range todos {
+		todoItems = append(todoItems, todoToProto(todo))
+	}
+	return &proto.ListTrashResponse{Todos: todoItems, NextPageToken: nextPageToken}, nil
+}
+
+// RestoreTodo возвращает задачу из корзины вместе с подзадачами, удаленными
+// одновременно с ней. Если ее список успел исчезнуть, задача попадает в Inbox.
+func (s *TodoServiceServer) RestoreTodo(ctx context.Context, req *proto.RestoreTodoRequest) (*proto.TodoItem, error) {
+	todo, err := s.getTrashedTodo(ctx, req.Id, req.UserId, "restore")
+	if err != nil {
+		return nil, err
+	}
+
+	listID := todo.ListID
+	if todo.ParentID != nil {
+		// Подзадача возвращается только к живому родителю и в его список
+		parent, err := s.todoRepo.GetTodoByID(ctx, *todo.ParentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, status.Errorf(codes.FailedPrecondition, "parent todo is in the trash, restore it first")
+			}
+			return nil, status.Errorf(codes.Internal, "failed to get parent todo: %v", err)
+		}
+		listID = parent.ListID
+	} else {
+		list, err := s.resolveList(ctx, formatListID(todo.ListID), todo.UserID)
+		if status.Code(err) == codes.NotFound {
+			// Список удалили, пока задача лежала в корзине
+			list, err = s.resolveList(ctx, "", todo.UserID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		listID = list.ID
+	}
+	if listID != todo.ListID {
+		if todo.Position, err = s.appendPosition(ctx, todo.UserID, listID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.todoRepo.RestoreTodo(ctx, todo, listID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to restore todo: %v", err)
+	}
+
+	return todoToProto(todo), nil
+}
+
+// PurgeTodo окончательно удаляет задачу из корзины, не дожидаясь срока хранения
+func (s *TodoServiceServer) PurgeTodo(ctx context.Context, req *proto.PurgeTodoRequest) (*proto.PurgeTodoResponse, error) {
+	todo, err := s.getTrashedTodo(ctx, req.Id, req.UserId, "purge")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.todoRepo.PurgeTodo(ctx, todo.ID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to purge todo: %v", err)
+	}
+
+	return &proto.PurgeTodoResponse{Message: "Todo purged successfully"}, nil
+}
+
+// getTrashedTodo загружает задачу из корзины и проверяет владельца
+func (s *TodoServiceServer) getTrashedTodo(ctx context.Context, id, userID, action string) (*models.Todo, error) {
+	todoID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid todo ID format")
+	}
+
+	todo, err := s.todoRepo.GetTrashedTodo(ctx, uint(todoID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "todo not found in trash")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get todo: %v", err)
+	}
+
+	ownerID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	if todo.UserID != uint(ownerID) {
+		return nil, status.Errorf(codes.PermissionDenied, "you don't have permission to %s this todo", action)
+	}
+	return todo, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"server/internal/repository"
+)
+
+// trashPurgeBatchSize — сколько задач удаляется одним запросом
+const trashPurgeBatchSize = 500
+
+// TrashPurger периодически окончательно удаляет задачи, пролежавшие
+// в корзине дольше срока хранения
+type TrashPurger struct {
+	todoRepo  repository.TodoRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashPurger(todoRepo repository.TodoRepository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{todoRepo: todoRepo, retention: retention, interval: interval}
+}
+
+// Run блокируется до отмены ctx
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.tick(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) tick(ctx context.Context, now time.Time) {
+	before := now.Add(-p.retention)
+	var total int64
+	for {
+		purged, err := p.todoRepo.PurgeDeletedBefore(ctx, before, trashPurgeBatchSize)
+		if err != nil {
+			log.Printf("trash purger: failed to purge todos: %v", err)
+			break
+		}
+		total += purged
+		if purged < trashPurgeBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("trash purger: purged %d todos deleted before %s", total, before.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/repository"
+	"server/internal/totp"
+)
+
+const (
+	// Сколько действует challenge-токен между вводом пароля и кода
+	loginChallengeTTL = 5 * time.Minute
+	// После стольких неверных кодов challenge-токен перестает действовать
+	maxSecondFactorAttempts = 5
+	// Допустимое расхождение часов устройства: по одному шагу в обе стороны
+	totpSkew          = 1
+	recoveryCodeCount = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// LoginSecondFactor обменивает challenge-токен из Login и код второго
+// фактора на пару токенов
+func (s *UserServiceServer) LoginSecondFactor(ctx context.Context, req *proto.LoginSecondFactorRequest) (*proto.LoginResponse, error) {
+	if req.ChallengeToken == "" || req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "challenge token and code are required")
+	}
+
+	now := time.Now()
+	challenge, err := s.userTokenRepo.GetActiveUserToken(ctx, models.UserTokenLoginChallenge, hashToken(req.ChallengeToken), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired challenge token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get challenge token: %v", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired challenge token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if err := s.checkLoginAllowed(user); err != nil {
+		return nil, err
+	}
+	// Неверные коды считаются вместе с неверными паролями: новые
+	// challenge-токены не дают обойти лимит попыток
+	if err := s.checkLoginThrottle(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user.ID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.userTokenRepo.RecordUserTokenFailure(ctx, challenge.ID, maxSecondFactorAttempts, now); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record attempt: %v", err)
+		}
+		return nil, s.loginFailed(ctx, user.Email, status.Errorf(codes.Unauthenticated, "invalid code"))
+	}
+
+	// Из двух параллельных запросов с верным кодом токены получит один
+	if _, err := s.userTokenRepo.ConsumeUserToken(ctx, models.UserTokenLoginChallenge, challenge.TokenHash, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired challenge token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to use challenge token: %v", err)
+	}
+	s.loginSucceeded(ctx, user.Email)
+
+	return s.startSession(ctx, user)
+}
+
+// EnrollTOTP выдает новый секрет. Второй фактор включится только после
+// ConfirmTOTP, поэтому незавершенная настройка не закроет доступ к аккаунту.
+func (s *UserServiceServer) EnrollTOTP(ctx context.Context, req *proto.EnrollTOTPRequest) (*proto.EnrollTOTPResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	user, err := s.getUser(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.secondFactorEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate secret: %v", err)
+	}
+	err = s.totpRepo.SaveTOTPCredential(ctx, &models.TOTPCredential{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save secret: %v", err)
+	}
+
+	return &proto.EnrollTOTPResponse{
+		Secret:     totp.EncodeSecret(secret),
+		OtpauthUri: totp.URI(s.cfg.TOTPIssuer, user.Email, secret, totp.DefaultParams),
+	}, nil
+}
+
+// ConfirmTOTP включает второй фактор, если код из приложения верен,
+// и выдает коды восстановления
+func (s *UserServiceServer) ConfirmTOTP(ctx context.Context, req *proto.ConfirmTOTPRequest) (*proto.ConfirmTOTPResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	credential, err := s.totpRepo.GetTOTPCredential(ctx, uint(userID))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.Internal, "failed to get secret: %v", err)
+	}
+	if credential == nil || credential.ConfirmedAt != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "no pending two-factor enrollment, call EnrollTOTP first")
+	}
+
+	now := time.Now()
+	counter, ok := totp.Validate(credential.Secret, strings.TrimSpace(req.Code), now, totp.DefaultParams, totpSkew)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid code")
+	}
+
+	recoveryCodes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range recoveryCodes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to generate recovery code: %v", err)
+		}
+		recoveryCodes[i] = code
+		hashes[i] = hashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.totpRepo.ConfirmTOTPCredential(ctx, uint(userID), counter, now, hashes); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "no pending two-factor enrollment, call EnrollTOTP first")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to enable two-factor authentication: %v", err)
+	}
+	return &proto.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTOTP выключает второй фактор. Нужны и пароль, и код: одного
+// украденного access-токена для этого недостаточно.
+func (s *UserServiceServer) DisableTOTP(ctx context.Context, req *proto.DisableTOTPRequest) (*proto.DisableTOTPResponse, error) {
+	userID, err := strconv.ParseUint(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+	user, err := s.getUser(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "password is incorrect")
+	}
+
+	enabled, err := s.secondFactorEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is not enabled")
+	}
+	ok, err := s.checkSecondFactor(ctx, user.ID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "invalid code")
+	}
+
+	if err := s.totpRepo.DeleteTOTP(ctx, user.ID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to disable two-factor authentication: %v", err)
+	}
+	return &proto.DisableTOTPResponse{Message: "Two-factor authentication disabled"}, nil
+}
+
+func (s *UserServiceServer) secondFactorEnabled(ctx context.Context, userID uint) (bool, error) {
+	credential, err := s.totpRepo.GetTOTPCredential(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, status.Errorf(codes.Internal, "failed to get two-factor settings: %v", err)
+	}
+	return credential.ConfirmedAt != nil, nil
+}
+
+// checkSecondFactor проверяет код TOTP или, если это не он, код
+// восстановления. Принятый код повторно не принимается.
+func (s *UserServiceServer) checkSecondFactor(ctx context.Context, userID uint, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.DefaultParams.Digits {
+		credential, err := s.totpRepo.GetTOTPCredential(ctx, userID)
+		if err != nil {
+			// Второй фактор успели выключить
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, nil
+			}
+			return false, status.Errorf(codes.Internal, "failed to get two-factor settings: %v", err)
+		}
+		counter, ok := totp.Validate(credential.Secret, code, time.Now(), totp.DefaultParams, totpSkew)
+		if !ok {
+			return false, nil
+		}
+		if err := s.totpRepo.AdvanceTOTPCounter(ctx, userID, counter); err != nil {
+			if errors.Is(err, repository.ErrTOTPCodeUsed) {
+				return false, nil
+			}
+			return false, status.Errorf(codes.Internal, "failed to save code: %v", err)
+		}
+		return true, nil
+	}
+
+	err := s.totpRepo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, status.Errorf(codes.Internal, "failed to use recovery code: %v", err)
+	}
+	return true, nil
+}
+
+// generateRecoveryCode возвращает 80-битный код вида "abcd-efgh-ijkl-mnop"
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// normalizeRecoveryCode убирает дефисы и пробелы и переводит код в нижний
+// регистр, чтобы код принимался в любом написании
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
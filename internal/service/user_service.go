@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"server/internal/apikey"
+	"server/internal/keyring"
+	"server/internal/loginguard"
+	"server/internal/mailer"
 	"server/internal/models"
 	"server/internal/proto"
+	"server/internal/rbac"
 	"server/internal/repository"
+	"server/internal/revocation"
 
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
@@ -19,24 +26,77 @@ import (
 
 type UserServiceServer struct {
 	proto.UnimplementedUserServiceServer
-	userRepo repository.UserRepository
-	jwtSecret string
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	userTokenRepo    repository.UserTokenRepository
+	totpRepo         repository.TOTPRepository
+	apiKeyRepo       repository.APIKeyRepository
+	oauthRepo        repository.OAuthRepository
+	identityRepo     repository.IdentityRepository
+	sessionRepo      repository.SessionRepository
+	revocations      revocation.Store
+	loginGuard       *loginguard.Guard
+	keys             *keyring.Keyring
+	mailer           mailer.Mailer
+	cfg              UserServiceConfig
+	sessions         *sessionActivity
 }
 
-func NewUserServiceServer(userRepo repository.UserRepository, jwtSecret string) *UserServiceServer {
-	return &UserServiceServer{userRepo: userRepo, jwtSecret: jwtSecret}
+// UserServiceConfig — сроки жизни токенов и прочие настройки UserService
+type UserServiceConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Срок действия ссылки для сброса пароля и адрес страницы, на которую
+	// она ведет (токен добавляется параметром token)
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	// То же для подтверждения email
+	EmailVerificationTTL time.Duration
+	EmailVerificationURL string
+	// Не пускать пользователей с неподтвержденным email. Иначе они входят
+	// с ограниченной ролью rbac.RoleUnverified.
+	RequireVerifiedEmail bool
+	// Имя сервиса, которое приложение-аутентификатор показывает рядом с кодом
+	TOTPIssuer string
+	// Идентификатор сервера авторизации OAuth (iss в ID-токенах) — внешний
+	// адрес шлюза
+	OAuthIssuer string
+}
+
+func NewUserServiceServer(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, userTokenRepo repository.UserTokenRepository, totpRepo repository.TOTPRepository, apiKeyRepo repository.APIKeyRepository, oauthRepo repository.OAuthRepository, identityRepo repository.IdentityRepository, sessionRepo repository.SessionRepository, revocations revocation.Store, loginGuard *loginguard.Guard, keys *keyring.Keyring, mail mailer.Mailer, cfg UserServiceConfig) *UserServiceServer {
+	return &UserServiceServer{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		userTokenRepo:    userTokenRepo,
+		totpRepo:         totpRepo,
+		apiKeyRepo:       apiKeyRepo,
+		oauthRepo:        oauthRepo,
+		identityRepo:     identityRepo,
+		sessionRepo:      sessionRepo,
+		revocations:      revocations,
+		loginGuard:       loginGuard,
+		keys:             keys,
+		mailer:           mail,
+		cfg:              cfg,
+		sessions:         newSessionActivity(),
+	}
 }
 
 func (s *UserServiceServer) Register(ctx context.Context, req *proto.RegisterRequest) (*proto.RegisterResponse, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
 	}
 
 	user := &models.User{
-		Email:    req.Email,
+		Email:    email,
 		Password: string(hashedPassword),
-		Role:     req.Role,
+		Role:     rbac.DefaultRole,
 	}
 
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
@@ -46,66 +106,185 @@ func (s *UserServiceServer) Register(ctx context.Context, req *proto.RegisterReq
 		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
 	}
 
-	return &proto.RegisterResponse{Message: "User registered successfully"}, nil
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &proto.RegisterResponse{Message: "User registered successfully, check your email to verify the address"}, nil
 }
 
 func (s *UserServiceServer) Login(ctx context.Context, req *proto.LoginRequest) (*proto.LoginResponse, error) {
-	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	key := loginKey(req.Email)
+	if err := s.checkLoginThrottle(ctx, key); err != nil {
+		return nil, err
+	}
+	// Неизвестный email и неверный пароль неотличимы ни по ошибке, ни по
+	// времени ответа: для несуществующего пользователя пароль сравнивается
+	// с заглушкой
+	invalidCredentials := status.Errorf(codes.Unauthenticated, "invalid credentials")
+
+	user, err := s.userRepo.GetUserByEmail(ctx, key)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, status.Errorf(codes.NotFound, "user not found")
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		return nil, s.loginFailed(ctx, key, invalidCredentials)
 	}
 
+	if user.Password == "" {
+		// Пароля нет у пользователей, созданных входом через провайдера SSO
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		return nil, s.loginFailed(ctx, key, invalidCredentials)
+	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		return nil, s.loginFailed(ctx, key, invalidCredentials)
+	}
+	// Проверяется после пароля, чтобы не раскрывать статус аккаунта подбором
+	if err := s.checkLoginAllowed(user); err != nil {
+		return nil, err
 	}
 
-	token, err := s.generateToken(user.ID, user.Role)
+	enabled, err := s.secondFactorEnabled(ctx, user.ID)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
+		return nil, err
 	}
+	if enabled {
+		// Счетчик сбросится только после верного второго фактора
+		challenge, _, err := s.issueUserToken(ctx, user.ID, models.UserTokenLoginChallenge, loginChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &proto.LoginResponse{SecondFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+	s.loginSucceeded(ctx, key)
+
+	// Каждый вход начинает новую сессию
+	return s.startSession(ctx, user)
+}
 
-	return &proto.LoginResponse{Token: token}, nil
+// checkLoginAllowed проверяет, может ли пользователь с верным паролем войти
+func (s *UserServiceServer) checkLoginAllowed(user *models.User) error {
+	if user.DisabledAt != nil {
+		return status.Errorf(codes.PermissionDenied, "user is disabled")
+	}
+	if !user.EmailVerified && s.cfg.RequireVerifiedEmail {
+		return status.Errorf(codes.PermissionDenied, "email address is not verified")
+	}
+	return nil
 }
 
 func (s *UserServiceServer) ValidateToken(ctx context.Context, req *proto.ValidateTokenRequest) (*proto.ValidateTokenResponse, error) {
-	token, err := jwt.Parse(req.Token, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+	if apikey.IsAPIKey(req.Token) {
+		return s.validateAPIKey(ctx, req.Token)
+	}
+
+	claims, err := s.parseToken(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ValidateTokenResponse{
+		IsValid:   true,
+		UserId:    fmt.Sprintf("%d", claims.UserID),
+		Role:      claims.Role,
+		SessionId: claims.SessionID,
+	}, nil
+}
+
+// accessClaims — проверенное содержимое access-токена
+type accessClaims struct {
+	UserID    uint
+	Role      string
+	JTI       string
+	SessionID string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// parseToken проверяет подпись и срок токена, а затем сверяется
+// с хранилищем отзывов и проверяет, не завершена ли сессия. Ключ проверки
+// выбирается по kid из заголовка: токены, подписанные выведенным из
+// оборота ключом, действуют до exp.
+func (s *UserServiceServer) parseToken(ctx context.Context, tokenString string) (*accessClaims, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := s.keys.Lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(s.jwtSecret), nil
+		return key.PublicKey(), nil
 	})
 
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid token claims")
 	}
 
-	userID := claims["user_id"].(string)
-	role := claims["role"].(string)
+	userID, _ := mapClaims["user_id"].(string)
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token claims")
+	}
+	claims := &accessClaims{UserID: uint(id)}
+	claims.Role, _ = mapClaims["role"].(string)
+	claims.SessionID, _ = mapClaims["sid"].(string)
+	// Без jti и iat токен нельзя сверить со списком отзывов
+	claims.JTI, _ = mapClaims["jti"].(string)
+	iat, ok := mapClaims["iat"].(float64)
+	if claims.JTI == "" || !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token claims")
+	}
+	claims.IssuedAt = floatToTime(iat)
+	if exp, ok := mapClaims["exp"].(float64); ok {
+		claims.ExpiresAt = floatToTime(exp)
+	}
 
-	return &proto.ValidateTokenResponse{
-		IsValid: true,
-		UserId:  userID,
-		Role:    role,
-	}, nil
+	revoked, err := s.revocations.IsRevoked(ctx, claims.JTI, claims.UserID, claims.IssuedAt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check token revocation: %v", err)
+	}
+	if revoked {
+		return nil, status.Errorf(codes.Unauthenticated, "token has been revoked")
+	}
+	active, err := s.sessionActive(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, status.Errorf(codes.Unauthenticated, "session has been revoked")
+	}
+	return claims, nil
 }
 
-func (s *UserServiceServer) generateToken(userID uint, role string) (string, error) {
+func (s *UserServiceServer) generateToken(userID uint, role, sessionID string) (string, error) {
+	jti, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": fmt.Sprintf("%d", userID),
 		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // Токен действует 24 часа
+		"jti":     jti,
+		"sid":     sessionID,
+		// iat с точностью до микросекунд: отзыв "на всех устройствах" не должен
+		// задевать токены, выданные в ту же секунду сразу после него
+		"iat": timeToFloat(now),
+		"exp": now.Add(s.cfg.AccessTokenTTL).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	key := s.keys.Current()
+	token := jwt.NewWithClaims(key.SigningMethod(), claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.PrivateKey())
 	if err != nil {
 		return "", err
 	}
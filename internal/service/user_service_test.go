@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"server/internal/keyring"
+	"server/internal/loginguard"
+	"server/internal/mailer"
+	"server/internal/models"
+	"server/internal/proto"
+	"server/internal/rbac"
+	"server/internal/repository/repotest"
+	"server/internal/revocation"
+)
+
+const testPassword = "password123"
+
+// userTestEnv — UserService поверх хранилищ в памяти
+type userTestEnv struct {
+	t             *testing.T
+	service       *UserServiceServer
+	users         *repotest.Users
+	refreshTokens *repotest.RefreshTokens
+	identities    *repotest.Identities
+	sessions      *repotest.Sessions
+}
+
+func newUserTestEnv(t *testing.T) *userTestEnv {
+	t.Helper()
+	keys, err := keyring.New(repotest.NewSigningKeys(), keyring.AlgEdDSA, time.Hour)
+	if err != nil {
+		t.Fatalf("keyring.New: %v", err)
+	}
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatalf("keys.Load: %v", err)
+	}
+	users := repotest.NewUsers()
+	refreshTokens := repotest.NewRefreshTokens()
+	identities := repotest.NewIdentities(users)
+	sessions := repotest.NewSessions(refreshTokens)
+	policy := loginguard.Policy{FreeAttempts: 100, BaseDelay: time.Second, MaxDelay: time.Minute, ResetAfter: time.Hour}
+	return &userTestEnv{
+		t: t,
+		service: NewUserServiceServer(users, refreshTokens, repotest.NewUserTokens(), repotest.NewTOTP(), repotest.NewAPIKeys(),
+			repotest.NewOAuth(), identities, sessions, revocation.NewMemoryStore(),
+			loginguard.New(loginguard.NewMemoryStore(), policy, policy), keys, discardMailer{},
+			UserServiceConfig{AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: time.Hour}),
+		users:         users,
+		refreshTokens: refreshTokens,
+		identities:    identities,
+		sessions:      sessions,
+	}
+}
+
+// addUser создает пользователя с подтвержденным email и паролем testPassword
+func (e *userTestEnv) addUser(email string) *models.User {
+	e.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		e.t.Fatalf("bcrypt: %v", err)
+	}
+	user := &models.User{Email: email, Password: string(hash), Role: rbac.RoleUser, EmailVerified: true}
+	if err := e.users.CreateUser(context.Background(), user); err != nil {
+		e.t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+func (e *userTestEnv) login(email string) *proto.LoginResponse {
+	e.t.Helper()
+	resp, err := e.service.Login(context.Background(), &proto.LoginRequest{Email: email, Password: testPassword})
+	if err != nil {
+		e.t.Fatalf("Login: %v", err)
+	}
+	return resp
+}
+
+func (e *userTestEnv) refresh(token string) (*proto.LoginResponse, error) {
+	return e.service.Refresh(context.Background(), &proto.RefreshRequest{RefreshToken: token})
+}
+
+func (e *userTestEnv) validate(token string) error {
+	_, err := e.service.ValidateToken(context.Background(), &proto.ValidateTokenRequest{Token: token})
+	return err
+}
+
+// discardMailer молча принимает письма
+type discardMailer struct{}
+
+func (discardMailer) Send(ctx context.Context, msg mailer.Message) error {
+	return nil
+}
+
+func TestValidateTokenRequiresRevocationClaims(t *testing.T) {
+	env := newUserTestEnv(t)
+	user := env.addUser("alice@example.com")
+	if err := env.validate(env.login("alice@example.com").Token); err != nil {
+		t.Fatalf("issued token: %v", err)
+	}
+
+	now := time.Now()
+	for name, claims := range map[string]jwt.MapClaims{
+		"without jti": {"user_id": strconv.Itoa(int(user.ID)), "role": rbac.RoleUser, "iat": timeToFloat(now)},
+		"without iat": {"user_id": strconv.Itoa(int(user.ID)), "role": rbac.RoleUser, "jti": "id"},
+	} {
+		claims["exp"] = now.Add(time.Minute).Unix()
+		key := env.service.keys.Current()
+		token := jwt.NewWithClaims(key.SigningMethod(), claims)
+		token.Header["kid"] = key.ID
+		signed, err := token.SignedString(key.PrivateKey())
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		if err := env.validate(signed); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("token %s: err = %v, want Unauthenticated", name, err)
+		}
+	}
+}
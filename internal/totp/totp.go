@@ -0,0 +1,135 @@
+// Package totp реализует одноразовые пароли HOTP (RFC 4226) и TOTP
+// (RFC 6238), совместимые с Google Authenticator и аналогами.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Algorithm — хэш-функция HMAC
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// Params — параметры генерации кодов. Приложения-аутентификаторы надежно
+// поддерживают только значения по умолчанию (DefaultParams).
+type Params struct {
+	Period    time.Duration
+	Digits    int
+	Algorithm Algorithm
+}
+
+var DefaultParams = Params{Period: 30 * time.Second, Digits: 6, Algorithm: SHA1}
+
+// SecretSize — длина секрета в байтах; RFC 4226 рекомендует не меньше 160 бит
+const SecretSize = 20
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret создает случайный секрет
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// EncodeSecret возвращает секрет в base32 — в таком виде его вводят вручную
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// HOTP вычисляет код для счетчика counter (RFC 4226, раздел 5.3)
+func HOTP(secret []byte, counter uint64, digits int, alg Algorithm) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(alg.hash(), secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod)
+}
+
+// Counter — номер временного шага, в который попадает t (RFC 6238, раздел 4.2)
+func Counter(t time.Time, period time.Duration) uint64 {
+	return uint64(t.Unix()) / uint64(period/time.Second)
+}
+
+// Code вычисляет TOTP-код на момент t
+func Code(secret []byte, t time.Time, p Params) string {
+	return HOTP(secret, Counter(t, p.Period), p.Digits, p.Algorithm)
+}
+
+// Validate проверяет код на момент t, допуская расхождение часов в skew
+// шагов в обе стороны. Возвращает номер шага, которому соответствует код:
+// вызывающий должен запомнить его и не принимать коды с номером не больше
+// запомненного, иначе перехваченный код можно использовать повторно.
+func Validate(secret []byte, code string, t time.Time, p Params, skew int) (uint64, bool) {
+	if len(code) != p.Digits {
+		return 0, false
+	}
+	if _, err := strconv.ParseUint(code, 10, 64); err != nil {
+		return 0, false
+	}
+	current := Counter(t, p.Period)
+	for i := -skew; i <= skew; i++ {
+		if i < 0 && current < uint64(-i) {
+			continue
+		}
+		counter := current + uint64(int64(i))
+		expected := HOTP(secret, counter, p.Digits, p.Algorithm)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// URI возвращает ссылку otpauth://, которую приложения-аутентификаторы
+// считывают из QR-кода
+func URI(issuer, account string, secret []byte, p Params) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", string(p.Algorithm))
+	query.Set("digits", strconv.Itoa(p.Digits))
+	query.Set("period", strconv.Itoa(int(p.Period/time.Second)))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
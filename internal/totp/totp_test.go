@@ -0,0 +1,100 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Секреты из RFC 6238, приложение B: для каждого алгоритма своя длина ключа
+var rfcSecrets = map[Algorithm][]byte{
+	SHA1:   []byte("12345678901234567890"),
+	SHA256: []byte("12345678901234567890123456789012"),
+	SHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+}
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		alg  Algorithm
+		want string
+	}{
+		{59, SHA1, "94287082"},
+		{59, SHA256, "46119246"},
+		{59, SHA512, "90693936"},
+		{1111111109, SHA1, "07081804"},
+		{1111111109, SHA256, "68084774"},
+		{1111111109, SHA512, "25091201"},
+		{1111111111, SHA1, "14050471"},
+		{1111111111, SHA256, "67062674"},
+		{1111111111, SHA512, "99943326"},
+		{1234567890, SHA1, "89005924"},
+		{1234567890, SHA256, "91819424"},
+		{1234567890, SHA512, "93441116"},
+		{2000000000, SHA1, "69279037"},
+		{2000000000, SHA256, "90698825"},
+		{2000000000, SHA512, "38618901"},
+		{20000000000, SHA1, "65353130"},
+		{20000000000, SHA256, "77737706"},
+		{20000000000, SHA512, "47863826"},
+	}
+	for _, tt := range tests {
+		p := Params{Period: 30 * time.Second, Digits: 8, Algorithm: tt.alg}
+		got := Code(rfcSecrets[tt.alg], time.Unix(tt.unix, 0).UTC(), p)
+		if got != tt.want {
+			t.Errorf("Code(%s, %d) = %s, want %s", tt.alg, tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestHOTPRFC4226Vectors(t *testing.T) {
+	// RFC 4226, приложение D
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, code := range want {
+		if got := HOTP(rfcSecrets[SHA1], uint64(counter), 6, SHA1); got != code {
+			t.Errorf("HOTP(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := rfcSecrets[SHA1]
+	now := time.Unix(1111111111, 0)
+	current := Counter(now, DefaultParams.Period)
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+		step uint64
+		skew int
+	}{
+		{"current step", Code(secret, now, DefaultParams), true, current, 1},
+		{"previous step within skew", Code(secret, now.Add(-30*time.Second), DefaultParams), true, current - 1, 1},
+		{"next step within skew", Code(secret, now.Add(30*time.Second), DefaultParams), true, current + 1, 1},
+		{"previous step without skew", Code(secret, now.Add(-30*time.Second), DefaultParams), false, 0, 0},
+		{"too old", Code(secret, now.Add(-90*time.Second), DefaultParams), false, 0, 1},
+		{"wrong length", "12345", false, 0, 1},
+		{"not digits", "12345a", false, 0, 1},
+	}
+	for _, tt := range tests {
+		step, ok := Validate(secret, tt.code, now, DefaultParams, tt.skew)
+		if ok != tt.ok || step != tt.step {
+			t.Errorf("%s: Validate = (%d, %v), want (%d, %v)", tt.name, step, ok, tt.step, tt.ok)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Todo App", "alice@example.com", rfcSecrets[SHA1], DefaultParams)
+	want := "otpauth://totp/Todo%20App:alice@example.com?algorithm=SHA1&digits=6&issuer=Todo+App&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if uri != want {
+		t.Errorf("URI = %s, want %s", uri, want)
+	}
+	if strings.Contains(EncodeSecret(rfcSecrets[SHA1]), "=") {
+		t.Error("secret must not be padded")
+	}
+}